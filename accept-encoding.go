@@ -56,7 +56,7 @@ func parseQVHeader(header http.Header, headerName string) []QualityValue {
 		}
 		s = skipSpace(s[1:])
 	}
-	sort.Sort(sorter)
+	sort.Stable(sorter)
 	return sorter
 }
 
@@ -0,0 +1,391 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/neuron-core/annotation"
+	"github.com/neuronlabs/neuron-core/class"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+
+	handlerErrors "github.com/neuronlabs/jsonapi-handler/errors"
+	handlerClass "github.com/neuronlabs/jsonapi-handler/errors/class"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// Aggregate endpoint query parameters.
+const (
+	// QueryParamAggregateGroup is the query parameter with the comma separated fields that groups the aggregated resources.
+	QueryParamAggregateGroup = "group"
+	// QueryParamAggregateCount is the query parameter that marks to count the resources of each group.
+	// The resources are counted by default if no other aggregate function is set.
+	QueryParamAggregateCount = "count"
+	// QueryParamAggregateSum is the query parameter with the comma separated numeric attributes summed within each group.
+	QueryParamAggregateSum = "sum"
+)
+
+// AggregateWith returns the ListHandlerCreator of the endpoint that aggregates the 'model' resources.
+// The filters, search, soft delete and maximum page size options of the creator applies to the aggregate endpoint.
+// The pagination, sorting, counts and meta options are ignored.
+func (h *Creator) AggregateWith(model interface{}) *ListHandlerCreator {
+	return &ListHandlerCreator{
+		h:       h,
+		model:   h.c.MustGetModelStruct(model),
+		handler: h.handleAggregate,
+	}
+}
+
+// Aggregate returns the http.HandlerFunc that aggregates the 'model' resources i.e.:
+//
+//	GET /{collection}/aggregate?group=status&count&sum=amount
+//
+// The resources matching the list filters are grouped by the values of the 'group' fields. The results are
+// written within the 'meta' object of the response document in the order of the first group occurrence:
+//
+//	{"meta":{"aggregates":[{"group":{"status":"open"},"count":2,"sum":{"amount":12}}]}}
+//
+// The aggregation is computed by the model's repository if it implements the Aggregator interface. Otherwise
+// the request is rejected with the '501' status, unless the Creator's MaxAggregateResources is set. In such case
+// all the matching resources are listed into memory and aggregated by the handler, which costs a full scan of the
+// filtered resources per request, and the requests matching more resources than the MaxAggregateResources
+// are rejected with the '400' status.
+func (h *Creator) Aggregate(model interface{}) http.HandlerFunc {
+	return h.handleAggregate(h.c.MustGetModelStruct(model), &endpointOptions{})
+}
+
+func (h *Creator) handleAggregate(model *mapping.ModelStruct, o *endpointOptions) http.HandlerFunc {
+	h.registerEndpoint(EndpointAggregate, model, nil, o.basePath)
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if h.endpointNotAllowed(rw, req, EndpointAggregate, model, nil) {
+			return
+		}
+		ctx := req.Context()
+		s := query.NewModelC(h.c, model, true)
+		if err := h.parseQuery(ctx, req, EndpointAggregate, s, o); err != nil {
+			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
+			return
+		}
+		if err := checkRequiredFilters(s, o); err != nil {
+			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
+			return
+		}
+		if err := addDefaultFilters(s, o); err != nil {
+			log.Errorf("[AGGREGATE][SCOPE][%s] Adding default filters failed: %v", s.ID(), err)
+			h.marshalErrors(rw, req, 0, handlerErrors.ErrInternalError())
+			return
+		}
+		if err := h.excludeSoftDeleted(s); err != nil {
+			log.Errorf("[AGGREGATE][SCOPE][%s] Excluding soft deleted resources failed: %v", s.ID(), err)
+			h.marshalErrors(rw, req, 0, handlerErrors.ErrInternalError())
+			return
+		}
+
+		a := &Aggregation{}
+		if v, ok := s.StoreGet(scopeAggregationK); ok {
+			a = v.(*Aggregation)
+		}
+		if len(a.Sums) == 0 {
+			a.Count = true
+		}
+		fields := a.fields()
+		if len(fields) == 0 {
+			fields = append(fields, model.Primary())
+		}
+		if err := s.SetFields(fields...); err != nil {
+			log.Errorf("[AGGREGATE][SCOPE][%s] Setting fieldset failed: %v", s.ID(), err)
+			h.marshalErrors(rw, req, 0, handlerErrors.ErrInternalError())
+			return
+		}
+
+		if errs := h.authorize(req, EndpointAggregate, model, nil, s); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
+		isNoResult, err := h.resolveFilterGroups(ctx, s)
+		if err != nil {
+			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
+			return
+		}
+		results := []*AggregateResult{}
+		if !isNoResult {
+			if results, err = h.aggregate(ctx, s, a); err != nil {
+				h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
+				return
+			}
+		}
+
+		h.writeDocument(rw, req, http.StatusOK, document{"meta": map[string]interface{}{"aggregates": results}})
+	}
+}
+
+// aggregate computes the aggregation 'a' of the scope 's' resources. The aggregation is computed by the repository
+// if it implements the Aggregator. Otherwise, if the Creator's MaxAggregateResources is set, the resources are listed
+// and aggregated by the handler, where the number of the listed resources is limited by the MaxAggregateResources.
+func (h *Creator) aggregate(ctx context.Context, s *query.Scope, a *Aggregation) ([]*AggregateResult, error) {
+	repo, err := h.c.GetRepository(s.Struct())
+	if err != nil {
+		return nil, err
+	}
+	if aggregator, ok := repo.(Aggregator); ok {
+		results, err := aggregator.Aggregate(ctx, s, a)
+		if err == nil {
+			return results, nil
+		}
+		if e, ok := err.(errors.ClassError); !ok || e.Class() != handlerClass.RepositoryNotImplementsAggregator {
+			return nil, err
+		}
+		log.Debug2f("[AGGREGATE][SCOPE][%s] Repository doesn't implement the aggregation: %v", s.ID(), err)
+	}
+
+	if err = h.listAggregated(ctx, s); err != nil {
+		if e, ok := err.(errors.ClassError); ok && e.Class() == class.QueryValueNoResult {
+			return []*AggregateResult{}, nil
+		}
+		return nil, err
+	}
+	return a.aggregate(s.Value), nil
+}
+
+// listAggregated lists the scope 's' resources aggregated in memory by the handler. The resources are listed only
+// if the Creator's MaxAggregateResources is set, otherwise the error of the RepositoryNotImplementsAggregator class
+// is returned. The scope matching more resources than the MaxAggregateResources results in the QueryTooManyResources error.
+func (h *Creator) listAggregated(ctx context.Context, s *query.Scope) error {
+	maxResources := int64(h.MaxAggregateResources)
+	if maxResources <= 0 {
+		err := errors.NewDetf(handlerClass.RepositoryNotImplementsAggregator, "repository of: '%s' doesn't implement the aggregation", s.Struct().Collection())
+		err.SetDetailsf("The aggregation of the collection: '%s' is not supported.", s.Struct().Collection())
+		return err
+	}
+	// list at most one resource more than the maximum to find out if the aggregation exceeds it.
+	if err := s.Limit(maxResources+1, 0); err != nil {
+		return err
+	}
+	if err := s.ListContext(ctx); err != nil {
+		return err
+	}
+	if int64(reflect.ValueOf(s.Value).Elem().Len()) > maxResources {
+		err := errors.NewDetf(handlerClass.QueryTooManyResources, "aggregated resources exceeds the maximum: '%d'", maxResources)
+		err.SetDetailsf("The aggregation must not process more than: %d resources. Narrow the filters.", maxResources)
+		return err
+	}
+	return nil
+}
+
+// Aggregator is the optional interface of the model's repository that computes the aggregation of the scope 's'
+// resources within the repository. The scope contains the filters of the aggregated resources. The results should
+// be ordered by the first group occurrence. If the repository doesn't support given 'aggregation' it should return
+// the error of the RepositoryNotImplementsAggregator class - the handler aggregates the listed resources then.
+type Aggregator interface {
+	Aggregate(ctx context.Context, s *query.Scope, aggregation *Aggregation) ([]*AggregateResult, error)
+}
+
+// Aggregation is the set of the aggregate functions requested with the query parameters.
+type Aggregation struct {
+	// Groups are the fields that groups the aggregated resources.
+	Groups []*mapping.StructField
+	// Sums are the numeric attributes summed within each group.
+	Sums []*mapping.StructField
+	// Count marks to count the resources of each group.
+	Count bool
+}
+
+// fields gets the unique fields required to compute the aggregation.
+func (a *Aggregation) fields() []interface{} {
+	var fields []interface{}
+	for _, field := range append(append([]*mapping.StructField{}, a.Groups...), a.Sums...) {
+		var found bool
+		for _, f := range fields {
+			if f.(*mapping.StructField) == field {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// AggregateResult is the aggregation result of a single group. The 'Group' and 'Sum' are keyed by the neuron names
+// of the fields. The sums of the integer attributes are of int64 type and the sums of the float attributes
+// are of float64 type.
+type AggregateResult struct {
+	Group map[string]interface{} `json:"group,omitempty"`
+	Count *int                   `json:"count,omitempty"`
+	Sum   map[string]interface{} `json:"sum,omitempty"`
+}
+
+// aggregate computes the aggregation results for the resources slice 'value'.
+func (a *Aggregation) aggregate(value interface{}) []*AggregateResult {
+	results := []*AggregateResult{}
+	byKey := map[string]*AggregateResult{}
+
+	values := reflect.ValueOf(value).Elem()
+	for i := 0; i < values.Len(); i++ {
+		single := values.Index(i)
+		if single.Kind() == reflect.Ptr {
+			if single.IsNil() {
+				continue
+			}
+			single = single.Elem()
+		}
+
+		groupValues := make([]interface{}, len(a.Groups))
+		for j, field := range a.Groups {
+			groupValues[j] = fieldInterface(single.FieldByIndex(field.ReflectField().Index))
+		}
+		key := fmt.Sprintf("%#v", groupValues)
+		result, ok := byKey[key]
+		if !ok {
+			result = a.newGroup(groupValues)
+			byKey[key] = result
+			results = append(results, result)
+		}
+
+		if result.Count != nil {
+			*result.Count++
+		}
+		for _, field := range a.Sums {
+			fieldValue := single.FieldByIndex(field.ReflectField().Index)
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					continue
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			switch sum := result.Sum[field.NeuronName()].(type) {
+			case float64:
+				result.Sum[field.NeuronName()] = sum + fieldValue.Float()
+			case int64:
+				if isUnsignedKind(fieldValue.Kind()) {
+					result.Sum[field.NeuronName()] = sum + int64(fieldValue.Uint())
+				} else {
+					result.Sum[field.NeuronName()] = sum + fieldValue.Int()
+				}
+			}
+		}
+	}
+	return results
+}
+
+func (a *Aggregation) newGroup(groupValues []interface{}) *AggregateResult {
+	result := &AggregateResult{}
+	if len(a.Groups) > 0 {
+		result.Group = map[string]interface{}{}
+		for j, field := range a.Groups {
+			result.Group[field.NeuronName()] = groupValues[j]
+		}
+	}
+	if a.Count {
+		result.Count = new(int)
+	}
+	if len(a.Sums) > 0 {
+		result.Sum = map[string]interface{}{}
+		for _, field := range a.Sums {
+			if isFloatKind(derefType(field.ReflectField().Type).Kind()) {
+				result.Sum[field.NeuronName()] = float64(0)
+			} else {
+				result.Sum[field.NeuronName()] = int64(0)
+			}
+		}
+	}
+	return result
+}
+
+// queryParameterAggregate sets the aggregate function of the query parameter 'key' for the scope 's'.
+func (h *Creator) queryParameterAggregate(ctx context.Context, s *query.Scope, key, value string) error {
+	a := &Aggregation{}
+	if v, ok := s.StoreGet(scopeAggregationK); ok {
+		a = v.(*Aggregation)
+	} else {
+		s.StoreSet(scopeAggregationK, a)
+	}
+
+	if key == QueryParamAggregateCount {
+		switch value {
+		case "", "true":
+			a.Count = true
+		case "false":
+		default:
+			err := errors.NewDetf(handlerClass.QueryInvalidParameter, "invalid count value: '%s'", value)
+			err.SetDetailsf("The query parameter: '%s' value must be empty, 'true' or 'false'.", key)
+			return err
+		}
+		return nil
+	}
+
+	for _, name := range strings.Split(value, annotation.Separator) {
+		field, err := h.aggregateField(ctx, s.Struct(), key, name)
+		if err != nil {
+			return err
+		}
+		if key == QueryParamAggregateGroup {
+			a.Groups = append(a.Groups, field)
+			continue
+		}
+		if kind := derefType(field.ReflectField().Type).Kind(); field.Kind() != mapping.KindAttribute || !isNumericKind(kind) {
+			err := errors.NewDetf(handlerClass.QueryInvalidAggregateField, "field: '%s' is not numeric", name)
+			err.SetDetailsf("The query parameter: '%s' field: '%s' is not a numeric attribute.", key, name)
+			return err
+		}
+		a.Sums = append(a.Sums, field)
+	}
+	return nil
+}
+
+// aggregateField gets the readable attribute or foreign key 'name' of the 'model' used by the aggregate parameter 'key'.
+func (h *Creator) aggregateField(ctx context.Context, model *mapping.ModelStruct, key, name string) (*mapping.StructField, error) {
+	field, ok := model.Attribute(name)
+	if !ok {
+		field, ok = model.ForeignKey(name)
+	}
+	if !ok || field.IsHidden() {
+		err := errors.NewDetf(handlerClass.QueryInvalidAggregateField, "field: '%s' not found", name)
+		err.SetDetailsf("The query parameter: '%s' field: '%s' not found within the collection: '%s'.", key, name, model.Collection())
+		return nil, err
+	}
+	if !h.canRead(ctx, model, field) {
+		return nil, readForbiddenError(name)
+	}
+	return field, nil
+}
+
+// fieldInterface gets the interface value of the field 'v' with the pointers dereferenced.
+func fieldInterface(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+func isUnsignedKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+var scopeAggregationK scopeAggregation
+
+type scopeAggregation struct{}
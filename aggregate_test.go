@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+
+	handlerClass "github.com/neuronlabs/jsonapi-handler/errors/class"
+)
+
+// TestAggregate tests the aggregate endpoint.
+func TestAggregate(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, target string) *http.Request {
+		req, err := http.NewRequest("GET", target, nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req
+	}
+
+	repo, err := c.GetRepository(Human{})
+	require.NoError(t, err)
+
+	humansRepo, ok := repo.(*mocks.Repository)
+	require.True(t, ok)
+
+	type aggregates struct {
+		Meta struct {
+			Aggregates []struct {
+				Group map[string]interface{} `json:"group"`
+				Count *int                   `json:"count"`
+				Sum   map[string]float64     `json:"sum"`
+			} `json:"aggregates"`
+		} `json:"meta"`
+	}
+
+	t.Run("GroupSum", func(t *testing.T) {
+		h := NewC(c)
+		h.MaxAggregateResources = 10
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			assert.Len(t, s.AttributeFilters, 1)
+			v, ok := s.Value.(*[]*Human)
+			require.True(t, ok)
+			*v = append(*v, &Human{Name: "Jon", Age: 20}, &Human{Name: "Ann", Age: 30}, &Human{Name: "Jon", Age: 22})
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.Aggregate(Human{}).ServeHTTP(resp, newRequest(t, "/humen/aggregate?group=name&count&sum=age&filter[humen][age][$gt]=10"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		payload := aggregates{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		if assert.Len(t, payload.Meta.Aggregates, 2) {
+			jon := payload.Meta.Aggregates[0]
+			assert.Equal(t, map[string]interface{}{"name": "Jon"}, jon.Group)
+			if assert.NotNil(t, jon.Count) {
+				assert.Equal(t, 2, *jon.Count)
+			}
+			assert.Equal(t, map[string]float64{"age": 42}, jon.Sum)
+
+			ann := payload.Meta.Aggregates[1]
+			assert.Equal(t, map[string]interface{}{"name": "Ann"}, ann.Group)
+			assert.Equal(t, map[string]float64{"age": 30}, ann.Sum)
+		}
+	})
+
+	t.Run("DefaultCount", func(t *testing.T) {
+		h := NewC(c)
+		h.MaxAggregateResources = 10
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*[]*Human)
+			require.True(t, ok)
+			*v = append(*v, &Human{ID: 1}, &Human{ID: 2})
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.Aggregate(Human{}).ServeHTTP(resp, newRequest(t, "/humen/aggregate"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		payload := aggregates{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		if assert.Len(t, payload.Meta.Aggregates, 1) && assert.NotNil(t, payload.Meta.Aggregates[0].Count) {
+			assert.Equal(t, 2, *payload.Meta.Aggregates[0].Count)
+			assert.Nil(t, payload.Meta.Aggregates[0].Sum)
+		}
+	})
+
+	t.Run("TooManyResources", func(t *testing.T) {
+		h := NewC(c)
+		h.MaxAggregateResources = 2
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			// a single resource more than the maximum is listed.
+			if assert.NotNil(t, s.Pagination) {
+				assert.Equal(t, int64(3), s.Pagination.Size)
+			}
+			v, ok := s.Value.(*[]*Human)
+			require.True(t, ok)
+			*v = append(*v, &Human{ID: 1}, &Human{ID: 2}, &Human{ID: 3})
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.Aggregate(Human{}).ServeHTTP(resp, newRequest(t, "/humen/aggregate"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("NotImplemented", func(t *testing.T) {
+		h := NewC(c)
+
+		// the resources are not aggregated in memory by default.
+		resp := httptest.NewRecorder()
+		h.Aggregate(Human{}).ServeHTTP(resp, newRequest(t, "/humen/aggregate"))
+		assert.Equal(t, http.StatusNotImplemented, resp.Code)
+		humansRepo.AssertExpectations(t)
+	})
+
+	t.Run("Aggregator", func(t *testing.T) {
+		c, err := neuron.NewController(config.Default())
+		require.NoError(t, err)
+
+		err = c.RegisterRepository("aggregator", &config.Repository{DriverName: aggregatorDriverName})
+		require.NoError(t, err)
+
+		err = c.RegisterModels(Human{}, House{})
+		require.NoError(t, err)
+
+		repo, err := c.GetRepository(Human{})
+		require.NoError(t, err)
+
+		aggregator, ok := repo.(*aggregatorRepository)
+		require.True(t, ok)
+
+		count := 3
+		aggregator.On("Aggregate", mock.Anything, mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			a, ok := args[2].(*Aggregation)
+			require.True(t, ok)
+
+			assert.True(t, a.Count)
+			if assert.Len(t, a.Groups, 1) {
+				assert.Equal(t, "name", a.Groups[0].NeuronName())
+			}
+		}).Return([]*AggregateResult{{Group: map[string]interface{}{"name": "Jon"}, Count: &count}}, nil)
+
+		h := NewC(c)
+		h.MaxAggregateResources = 2
+
+		resp := httptest.NewRecorder()
+		h.Aggregate(Human{}).ServeHTTP(resp, newRequest(t, "/humen/aggregate?group=name"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		payload := aggregates{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		if assert.Len(t, payload.Meta.Aggregates, 1) && assert.NotNil(t, payload.Meta.Aggregates[0].Count) {
+			assert.Equal(t, 3, *payload.Meta.Aggregates[0].Count)
+		}
+
+		// the handler aggregates the listed resources if the repository doesn't support the aggregation.
+		aggregator.On("Aggregate", mock.Anything, mock.Anything, mock.Anything).Once().
+			Return(nil, errors.NewDet(handlerClass.RepositoryNotImplementsAggregator, "not implemented"))
+		aggregator.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*[]*Human)
+			require.True(t, ok)
+			*v = append(*v, &Human{ID: 1, Name: "Jon"})
+		}).Return(nil)
+
+		resp = httptest.NewRecorder()
+		h.Aggregate(Human{}).ServeHTTP(resp, newRequest(t, "/humen/aggregate?group=name"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		payload = aggregates{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		if assert.Len(t, payload.Meta.Aggregates, 1) && assert.NotNil(t, payload.Meta.Aggregates[0].Count) {
+			assert.Equal(t, 1, *payload.Meta.Aggregates[0].Count)
+		}
+		aggregator.AssertExpectations(t)
+	})
+
+	t.Run("InvalidField", func(t *testing.T) {
+		h := NewC(c)
+		for _, target := range []string{"/humen/aggregate?group=unknown", "/humen/aggregate?sum=name", "/humen/aggregate?count=yes"} {
+			resp := httptest.NewRecorder()
+			h.Aggregate(Human{}).ServeHTTP(resp, newRequest(t, target))
+			assert.Equal(t, http.StatusBadRequest, resp.Code, target)
+		}
+	})
+
+	t.Run("ListOptions", func(t *testing.T) {
+		h := NewC(c)
+		h.MaxAggregateResources = 10
+		handler := h.AggregateWith(Human{}).RequiredFilters("age").FilterableFields("age").DefaultFilter("filter[humen][name][$ne]", "Bob").Handler()
+
+		for _, target := range []string{"/humen/aggregate", "/humen/aggregate?filter[humen][age][$gt]=10&filter[humen][id]=1"} {
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, newRequest(t, target))
+			assert.Equal(t, http.StatusBadRequest, resp.Code, target)
+		}
+
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			// the client and default filters.
+			assert.Len(t, s.AttributeFilters, 2)
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, newRequest(t, "/humen/aggregate?filter[humen][age][$gt]=10"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("Endpoint", func(t *testing.T) {
+		h := NewC(c)
+		h.AggregateWith(Human{}).BasePath("api").Handler()
+		endpoints := h.Endpoints()
+		if assert.Len(t, endpoints, 1) {
+			assert.Equal(t, "/api/humen/aggregate", endpoints[0].Path())
+			assert.Equal(t, http.MethodGet, endpoints[0].Type.Method())
+		}
+	})
+	humansRepo.AssertExpectations(t)
+}
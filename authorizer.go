@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/annotation"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+
+	handlerErrors "github.com/neuronlabs/jsonapi-handler/errors"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// Authorizer is the interface used by the handlers to authorize the requests. It is called by all the handlers
+// before the query scope is executed and before the Before* hooks.
+type Authorizer interface {
+	// Authorize authorizes the request 'req' on the 'endpoint' of the 'model'. The 'field' is the relationship field
+	// of the relationship endpoints and of the included resources, otherwise it is nil. The included resources
+	// are authorized once per each included model - if the model is included by multiple relationship fields
+	// the 'field' is nil and the scope filters restrict the resources of all these relationships.
+	// The scope 's' is the query scope that is about to be executed. The authorizer might add the filters
+	// to the scope in order to restrict the resources accessible by the request. The scope model might differ
+	// from the 'model' for the related and included resources. The GetRelated endpoint authorizes both the root
+	// resource scope and the related resources scope.
+	//
+	// A nil error allows the request. The errors.ClassError is mapped into the api errors i.e.: the errors
+	// of class.AccessForbidden and class.AccessEndpointForbidden are mapped into the ErrForbidden
+	// and ErrEndpointForbidden. Any other error results in ErrForbidden.
+	Authorize(req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField, s *query.Scope) error
+}
+
+// AuthorizerFunc is the function that implements Authorizer interface.
+type AuthorizerFunc func(req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField, s *query.Scope) error
+
+// Authorize implements Authorizer interface.
+func (f AuthorizerFunc) Authorize(req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField, s *query.Scope) error {
+	return f(req, endpoint, model, field, s)
+}
+
+// authorize authorizes the scope 's' with the Creator's Authorizer. If the request is denied
+// the function returns the api errors.
+func (h *Creator) authorize(req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField, s *query.Scope) []*jsonapi.Error {
+	if h.Authorizer == nil {
+		return nil
+	}
+	err := h.Authorizer.Authorize(req, endpoint, model, field, s)
+	if err == nil {
+		return nil
+	}
+	log.Debug2f("[%s][%s] %s authorization denied: %v", endpoint, model.Collection(), s.Struct().Collection(), err)
+	switch err.(type) {
+	case errors.ClassError, errors.MultiError:
+		return handlerErrors.MapError(err)
+	}
+	apiErr := handlerErrors.ErrForbidden()
+	apiErr.Detail = err.Error()
+	return []*jsonapi.Error{apiErr}
+}
+
+// authorizeIncludes authorizes the scopes included into the scope 's' by the 'include' query parameter.
+// The included scope is shared by all the relationship fields that include the same model, thus it is authorized
+// once per each included model and its filters restrict the resources of all these relationships. The 'field' is
+// provided to the Authorizer only if the model is included by a single relationship field, otherwise it is nil.
+func (h *Creator) authorizeIncludes(req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, s *query.Scope) []*jsonapi.Error {
+	if h.Authorizer == nil {
+		return nil
+	}
+	var models []*mapping.ModelStruct
+	fields := map[*mapping.ModelStruct][]*mapping.StructField{}
+	for _, included := range req.URL.Query()[query.ParamInclude] {
+		for _, include := range strings.Split(included, annotation.Separator) {
+			current := s.Struct()
+			for _, name := range strings.Split(include, annotation.NestedSeparator) {
+				field, ok := current.RelationField(name)
+				if !ok {
+					break
+				}
+				current = field.Relationship().Struct()
+				modelFields, ok := fields[current]
+				if !ok {
+					models = append(models, current)
+				}
+				if !containsField(modelFields, field) {
+					fields[current] = append(modelFields, field)
+				}
+			}
+		}
+	}
+	for _, includedModel := range models {
+		includedScope, err := s.IncludedScope(includedModel)
+		if err != nil {
+			// the include had been stripped from the scope.
+			continue
+		}
+		var field *mapping.StructField
+		if len(fields[includedModel]) == 1 {
+			field = fields[includedModel][0]
+		}
+		if errs := h.authorize(req, endpoint, model, field, includedScope); errs != nil {
+			return errs
+		}
+	}
+	return nil
+}
+
+// containsField checks if the 'fields' contains the 'field'.
+func containsField(fields []*mapping.StructField, field *mapping.StructField) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+
+	handlerClass "github.com/neuronlabs/jsonapi-handler/errors/class"
+)
+
+// TestAuthorizer tests the request authorization.
+func TestAuthorizer(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{}, Parcel{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, method, target string) *http.Request {
+		req, err := http.NewRequest(method, target, nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req
+	}
+
+	t.Run("Deny", func(t *testing.T) {
+		h := NewC(c)
+		h.IDExtractor = PathSuffixIDExtractor{}
+		h.Authorizer = AuthorizerFunc(func(req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField, s *query.Scope) error {
+			switch endpoint {
+			case EndpointDelete:
+				return errors.NewDet(handlerClass.AccessEndpointForbidden, "delete is forbidden")
+			case EndpointGet:
+				return fmt.Errorf("not an owner")
+			}
+			return nil
+		})
+
+		resp := httptest.NewRecorder()
+		h.Delete(House{}).ServeHTTP(resp, newRequest(t, "DELETE", "/houses/1"))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+
+		payload, err := jsonapi.UnmarshalErrors(resp.Body)
+		require.NoError(t, err)
+		if assert.Len(t, payload.Errors, 1) {
+			code, err := strconv.ParseInt(payload.Errors[0].Code, 16, 32)
+			require.NoError(t, err)
+			assert.Equal(t, handlerClass.AccessEndpointForbidden, errors.Class(code))
+		}
+
+		resp = httptest.NewRecorder()
+		h.Get(House{}).ServeHTTP(resp, newRequest(t, "GET", "/houses/1"))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("Include", func(t *testing.T) {
+		h := NewC(c)
+		var included []*mapping.StructField
+		h.Authorizer = AuthorizerFunc(func(req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField, s *query.Scope) error {
+			if s.Struct() != model {
+				included = append(included, field)
+				return errors.NewDet(handlerClass.AccessForbidden, "reading owners is forbidden")
+			}
+			return nil
+		})
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "GET", "/houses?include=owner"))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		if assert.Len(t, included, 1) {
+			assert.Equal(t, "owner", included[0].NeuronName())
+		}
+	})
+
+	t.Run("IncludeFields", func(t *testing.T) {
+		h := NewC(c)
+		var included []*mapping.StructField
+		h.Authorizer = AuthorizerFunc(func(req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField, s *query.Scope) error {
+			if s.Struct() != model {
+				included = append(included, field)
+			}
+			return nil
+		})
+
+		repo, err := c.GetRepository(Parcel{})
+		require.NoError(t, err)
+
+		parcelsRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+		parcelsRepo.On("List", mock.Anything, mock.Anything).Once().Return(nil)
+
+		// the included scope shared by multiple relationships is authorized once, without the relationship field.
+		resp := httptest.NewRecorder()
+		h.List(Parcel{}).ServeHTTP(resp, newRequest(t, "GET", "/parcels?include=sender,recipient"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		if assert.Len(t, included, 1) {
+			assert.Nil(t, included[0])
+		}
+
+		parcelsRepo.On("List", mock.Anything, mock.Anything).Once().Return(nil)
+
+		included = nil
+		resp = httptest.NewRecorder()
+		h.List(Parcel{}).ServeHTTP(resp, newRequest(t, "GET", "/parcels?include=sender"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		if assert.Len(t, included, 1) && assert.NotNil(t, included[0]) {
+			assert.Equal(t, "sender", included[0].NeuronName())
+		}
+	})
+
+	t.Run("Relationship", func(t *testing.T) {
+		h := NewC(c)
+		h.Authorizer = AuthorizerFunc(func(req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField, s *query.Scope) error {
+			if s.Struct() == model {
+				return nil
+			}
+			assert.Equal(t, EndpointGetRelationship, endpoint)
+			address, _ := s.Struct().Attribute("address")
+			return s.FilterField(query.NewFilter(address, query.OpNotEqual, "Hidden"))
+		})
+
+		repo, err := c.GetRepository(Human{})
+		require.NoError(t, err)
+
+		humansRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		repo, err = c.GetRepository(House{})
+		require.NoError(t, err)
+
+		housesRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		humansRepo.On("Get", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*Human)
+			require.True(t, ok)
+			v.ID = 1
+		}).Return(nil)
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*[]*House)
+			require.True(t, ok)
+			*v = append(*v, &House{ID: 1}, &House{ID: 2})
+		}).Return(nil)
+		// only the related resources accessible within the authorized related scope are written.
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if assert.Len(t, s.PrimaryFilters, 1) && assert.Len(t, s.PrimaryFilters[0].Values, 1) {
+				assert.Equal(t, query.OpIn, s.PrimaryFilters[0].Values[0].Operator)
+				assert.Equal(t, []interface{}{1, 2}, s.PrimaryFilters[0].Values[0].Values)
+			}
+			if assert.Len(t, s.AttributeFilters, 1) {
+				assert.Equal(t, "address", s.AttributeFilters[0].StructField.NeuronName())
+			}
+			v, ok := s.Value.(*[]*House)
+			require.True(t, ok)
+			*v = append(*v, &House{ID: 2})
+		}).Return(nil)
+
+		req := newRequest(t, "GET", "/humen/1/relationships/houses")
+		req = req.WithContext(context.WithValue(req.Context(), IDKey, "1"))
+		resp := httptest.NewRecorder()
+		h.GetRelationship(Human{}, "houses").ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		houses := []*House{}
+		require.NoError(t, jsonapi.UnmarshalC(c, resp.Body, &houses))
+		if assert.Len(t, houses, 1) {
+			assert.Equal(t, 2, houses[0].ID)
+		}
+		housesRepo.AssertExpectations(t)
+	})
+
+	t.Run("RowLevel", func(t *testing.T) {
+		h := NewC(c)
+		h.Authorizer = AuthorizerFunc(func(req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField, s *query.Scope) error {
+			if endpoint == EndpointList {
+				ownerID, _ := s.Struct().ForeignKey("owner_id")
+				return s.FilterField(query.NewFilter(ownerID, query.OpEqual, 3))
+			}
+			return nil
+		})
+
+		repo, err := c.GetRepository(House{})
+		require.NoError(t, err)
+
+		housesRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if assert.Len(t, s.ForeignFilters, 1) {
+				assert.Equal(t, "owner_id", s.ForeignFilters[0].StructField.NeuronName())
+			}
+
+			v, ok := s.Value.(*[]*House)
+			require.True(t, ok)
+			*v = append(*v, &House{ID: 1, Address: "Main Rd 52", OwnerID: 3})
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "GET", "/houses"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+}
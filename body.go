@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/neuronlabs/brotli"
+	"github.com/neuronlabs/errors"
+
+	"github.com/neuronlabs/jsonapi-handler/errors/class"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// keyIncluded is the top-level document key that contains included resources.
+const keyIncluded = "included"
+
+// maxBodySize gets the request body size limit for provided endpoint options.
+func (h *Creator) maxBodySize(o *endpointOptions) int64 {
+	if o != nil && o.maxBodySize != 0 {
+		return o.maxBodySize
+	}
+	return h.MaxBodySize
+}
+
+// requestBody wraps the request body with the document reader that guards the size and the
+// structure of the incoming document. If the request body is encoded with the 'Content-Encoding' header
+// it is transparently decoded. The size of the decoded body is limited by the MaxDecompressedBodySize.
+// For the unsupported content encoding, the supported ones are set in the 'rw' Accept-Encoding header.
+func (h *Creator) requestBody(rw http.ResponseWriter, req *http.Request, o *endpointOptions) (*documentReader, error) {
+	body := &documentReader{
+		r:           req.Body,
+		limit:       h.maxBodySize(o),
+		maxDepth:    h.MaxDocumentDepth,
+		maxIncluded: h.MaxIncludedResources,
+	}
+	encodings := contentEncodings(req.Header)
+	if len(encodings) == 0 {
+		return body, nil
+	}
+	// the encoded body is limited by the endpoint body size limit, where the decoded document
+	// is limited by the decompressed body size limit.
+	source := &documentReader{r: req.Body, limit: body.limit}
+	body.source = source
+	if h.MaxDecompressedBodySize > 0 {
+		body.limit = h.MaxDecompressedBodySize
+	}
+
+	r := io.Reader(source)
+	// the encodings are listed in the order they were applied - decode them in the reverse order.
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var err error
+		switch encodings[i] {
+		case EncodingGzip, "x-gzip":
+			r, err = gzip.NewReader(r)
+		case EncodingDeflate:
+			r, err = newDeflateReader(r)
+		case EncodingBrotli:
+			r = brotli.NewReader(r)
+		default:
+			log.Debugf("Unsupported request body content encoding: '%s'", encodings[i])
+			// RFC 7694 - the unsupported content encoding response lists the accepted encodings.
+			rw.Header().Set("Accept-Encoding", strings.Join(requestEncodings, ", "))
+			e := errors.NewDetf(class.InputBodyUnsupportedEncoding, "unsupported content encoding: '%s'", encodings[i])
+			e.SetDetailsf("The request body content encoding: '%s' is not supported.", encodings[i])
+			return nil, e
+		}
+		if err != nil {
+			return nil, body.decodingError(err)
+		}
+	}
+	body.r = r
+	return body, nil
+}
+
+// requestEncodings are the content encodings of the request body decoded by the handlers.
+var requestEncodings = []string{EncodingGzip, EncodingDeflate, EncodingBrotli}
+
+// contentEncodings gets the lower cased request 'Content-Encoding' values without the 'identity' encoding.
+func contentEncodings(header http.Header) (encodings []string) {
+	for _, value := range header.Values("Content-Encoding") {
+		for _, encoding := range strings.Split(value, ",") {
+			encoding = strings.ToLower(strings.TrimSpace(encoding))
+			if encoding == "" || encoding == EncodingIdentity {
+				continue
+			}
+			encodings = append(encodings, encoding)
+		}
+	}
+	return encodings
+}
+
+// newDeflateReader creates the reader for the 'deflate' content encoding. According to the RFC 9110 the 'deflate'
+// is the zlib data format, but as many clients send the raw deflate stream both formats are accepted.
+func newDeflateReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	// check the zlib header - compression method deflate with the valid header checksum.
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// documentReader is the io.Reader wrapper that checks the incoming JSON document while it is being read.
+// It limits the number of bytes read, the nesting depth of the document and the number of
+// top-level 'included' resources. The document is checked in a streaming manner so that
+// none of the limits requires reading the whole body into the memory.
+type documentReader struct {
+	r io.Reader
+
+	limit       int64
+	maxDepth    int
+	maxIncluded int
+
+	read  int64
+	depth int
+
+	inString bool
+	escaped  bool
+	// key is the last string read at the top-level object.
+	key         []byte
+	collectKey  bool
+	lastKey     string
+	lastString  string
+	inIncluded  bool
+	expectValue bool
+	included    int
+
+	// source is the reader of the encoded request body. It is set only if the body is decoded.
+	source *documentReader
+
+	err error
+}
+
+// Read implements io.Reader interface.
+func (d *documentReader) Read(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	if d.limit > 0 && int64(len(p)) > d.limit-d.read+1 {
+		// read at most one byte more than allowed so that the limit is detected without the excessive reads.
+		p = p[:d.limit-d.read+1]
+	}
+	n, err := d.r.Read(p)
+	if err != nil && err != io.EOF && d.source != nil {
+		d.err = d.decodingError(err)
+		return 0, d.err
+	}
+	d.read += int64(n)
+	if d.limit > 0 && d.read > d.limit {
+		log.Debugf("Request body exceeds the limit of: %d bytes", d.limit)
+		e := errors.NewDetf(class.InputBodyTooLarge, "request body exceeds the limit of: %d bytes", d.limit)
+		e.SetDetailsf("The request body exceeds the maximum permitted size of: %d bytes.", d.limit)
+		d.err = e
+		return 0, d.err
+	}
+	if d.maxDepth > 0 || d.maxIncluded > 0 {
+		if scanErr := d.scan(p[:n]); scanErr != nil {
+			d.err = scanErr
+			return 0, d.err
+		}
+	}
+	return n, err
+}
+
+// unmarshalError returns the document reader error if it occurred. Otherwise returns provided 'err'.
+func (d *documentReader) unmarshalError(err error) error {
+	if d.err != nil {
+		return d.err
+	}
+	return err
+}
+
+// decodingError returns the error that occurred while decoding the request body. If the encoded
+// body exceeds its size limit, the source reader error is returned.
+func (d *documentReader) decodingError(err error) error {
+	if d.source != nil && d.source.err != nil {
+		return d.source.err
+	}
+	log.Debugf("Decoding request body failed: %v", err)
+	e := errors.NewDet(class.InputBodyInvalidEncoding, "decoding request body failed")
+	e.SetDetails("The request body couldn't be decoded with provided content encoding.")
+	return e
+}
+
+func (d *documentReader) scan(p []byte) error {
+	for _, b := range p {
+		if d.inString {
+			switch {
+			case d.escaped:
+				d.escaped = false
+			case b == '\\':
+				d.escaped = true
+			case b == '"':
+				d.inString = false
+				if d.collectKey {
+					d.lastString = string(d.key)
+					d.collectKey = false
+				}
+				continue
+			}
+			if d.collectKey && len(d.key) < len(keyIncluded) {
+				d.key = append(d.key, b)
+			} else {
+				// the key is longer than the one we're looking for.
+				d.collectKey = false
+			}
+			continue
+		}
+
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+
+		if d.inIncluded && d.depth == 2 {
+			switch b {
+			case ',':
+				d.expectValue = true
+			case ']':
+			default:
+				if d.expectValue {
+					d.expectValue = false
+					d.included++
+					if d.maxIncluded > 0 && d.included > d.maxIncluded {
+						log.Debugf("Request document contains more than: %d included resources", d.maxIncluded)
+						err := errors.NewDetf(class.InputBodyTooManyIncluded, "too many included resources")
+						err.SetDetailsf("The request document contains more than: %d included resources.", d.maxIncluded)
+						return err
+					}
+				}
+			}
+		}
+
+		switch b {
+		case '"':
+			d.inString = true
+			if d.depth == 1 {
+				d.collectKey = true
+				d.key = d.key[:0]
+				d.lastString = ""
+			}
+		case ':':
+			if d.depth == 1 {
+				d.lastKey = d.lastString
+			}
+		case ',':
+			if d.depth == 1 {
+				d.lastKey = ""
+			}
+		case '{', '[':
+			d.depth++
+			if d.maxDepth > 0 && d.depth > d.maxDepth {
+				log.Debugf("Request document exceeds the nesting limit of: %d", d.maxDepth)
+				err := errors.NewDetf(class.InputBodyTooDeep, "document nesting exceeds the limit of: %d", d.maxDepth)
+				err.SetDetailsf("The request document exceeds the maximum nesting depth of: %d.", d.maxDepth)
+				return err
+			}
+			if b == '[' && d.depth == 2 && d.lastKey == keyIncluded {
+				d.inIncluded = true
+				d.expectValue = true
+			}
+		case '}', ']':
+			if d.depth == 2 && d.inIncluded {
+				d.inIncluded = false
+			}
+			d.depth--
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/neuronlabs/brotli"
+	"github.com/neuronlabs/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/jsonapi-handler/errors/class"
+)
+
+// TestDocumentReader tests the documentReader limits.
+func TestDocumentReader(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		doc := `{"data":{"type":"houses","attributes":{"address":"[{\"a\"}]"}},"included":[{"type":"humen","id":"1"}]}`
+		d := &documentReader{r: strings.NewReader(doc), limit: int64(len(doc)), maxDepth: 3, maxIncluded: 1}
+
+		data, err := ioutil.ReadAll(d)
+		require.NoError(t, err)
+		assert.Equal(t, doc, string(data))
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		doc := `{"data":{"type":"houses"}}`
+		d := &documentReader{r: strings.NewReader(doc), limit: int64(len(doc)) - 1}
+
+		_, err := ioutil.ReadAll(d)
+		require.Error(t, err)
+
+		ce, ok := err.(errors.ClassError)
+		require.True(t, ok)
+		assert.Equal(t, class.InputBodyTooLarge, ce.Class())
+	})
+
+	t.Run("TooDeep", func(t *testing.T) {
+		doc := `{"data":{"type":"houses","attributes":{"nested":[[[1]]]}}}`
+		d := &documentReader{r: strings.NewReader(doc), maxDepth: 4}
+
+		_, err := ioutil.ReadAll(d)
+		require.Error(t, err)
+
+		ce, ok := err.(errors.ClassError)
+		require.True(t, ok)
+		assert.Equal(t, class.InputBodyTooDeep, ce.Class())
+	})
+
+	t.Run("TooManyIncluded", func(t *testing.T) {
+		doc := `{"data":{"type":"houses","included":[1,2,3]},"included":[{"type":"humen","id":"1"},{"type":"humen","id":"2"}]}`
+		d := &documentReader{r: strings.NewReader(doc), maxIncluded: 1}
+
+		_, err := ioutil.ReadAll(d)
+		require.Error(t, err)
+
+		ce, ok := err.(errors.ClassError)
+		require.True(t, ok)
+		assert.Equal(t, class.InputBodyTooManyIncluded, ce.Class())
+		assert.Equal(t, 2, d.included)
+	})
+}
+
+// TestRequestBody tests decoding the encoded request bodies.
+func TestRequestBody(t *testing.T) {
+	doc := `{"data":{"type":"houses","attributes":{"address":"Some"}}}`
+
+	encode := func(t *testing.T, encoding string, data string) *bytes.Buffer {
+		buf := &bytes.Buffer{}
+		var w io.WriteCloser
+		switch encoding {
+		case "gzip":
+			w = gzip.NewWriter(buf)
+		case "zlib":
+			w = zlib.NewWriter(buf)
+		case "flate":
+			fw, err := flate.NewWriter(buf, flate.DefaultCompression)
+			require.NoError(t, err)
+			w = fw
+		case "br":
+			w = brotli.NewWriter(buf)
+		}
+		_, err := w.Write([]byte(data))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf
+	}
+
+	readBody := func(h *Creator, encoding string, body io.Reader) ([]byte, error) {
+		req := httptest.NewRequest("POST", "/houses", body)
+		req.Header.Set("Content-Encoding", encoding)
+		d, err := h.requestBody(httptest.NewRecorder(), req, nil)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(d)
+	}
+
+	assertClass := func(t *testing.T, err error, c errors.Class) {
+		require.Error(t, err)
+		ce, ok := err.(errors.ClassError)
+		require.True(t, ok)
+		assert.Equal(t, c, ce.Class())
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		tests := []struct {
+			Name     string
+			Encoding string
+			Format   string
+		}{
+			{"Gzip", "gzip", "gzip"},
+			{"Zlib", "deflate", "zlib"},
+			{"RawDeflate", "deflate", "flate"},
+			{"Brotli", "br", "br"},
+			{"UpperCase", "GZIP", "gzip"},
+		}
+		h := NewC(nil)
+		for _, tc := range tests {
+			t.Run(tc.Name, func(t *testing.T) {
+				data, err := readBody(h, tc.Encoding, encode(t, tc.Format, doc))
+				require.NoError(t, err)
+				assert.Equal(t, doc, string(data))
+			})
+		}
+	})
+
+	t.Run("Identity", func(t *testing.T) {
+		data, err := readBody(NewC(nil), "identity", strings.NewReader(doc))
+		require.NoError(t, err)
+		assert.Equal(t, doc, string(data))
+	})
+
+	t.Run("Multiple", func(t *testing.T) {
+		data, err := readBody(NewC(nil), "gzip, br", encode(t, "br", encode(t, "gzip", doc).String()))
+		require.NoError(t, err)
+		assert.Equal(t, doc, string(data))
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := readBody(NewC(nil), "compress", strings.NewReader(doc))
+		assertClass(t, err, class.InputBodyUnsupportedEncoding)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := readBody(NewC(nil), "gzip", strings.NewReader(doc))
+		assertClass(t, err, class.InputBodyInvalidEncoding)
+	})
+
+	t.Run("DecompressedTooLarge", func(t *testing.T) {
+		h := NewC(nil)
+		h.MaxDecompressedBodySize = 1024
+
+		body := encode(t, "gzip", strings.Repeat("a", 4096))
+		require.True(t, body.Len() < 1024)
+
+		_, err := readBody(h, "gzip", body)
+		assertClass(t, err, class.InputBodyTooLarge)
+	})
+
+	t.Run("EncodedTooLarge", func(t *testing.T) {
+		h := NewC(nil)
+		h.MaxBodySize = 10
+
+		_, err := readBody(h, "gzip", encode(t, "gzip", doc))
+		assertClass(t, err, class.InputBodyTooLarge)
+	})
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/neuronlabs/brotli"
+
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// Content encodings supported by the handler writers.
+const (
+	EncodingGzip     = "gzip"
+	EncodingDeflate  = "deflate"
+	EncodingBrotli   = "br"
+	EncodingZstd     = "zstd"
+	EncodingIdentity = "identity"
+)
+
+// supportedEncodings are the content encodings supported by the writer in the server's preference order.
+var supportedEncodings = []string{EncodingGzip, EncodingBrotli, EncodingZstd, EncodingDeflate}
+
+func isSupportedEncoding(encoding string) bool {
+	for _, supported := range supportedEncodings {
+		if supported == encoding {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding gets the content encoding for the response based on the 'Accept-Encoding' header of the 'req'.
+// The encodings with zero quality are not acceptable. The wildcard '*' matches any supported encoding
+// not explicitly listed in the header. If no supported encoding is acceptable, or if the 'identity' is preferred,
+// the function returns an empty string - the response should not be encoded.
+func negotiateEncoding(req *http.Request) string {
+	accepts := ParseAcceptEncoding(req.Header)
+	for _, accept := range accepts {
+		if accept.Quality <= 0 {
+			// the encodings with 'q=0' are not acceptable and are sorted at the end.
+			break
+		}
+		value := strings.ToLower(accept.Value)
+		switch {
+		case value == EncodingIdentity:
+			return ""
+		case value == "*":
+			for _, encoding := range supportedEncodings {
+				if !isEncodingListed(accepts, encoding) {
+					return encoding
+				}
+			}
+		case isSupportedEncoding(value):
+			return value
+		}
+	}
+	// according to the RFC 9110 if none of the encodings is acceptable the response should be sent without
+	// any content coding - even if the 'identity;q=0' is provided.
+	return ""
+}
+
+func isEncodingListed(accepts []QualityValue, encoding string) bool {
+	for _, accept := range accepts {
+		if strings.EqualFold(accept.Value, encoding) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressionLevel gets the compression level for provided 'encoding'. The level is taken from the
+// Creator's CompressionLevels or from the CompressionLevel if not defined. A negative level
+// sets the default level for given encoding, other values are fitted into the encoding levels range.
+func (h *Creator) compressionLevel(encoding string) int {
+	level, ok := h.CompressionLevels[encoding]
+	if !ok {
+		level = h.CompressionLevel
+	}
+
+	var minLevel, maxLevel, defaultLevel int
+	switch encoding {
+	case EncodingGzip:
+		minLevel, maxLevel, defaultLevel = gzip.BestSpeed, gzip.BestCompression, gzip.DefaultCompression
+	case EncodingDeflate:
+		minLevel, maxLevel, defaultLevel = flate.BestSpeed, flate.BestCompression, flate.DefaultCompression
+	case EncodingBrotli:
+		minLevel, maxLevel, defaultLevel = brotli.BestSpeed, brotli.BestCompression, brotli.DefaultCompression
+	case EncodingZstd:
+		minLevel, maxLevel, defaultLevel = 1, 22, 3
+	}
+
+	switch {
+	case level < 0:
+		return defaultLevel
+	case level < minLevel:
+		return minLevel
+	case level > maxLevel:
+		return maxLevel
+	}
+	return level
+}
+
+// compressWriter is the io.WriteCloser that writes the response with the negotiated content encoding.
+// It defers writing the response header until the first MinCompressSize bytes are buffered or the
+// writer is closed. If the body is smaller than the MinCompressSize it is written without the compression.
+type compressWriter struct {
+	h        *Creator
+	rw       http.ResponseWriter
+	status   int
+	encoding string
+	minSize  int
+
+	buf     []byte
+	w       io.Writer
+	decided bool
+}
+
+// Write implements io.Writer interface.
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if c.decided {
+		return c.w.Write(p)
+	}
+	c.buf = append(c.buf, p...)
+	if len(c.buf) < c.minSize {
+		return len(p), nil
+	}
+	if err := c.decide(true); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close implements io.Closer interface. It writes all buffered data and closes the compressor.
+func (c *compressWriter) Close() error {
+	if !c.decided {
+		if err := c.decide(len(c.buf) >= c.minSize); err != nil {
+			return err
+		}
+	}
+	if wc, ok := c.w.(io.WriteCloser); ok {
+		return wc.Close()
+	}
+	return nil
+}
+
+// Flush writes the buffered data and flushes the compressor and the response writer.
+func (c *compressWriter) Flush() error {
+	if !c.decided {
+		if err := c.decide(len(c.buf) >= c.minSize); err != nil {
+			return err
+		}
+	}
+	if f, ok := c.w.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+	if f, ok := c.rw.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// decide sets up the underlying writer, writes the response header and the buffered data.
+func (c *compressWriter) decide(compress bool) (err error) {
+	c.decided = true
+	c.w = c.rw
+	if compress && c.encoding != "" {
+		level := c.h.compressionLevel(c.encoding)
+		var w io.Writer
+		switch c.encoding {
+		case EncodingGzip:
+			w, err = gzip.NewWriterLevel(c.rw, level)
+		case EncodingDeflate:
+			w, err = flate.NewWriter(c.rw, level)
+		case EncodingBrotli:
+			w = brotli.NewWriterLevel(c.rw, level)
+		case EncodingZstd:
+			w, err = zstd.NewWriter(c.rw, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)), zstd.WithEncoderConcurrency(1))
+		}
+		if err != nil {
+			log.Warningf("Can't create compressed writer: %v", err)
+		} else {
+			if log.Level() == log.LDEBUG3 {
+				log.Debug3f("Writer: '%s' with compression level: %d", c.encoding, level)
+			}
+			c.rw.Header().Set("Content-Encoding", c.encoding)
+			c.w = w
+		}
+	}
+	c.rw.WriteHeader(c.status)
+	if len(c.buf) > 0 {
+		_, err = c.w.Write(c.buf)
+		c.buf = nil
+	}
+	return err
+}
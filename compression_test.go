@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNegotiateEncoding tests the content encoding negotiation.
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Header   string
+		Expected string
+	}{
+		{"NoHeader", "", ""},
+		{"Single", "zstd", "zstd"},
+		{"Quality", "gzip;q=0.5, br;q=0.8", "br"},
+		{"ClientOrder", "deflate, gzip", "deflate"},
+		{"ZeroQuality", "gzip;q=0, deflate", "deflate"},
+		{"OnlyZeroQuality", "gzip;q=0", ""},
+		{"Unsupported", "compress", ""},
+		{"Identity", "identity, gzip;q=0.5", ""},
+		{"IdentityZero", "identity;q=0, compress", ""},
+		{"Wildcard", "*", "gzip"},
+		{"WildcardExcluded", "gzip;q=0, *", "br"},
+		{"WildcardLowerQuality", "*;q=0.1, deflate;q=0.5", "deflate"},
+		{"CaseInsensitive", "GZIP", "gzip"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/houses", nil)
+			if tc.Header != "" {
+				req.Header.Set("Accept-Encoding", tc.Header)
+			}
+			assert.Equal(t, tc.Expected, negotiateEncoding(req))
+		})
+	}
+}
+
+// TestCompressionLevel tests the compression levels for the encodings.
+func TestCompressionLevel(t *testing.T) {
+	h := &Creator{CompressionLevel: 15, CompressionLevels: map[string]int{EncodingZstd: -1, EncodingDeflate: 4}}
+
+	assert.Equal(t, gzip.BestCompression, h.compressionLevel(EncodingGzip))
+	assert.Equal(t, 4, h.compressionLevel(EncodingDeflate))
+	assert.Equal(t, 11, h.compressionLevel(EncodingBrotli))
+	assert.Equal(t, 3, h.compressionLevel(EncodingZstd))
+
+	h = &Creator{CompressionLevel: -1}
+	assert.Equal(t, gzip.DefaultCompression, h.compressionLevel(EncodingGzip))
+}
+
+// TestMinCompressSize tests the writer MinCompressSize threshold.
+func TestMinCompressSize(t *testing.T) {
+	h := &Creator{MinCompressSize: 16}
+
+	write := func(t *testing.T, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/houses", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		resp := httptest.NewRecorder()
+
+		w := h.writer(resp, req, http.StatusOK)
+		for _, part := range strings.SplitAfter(body, " ") {
+			_, err := w.Write([]byte(part))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+		return resp
+	}
+
+	t.Run("Below", func(t *testing.T) {
+		resp := write(t, "short body")
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, resp.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", resp.Header().Get("Vary"))
+		assert.Equal(t, "short body", resp.Body.String())
+	})
+
+	t.Run("Above", func(t *testing.T) {
+		body := "the body that is longer than the minimum compress size"
+		resp := write(t, body)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, EncodingGzip, resp.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", resp.Header().Get("Vary"))
+
+		r, err := gzip.NewReader(resp.Body)
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(data))
+	})
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"net/url"
 
 	"github.com/neuronlabs/jsonapi"
 	"github.com/neuronlabs/neuron-core/mapping"
@@ -21,14 +22,29 @@ func (h *Creator) CreateWith(model interface{}) *EndpointHandler {
 // Create returns JSONAPI create method handler function for the provided 'model'.
 func (h *Creator) Create(model interface{}) http.HandlerFunc {
 	mappedModel := h.c.MustGetModelStruct(model)
-	return h.handleCreate(mappedModel, "")
+	return h.handleCreate(mappedModel, &endpointOptions{})
 }
 
-func (h *Creator) handleCreate(model *mapping.ModelStruct, basePath string) http.HandlerFunc {
+func (h *Creator) handleCreate(model *mapping.ModelStruct, o *endpointOptions) http.HandlerFunc {
+	h.registerEndpoint(EndpointCreate, model, nil, o.basePath)
 	return func(rw http.ResponseWriter, req *http.Request) {
+		if h.endpointNotAllowed(rw, req, EndpointCreate, model, nil) {
+			return
+		}
+		if err := h.parseQuery(req.Context(), req, EndpointCreate, nil, o); err != nil {
+			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
+			return
+		}
 		// unmarshal the input from the request body.
-		s, err := jsonapi.UnmarshalSingleScopeC(h.c, req.Body, model, h.jsonapiUnmarshalOptions())
+		body, err := h.requestBody(rw, req, o)
+		if err != nil {
+			log.Debugf("Reading request body for: '%s' failed: %v", model.Collection(), err)
+			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
+			return
+		}
+		s, err := jsonapi.UnmarshalSingleScopeC(h.c, body, model, h.jsonapiUnmarshalOptions())
 		if err != nil {
+			err = body.unmarshalError(err)
 			log.Debugf("Unmarshal scope for: '%s' failed: %v", model.Collection(), err)
 			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
 			return
@@ -50,6 +66,25 @@ func (h *Creator) handleCreate(model *mapping.ModelStruct, basePath string) http
 		// get the context.
 		ctx := req.Context()
 
+		if errs := h.authorize(req, EndpointCreate, model, nil, s); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
+
+		// check if all the unmarshaled fields are writable.
+		if errs := h.unwritableFields(ctx, s); len(errs) > 0 {
+			log.Debug2f("Creating: '%s' with forbidden fields", s.Struct().Collection())
+			h.marshalSourceErrors(rw, req, http.StatusForbidden, errs...)
+			return
+		}
+
+		// validate the unmarshaled attributes.
+		if errs := h.validate(ctx, s, true); len(errs) > 0 {
+			log.Debug2f("Creating: '%s' validation failed", s.Struct().Collection())
+			h.marshalSourceErrors(rw, req, 0, errs...)
+			return
+		}
+
 		if beforeCreateHook, ok := Hooks.getHook(s.Struct(), BeforeCreate); ok {
 			if err = beforeCreateHook(ctx, s); err != nil {
 				h.marshalErrors(rw, req, 0, errors.MapError(err)...)
@@ -79,14 +114,12 @@ func (h *Creator) handleCreate(model *mapping.ModelStruct, basePath string) http
 			return
 		}
 
-		// get the primary field value so that it could be used for the jsonapi marshal process.
-		idDataValue, err := h.getFieldValue(s.Value, s.Struct().Primary())
+		// get the url 'id' value so that it could be used for the jsonapi marshal process.
+		id, err := h.encodeID(model, s.Value)
 		if err != nil {
 			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
 			return
 		}
-		// get the string form value of the 'id'
-		strValues := mapping.StringValues(idDataValue, nil)
 		// by default marshal resource links
 		linkType := jsonapi.ResourceLink
 		// but if the config doesn't allow that - set 'jsonapi.NoLink'
@@ -97,11 +130,12 @@ func (h *Creator) handleCreate(model *mapping.ModelStruct, basePath string) http
 		options := &jsonapi.MarshalOptions{
 			Link: jsonapi.LinkOptions{
 				Type:       linkType,
-				BaseURL:    h.getBasePath(basePath),
+				BaseURL:    h.getBasePath(o.basePath),
 				Collection: s.Struct().Collection(),
-				RootID:     strValues[0],
+				RootID:     url.PathEscape(id),
 			},
 		}
+		setDocumentMeta(s, o)
 		h.marshalScope(s, rw, req, http.StatusCreated, options)
 	}
 }
@@ -17,6 +17,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/neuronlabs/brotli"
 	"github.com/neuronlabs/errors"
 	"github.com/neuronlabs/jsonapi"
@@ -24,6 +25,8 @@ import (
 	"github.com/neuronlabs/neuron-core/config"
 	"github.com/neuronlabs/neuron-core/query"
 	mocks "github.com/neuronlabs/neuron-mocks"
+
+	handlerClass "github.com/neuronlabs/jsonapi-handler/errors/class"
 )
 
 // TestHandleCreate test the handleCreate function.
@@ -97,6 +100,124 @@ func TestHandleCreate(t *testing.T) {
 		})
 	})
 
+	t.Run("BodyLimits", func(t *testing.T) {
+		t.Run("Size", func(t *testing.T) {
+			h := NewC(c)
+			h.MaxBodySize = 16
+
+			req, err := http.NewRequest("POST", "/houses", strings.NewReader(`{"data":{"type":"houses","attributes":{"address":"Some"}}}`))
+			require.NoError(t, err)
+
+			req.Header.Add("Content-Type", jsonapi.MediaType)
+			req.Header.Add("Accept", jsonapi.MediaType)
+			req.Header.Add("Accept-Encoding", "identity")
+
+			resp := httptest.NewRecorder()
+			h.Create(House{}).ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+
+			jsonapiErrors, err := jsonapi.UnmarshalErrors(resp.Body)
+			require.NoError(t, err)
+
+			if assert.Len(t, jsonapiErrors.Errors, 1) {
+				code, err := strconv.ParseInt(jsonapiErrors.Errors[0].Code, 16, 32)
+				require.NoError(t, err)
+
+				assert.Equal(t, handlerClass.InputBodyTooLarge, errors.Class(code))
+			}
+		})
+
+		t.Run("EndpointSize", func(t *testing.T) {
+			h := NewC(c)
+			h.MaxBodySize = 1024
+
+			req, err := http.NewRequest("POST", "/houses", strings.NewReader(`{"data":{"type":"houses","attributes":{"address":"Some"}}}`))
+			require.NoError(t, err)
+
+			req.Header.Add("Content-Type", jsonapi.MediaType)
+			req.Header.Add("Accept", jsonapi.MediaType)
+			req.Header.Add("Accept-Encoding", "identity")
+
+			resp := httptest.NewRecorder()
+			h.CreateWith(House{}).MaxBodySize(10).Handler().ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+		})
+
+		t.Run("TooManyIncluded", func(t *testing.T) {
+			h := NewC(c)
+			h.MaxIncludedResources = 1
+
+			req, err := http.NewRequest("POST", "/houses", strings.NewReader(`{"data":{"type":"houses","attributes":{"address":"Some"}},"included":[{"type":"humen","id":"1"},{"type":"humen","id":"2"}]}`))
+			require.NoError(t, err)
+
+			req.Header.Add("Content-Type", jsonapi.MediaType)
+			req.Header.Add("Accept", jsonapi.MediaType)
+			req.Header.Add("Accept-Encoding", "identity")
+
+			resp := httptest.NewRecorder()
+			h.Create(House{}).ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+			jsonapiErrors, err := jsonapi.UnmarshalErrors(resp.Body)
+			require.NoError(t, err)
+
+			if assert.Len(t, jsonapiErrors.Errors, 1) {
+				code, err := strconv.ParseInt(jsonapiErrors.Errors[0].Code, 16, 32)
+				require.NoError(t, err)
+
+				assert.Equal(t, handlerClass.InputBodyTooManyIncluded, errors.Class(code))
+			}
+		})
+
+		t.Run("UnsupportedEncoding", func(t *testing.T) {
+			h := NewC(c)
+
+			req, err := http.NewRequest("POST", "/houses", strings.NewReader(`{"data":{"type":"houses","attributes":{"address":"Some"}}}`))
+			require.NoError(t, err)
+
+			req.Header.Add("Content-Type", jsonapi.MediaType)
+			req.Header.Add("Content-Encoding", "compress")
+			req.Header.Add("Accept", jsonapi.MediaType)
+			req.Header.Add("Accept-Encoding", "identity")
+
+			resp := httptest.NewRecorder()
+			h.Create(House{}).ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusUnsupportedMediaType, resp.Code)
+			assert.Equal(t, "gzip, deflate, br", resp.Header().Get("Accept-Encoding"))
+		})
+
+		t.Run("Depth", func(t *testing.T) {
+			h := NewC(c)
+			h.MaxDocumentDepth = 2
+
+			req, err := http.NewRequest("POST", "/houses", strings.NewReader(`{"data":{"type":"houses","attributes":{"address":"Some"}}}`))
+			require.NoError(t, err)
+
+			req.Header.Add("Content-Type", jsonapi.MediaType)
+			req.Header.Add("Accept", jsonapi.MediaType)
+			req.Header.Add("Accept-Encoding", "identity")
+
+			resp := httptest.NewRecorder()
+			h.Create(House{}).ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+			jsonapiErrors, err := jsonapi.UnmarshalErrors(resp.Body)
+			require.NoError(t, err)
+
+			if assert.Len(t, jsonapiErrors.Errors, 1) {
+				code, err := strconv.ParseInt(jsonapiErrors.Errors[0].Code, 16, 32)
+				require.NoError(t, err)
+
+				assert.Equal(t, handlerClass.InputBodyTooDeep, errors.Class(code))
+			}
+		})
+	})
+
 	t.Run("Valid", func(t *testing.T) {
 		type encoding struct {
 			Name  string
@@ -104,7 +225,7 @@ func TestHandleCreate(t *testing.T) {
 		}
 
 		h := NewC(c)
-		encodings := []encoding{{"NoEncoding", ""}, {"Gzip", "gzip"}, {"Deflate", "deflate"}, {"Brotli", "br"}}
+		encodings := []encoding{{"NoEncoding", ""}, {"Gzip", "gzip"}, {"Deflate", "deflate"}, {"Brotli", "br"}, {"Zstd", "zstd"}}
 
 		for _, encoding := range encodings {
 			t.Run(encoding.Name, func(t *testing.T) {
@@ -162,6 +283,11 @@ func TestHandleCreate(t *testing.T) {
 					reader = flate.NewReader(resp.Body)
 				case "br":
 					reader = brotli.NewReader(resp.Body)
+				case "zstd":
+					zr, err := zstd.NewReader(resp.Body)
+					require.NoError(t, err)
+					defer zr.Close()
+					reader = zr
 				default:
 					reader = resp.Body
 				}
@@ -170,6 +296,7 @@ func TestHandleCreate(t *testing.T) {
 				require.NoError(t, err)
 
 				assert.Equal(t, `{"data":{"type":"houses","id":"1","attributes":{"address":"Some"}}}`+"\n", string(data))
+				assert.Equal(t, "Accept-Encoding", resp.Header().Get("Vary"))
 			})
 		}
 	})
@@ -1,17 +1,12 @@
 package handler
 
 import (
-	"compress/flate"
-	"compress/gzip"
 	"io"
 	"net/http"
 	"path"
-	"reflect"
+	"sync"
 
-	"github.com/neuronlabs/brotli"
-	"github.com/neuronlabs/errors"
 	"github.com/neuronlabs/jsonapi"
-	"github.com/neuronlabs/neuron-core/class"
 	"github.com/neuronlabs/neuron-core/controller"
 	"github.com/neuronlabs/neuron-core/mapping"
 	"github.com/neuronlabs/neuron-core/query"
@@ -26,10 +21,24 @@ type Creator struct {
 	BasePath string
 	// DefaultPageSize defines default PageSize for the list endpoints.
 	DefaultPageSize int
+	// MaxPageSize if positive, defines the maximum value of the 'page[size]' and 'page[limit]' query parameters
+	// for the list endpoints. The requests with greater values are rejected with the '400' status. The default
+	// page size is limited to the maximum and the requests without the pagination are paginated with the maximum.
+	MaxPageSize int
+	// ClampPageSize if true, the page sizes greater than the MaxPageSize are clamped to the maximum value
+	// instead of rejecting the request.
+	ClampPageSize bool
 	// NoContentOnCreate allows to set the flag for the models with client generated id to return no content.
 	NoContentOnCreate bool
-	// CompressionLevel defines the compression level for the handler function writers
+	// CompressionLevel defines the compression level for the handler function writers.
+	// A negative value sets the default compression level of the negotiated encoding.
 	CompressionLevel int
+	// CompressionLevels defines the compression levels for specific content encodings: 'gzip', 'deflate', 'br'
+	// and 'zstd'. The level set for an encoding overwrites the CompressionLevel.
+	CompressionLevels map[string]int
+	// MinCompressSize is the minimum size in bytes of the response body to be compressed.
+	// Smaller responses are written without the content encoding.
+	MinCompressSize int
 	// StrictQueriesMode if true sets the strict mode for the query builder, that doesn't allow
 	// unknown query keys, and unknown fields.
 	StrictQueriesMode bool
@@ -45,7 +54,62 @@ type Creator struct {
 	FilterValueLimit int
 	// MarshalLinks is the default behavior for marshaling the resource links into the handler responses.
 	MarshalLinks bool
-	c            *controller.Controller
+	// MaxBodySize is the maximum size in bytes of the request body read by the create, patch and patch relationship
+	// endpoints. If the value is not greater than zero the size of the body is not limited.
+	// The value might be overwritten for specific endpoint by the EndpointHandler.
+	MaxBodySize int64
+	// MaxDecompressedBodySize is the maximum size in bytes of the decoded request body sent with the 'Content-Encoding'
+	// header. It prevents from decompressing the bodies of excessive size i.e. zip bombs. If the value is not greater
+	// than zero the decoded body is limited by the MaxBodySize. By default it is set to 10 MB.
+	MaxDecompressedBodySize int64
+	// MaxDocumentDepth is the maximum nesting depth of the incoming JSON documents.
+	// If the value is not greater than zero the depth is not checked.
+	MaxDocumentDepth int
+	// MaxIncludedResources is the maximum number of the 'included' resources in the incoming JSON documents.
+	// If the value is not greater than zero the number of included resources is not checked.
+	MaxIncludedResources int
+	// MaxFilterExpressionDepth is the maximum nesting depth of the parenthesized groups within the list endpoints
+	// filter expression query parameter. If the value is not greater than zero the depth is not checked.
+	// By default it is set to 3.
+	MaxFilterExpressionDepth int
+	// MaxFilterExpressionResources is the maximum number of the resources matched by the single 'or' group of the
+	// filter expression. The requests with the groups matching more resources are rejected with the '400' status.
+	// If the value is not greater than zero the number is not checked. By default it is not set.
+	MaxFilterExpressionResources int
+	// MaxAggregateResources is the maximum number of the resources aggregated by the handler when the model's
+	// repository doesn't implement the Aggregator. Such aggregation lists all the matching resources into memory,
+	// thus the requests matching more resources are rejected with the '400' status. If the value is not greater
+	// than zero the handler doesn't aggregate the resources and such requests are rejected with the '501' status.
+	// By default it is not set.
+	MaxAggregateResources int
+	// ExportBatchSize is the number of the resources listed from the repository at once by the export endpoints.
+	// If the value is not greater than zero the default batch size is used. By default it is set to 1000.
+	ExportBatchSize int
+	// NoPanicOnMissingID if true, the handlers respond with the bad request error when the 'id' is not stored
+	// within the request context. By default the handlers panics in such case.
+	NoPanicOnMissingID bool
+	// IDExtractor is used by the handlers to get the 'id' value from the request.
+	// If not set, the 'id' is taken from the request context stored by the CtxSetID function.
+	IDExtractor IDExtractor
+	// Authorizer if set, authorizes the requests of all the handlers.
+	Authorizer Authorizer
+	// JSONAPIObject if set, is written as the top-level 'jsonapi' object of the response documents.
+	// It allows the clients to detect the implemented specification version.
+	JSONAPIObject *JSONAPIObject
+
+	c                *controller.Controller
+	idCodecs         map[*mapping.ModelStruct]IDCodec
+	validations      map[*mapping.ModelStruct]*ModelValidation
+	fieldPolicies    map[*mapping.ModelStruct]FieldPolicy
+	endpointPolicies map[*mapping.ModelStruct]*endpointPolicy
+	softDeletes      map[*mapping.ModelStruct]*mapping.StructField
+	searchers        map[*mapping.ModelStruct]Searcher
+	encoders         map[string]Encoder
+	metaProviders    []MetaProvider
+	resourceMetas    map[*mapping.ModelStruct]ResourceMetaProvider
+	relationMetas    map[*mapping.ModelStruct]RelationshipMetaProvider
+	endpoints        []Endpoint
+	endpointsLock    sync.Mutex
 }
 
 // NewC creates new jsonapi Handler Creator for given 'c' controller.
@@ -60,11 +124,29 @@ func New() *Creator {
 
 func newCreator(c *controller.Controller) *Creator {
 	return &Creator{
-		QueryErrorsLimit: 10,
-		c:                c,
+		QueryErrorsLimit:         10,
+		MaxDocumentDepth:         32,
+		MaxDecompressedBodySize:  10 << 20,
+		MaxFilterExpressionDepth: 3,
+		ExportBatchSize:          1000,
+		c:                        c,
+		idCodecs:                 map[*mapping.ModelStruct]IDCodec{},
+		validations:              map[*mapping.ModelStruct]*ModelValidation{},
+		fieldPolicies:            map[*mapping.ModelStruct]FieldPolicy{},
+		endpointPolicies:         map[*mapping.ModelStruct]*endpointPolicy{},
+		softDeletes:              map[*mapping.ModelStruct]*mapping.StructField{},
+		searchers:                map[*mapping.ModelStruct]Searcher{},
+		encoders:                 map[string]Encoder{},
+		resourceMetas:            map[*mapping.ModelStruct]ResourceMetaProvider{},
+		relationMetas:            map[*mapping.ModelStruct]RelationshipMetaProvider{},
 	}
 }
 
+// Controller gets the neuron controller used by the handler Creator.
+func (h *Creator) Controller() *controller.Controller {
+	return h.c
+}
+
 func (h *Creator) basePath() string {
 	if h.BasePath == "" {
 		return "/"
@@ -76,62 +158,98 @@ func (h *Creator) baseModelPath(mStruct *mapping.ModelStruct) string {
 	return path.Join("/", h.BasePath, mStruct.Collection())
 }
 
-func (h *Creator) writeContentType(rw http.ResponseWriter) {
-	rw.Header().Add("Content-Type", jsonapi.MediaType)
-}
-
 func (h *Creator) jsonapiUnmarshalOptions() *jsonapi.UnmarshalOptions {
 	return &jsonapi.UnmarshalOptions{StrictUnmarshalMode: h.StrictFieldsMode}
 }
 
-func (h *Creator) getFieldValue(modelValue interface{}, field *mapping.StructField) (value interface{}, err error) {
-	if modelValue == nil {
-		return nil, errors.New(class.ModelValueNil, "provided nil value")
-	}
-	v := reflect.ValueOf(modelValue).Elem()
-	return v.FieldByIndex(field.ReflectField().Index).Interface(), nil
+// MarshalErrors writes the 'errs' errors into the 'rw' response writer with the compression
+// negotiated for the 'req' request. If the 'status' is zero, the most significant status of provided errors is used.
+func (h *Creator) MarshalErrors(rw http.ResponseWriter, req *http.Request, status int, errs ...*jsonapi.Error) {
+	h.marshalErrors(rw, req, status, errs...)
 }
 
 func (h *Creator) marshalErrors(rw http.ResponseWriter, req *http.Request, status int, errs ...*jsonapi.Error) {
-	h.writeContentType(rw)
+	mediaType, encoder := h.negotiateEncoder(req)
+	rw.Header().Add("Content-Type", mediaType)
 
 	if status == 0 {
 		status = handlerErrors.MultiError(errs).Status()
 	}
-	rw.WriteHeader(status)
 
-	w := h.writer(rw, req)
+	w := h.writer(rw, req, status)
 	defer func() {
-		wc, ok := w.(io.WriteCloser)
-		if ok {
-			if err := wc.Close(); err != nil {
-				log.Debugf("Closing Writer failed: %v", err)
-			}
+		if err := w.Close(); err != nil {
+			log.Debugf("Closing Writer failed: %v", err)
+		}
+	}()
+
+	marshal := func(w io.Writer) error {
+		return jsonapi.MarshalErrors(w, errs...)
+	}
+	var err error
+	if encoder != nil || h.JSONAPIObject != nil {
+		err = h.encodeMarshaled(w, encoder, marshal)
+	} else {
+		err = marshal(w)
+	}
+	if err != nil {
+		log.Errorf("Marshaling errors: '%v' failed: %v", errs, err)
+	}
+}
+
+// marshalSourceErrors writes the 'errs' errors with their sources into the 'rw' response writer.
+func (h *Creator) marshalSourceErrors(rw http.ResponseWriter, req *http.Request, status int, errs ...*handlerErrors.SourceError) {
+	mediaType, encoder := h.negotiateEncoder(req)
+	rw.Header().Add("Content-Type", mediaType)
+
+	if status == 0 {
+		status = handlerErrors.SourceErrors(errs).Status()
+	}
 
+	w := h.writer(rw, req, status)
+	defer func() {
+		if err := w.Close(); err != nil {
+			log.Debugf("Closing Writer failed: %v", err)
 		}
 	}()
 
-	err := jsonapi.MarshalErrors(w, errs...)
+	marshal := func(w io.Writer) error {
+		return handlerErrors.MarshalSourceErrors(w, errs...)
+	}
+	var err error
+	if encoder != nil || h.JSONAPIObject != nil {
+		err = h.encodeMarshaled(w, encoder, marshal)
+	} else {
+		err = marshal(w)
+	}
 	if err != nil {
 		log.Errorf("Marshaling errors: '%v' failed: %v", errs, err)
 	}
 }
 
 func (h *Creator) marshalScope(s *query.Scope, rw http.ResponseWriter, req *http.Request, status int, option ...*jsonapi.MarshalOptions) {
-	h.writeContentType(rw)
-	w := h.writer(rw, req)
+	h.stripUnreadableFields(req.Context(), s)
+	mediaType, encoder := h.negotiateEncoder(req)
+	rw.Header().Add("Content-Type", mediaType)
+	w := h.writer(rw, req, status)
 	defer func() {
-		wc, ok := w.(io.WriteCloser)
-		if ok {
-			if err := wc.Close(); err != nil {
-				log.Debugf("Close failed: %v", err)
-			}
+		if err := w.Close(); err != nil {
+			log.Debugf("Close failed: %v", err)
 		}
 	}()
 
-	rw.WriteHeader(status)
-
-	if err := jsonapi.MarshalScope(w, s, option...); err != nil {
+	var err error
+	if encoder != nil {
+		var doc document
+		if doc, err = h.scopeDocument(req, s, option...); err == nil {
+			err = encoder.Encode(w, doc)
+		}
+	} else if h.processesDocuments(s) {
+		err = h.marshalProcessedScope(w, req, s, option...)
+	} else {
+		err = jsonapi.MarshalScope(w, s, option...)
+	}
+	if err != nil {
 		log.Errorf("[SCOPE][%s] jsonapi.MarshalScope failed: %v", s.ID().String(), err)
 		err := jsonapi.MarshalErrors(w, handlerErrors.ErrInternalError())
 		if err != nil {
@@ -146,58 +264,20 @@ func (h *Creator) marshalScope(s *query.Scope, rw http.ResponseWriter, req *http
 	}
 }
 
-func (h *Creator) writer(rw http.ResponseWriter, req *http.Request) io.Writer {
-	accepts := ParseAcceptEncoding(req.Header)
-
-	w := io.Writer(rw)
-	var err error
-
-	for _, accept := range accepts {
-		compressionLevel := h.CompressionLevel
-		switch accept.Value {
-		case "gzip":
-			switch {
-			case compressionLevel > gzip.BestCompression:
-				compressionLevel = gzip.BestCompression
-			case compressionLevel < gzip.BestSpeed:
-				compressionLevel = gzip.BestSpeed
-			case compressionLevel == -1:
-				compressionLevel = gzip.DefaultCompression
-			}
-			w, err = gzip.NewWriterLevel(rw, h.CompressionLevel)
-		case "deflate":
-			switch {
-			case compressionLevel > flate.BestCompression:
-				compressionLevel = flate.BestCompression
-			case compressionLevel < flate.BestSpeed:
-				compressionLevel = flate.BestSpeed
-			case compressionLevel == -1:
-				compressionLevel = flate.DefaultCompression
-			}
-			w, err = flate.NewWriter(rw, h.CompressionLevel)
-		case "br":
-			switch {
-			case h.CompressionLevel > brotli.BestCompression:
-				compressionLevel = brotli.BestCompression
-			case h.CompressionLevel < brotli.BestSpeed:
-				compressionLevel = brotli.BestSpeed
-			case compressionLevel == -1:
-				compressionLevel = brotli.DefaultCompression
-			}
-			w = brotli.NewWriterLevel(rw, compressionLevel)
-		default:
-			continue
-		}
-		if log.Level() == log.LDEBUG3 {
-			log.Debug3f("Writer: '%s' with compression level: %d", accept.Value, compressionLevel)
-		}
-		rw.Header().Set("Content-Encoding", accept.Value)
-		break
+// writer creates the response writer that encodes the response body with the content encoding negotiated
+// for the 'req' request. The response header with provided 'status' is written by the writer on the first
+// write of at least MinCompressSize bytes or when it is closed. If any encoder is registered the response media type
+// depends on the 'Accept' header as well.
+func (h *Creator) writer(rw http.ResponseWriter, req *http.Request, status int) io.WriteCloser {
+	rw.Header().Add("Vary", "Accept-Encoding")
+	if len(h.encoders) > 0 {
+		rw.Header().Add("Vary", "Accept")
 	}
-
-	if err != nil {
-		log.Warningf("Can't create compressed writer: %v", err)
-		w = rw
+	return &compressWriter{
+		h:        h,
+		rw:       rw,
+		status:   status,
+		encoding: negotiateEncoding(req),
+		minSize:  h.MinCompressSize,
 	}
-	return w
 }
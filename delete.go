@@ -21,13 +21,21 @@ func (h *Creator) DeleteWith(model interface{}) *EndpointHandler {
 // Delete is the JSONAPI DELETE http handler for provided 'model'.
 func (h *Creator) Delete(model interface{}) http.HandlerFunc {
 	mappedModel := h.c.MustGetModelStruct(model)
-	return h.handleDelete(mappedModel, "")
+	return h.handleDelete(mappedModel, &endpointOptions{})
 }
 
-func (h *Creator) handleDelete(model *mapping.ModelStruct, basePath string) http.HandlerFunc {
+func (h *Creator) handleDelete(model *mapping.ModelStruct, o *endpointOptions) http.HandlerFunc {
+	h.registerEndpoint(EndpointDelete, model, nil, o.basePath)
 	return func(rw http.ResponseWriter, req *http.Request) {
+		if h.endpointNotAllowed(rw, req, EndpointDelete, model, nil) {
+			return
+		}
+		if err := h.parseQuery(req.Context(), req, EndpointDelete, nil, o); err != nil {
+			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
+			return
+		}
 		ctx := req.Context()
-		id := CtxMustGetID(ctx)
+		id := h.getID(req, model)
 		if id == "" {
 			// if the function would not contain 'id' parameter.
 			log.Debugf("[DELETE] Empty id params: %v", id)
@@ -36,7 +44,7 @@ func (h *Creator) handleDelete(model *mapping.ModelStruct, basePath string) http
 			h.marshalErrors(rw, req, 0, jsonapiError)
 			return
 		}
-		idValue, err := model.Primary().ValueFromString(id)
+		idValues, err := h.decodeID(model, id)
 		if err != nil {
 			log.Debugf("[DELETE][%s] Invalid URL id value: '%s': '%v'", model.Collection(), id, err)
 			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
@@ -44,13 +52,18 @@ func (h *Creator) handleDelete(model *mapping.ModelStruct, basePath string) http
 		}
 
 		s := query.NewModelC(h.c, model, false)
-		if err = s.FilterField(query.NewFilter(model.Primary(), query.OpEqual, idValue)); err != nil {
+		if err = filterID(s, idValues); err != nil {
 			// this should not occur - primary field's model must match scope's model.
 			log.Errorf("[DELETE][%s] Adding param primary filter with value: '%s' failed: %v", model.Collection(), id, err)
 			h.marshalErrors(rw, req, 0, errors.ErrInternalError())
 			return
 		}
 
+		if errs := h.authorize(req, EndpointDelete, model, nil, s); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
+
 		model := s.Struct()
 		// execute the before deleter API hook if given model defines it.
 		if beforeDeleteHook, ok := Hooks.getHook(model, BeforeDelete); ok {
@@ -60,7 +73,12 @@ func (h *Creator) handleDelete(model *mapping.ModelStruct, basePath string) http
 			}
 		}
 
-		if err = s.DeleteContext(ctx); err != nil {
+		if field, ok := h.softDeletes[model]; ok {
+			err = h.softDelete(ctx, s, field)
+		} else {
+			err = s.DeleteContext(ctx)
+		}
+		if err != nil {
 			log.Debugf("[DELETE][SCOPE][%s] Delete /%s/%s root scope failed: %v", s.Struct().Collection(), s.Struct().Collection(), id, err)
 			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
 			return
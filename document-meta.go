@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/query"
+)
+
+// JSONAPIObject is the top-level 'jsonapi' object of the response documents that describes the server implementation.
+type JSONAPIObject struct {
+	// Version is the highest JSON:API specification version supported by the server i.e. '1.0'.
+	Version string `json:"version,omitempty"`
+	// Ext are the URIs of the applied JSON:API extensions.
+	Ext []string `json:"ext,omitempty"`
+	// Profile are the URIs of the applied JSON:API profiles.
+	Profile []string `json:"profile,omitempty"`
+	// Meta is the non-standard meta information of the implementation.
+	Meta map[string]interface{} `json:"meta,omitempty"`
+}
+
+// MetaProvider is the interface used to provide the dynamic top-level meta of the response documents.
+type MetaProvider interface {
+	// Meta gets the meta values of the response for the 'req' request with the marshaled scope 's'.
+	Meta(req *http.Request, s *query.Scope) map[string]interface{}
+}
+
+// MetaProviderFunc is the function that implements MetaProvider interface.
+type MetaProviderFunc func(req *http.Request, s *query.Scope) map[string]interface{}
+
+// Meta implements MetaProvider interface.
+func (f MetaProviderFunc) Meta(req *http.Request, s *query.Scope) map[string]interface{} {
+	return f(req, s)
+}
+
+// ServerTimeMeta is the MetaProvider that sets the 'server_time' meta to the current UTC time in RFC3339 format.
+var ServerTimeMeta = MetaProviderFunc(func(*http.Request, *query.Scope) map[string]interface{} {
+	return map[string]interface{}{"server_time": time.Now().UTC().Format(time.RFC3339)}
+})
+
+// TotalCountMeta is the MetaProvider that sets the 'total' meta to the number of resources matching the list query.
+// For the paginated lists the resources are counted regardless of the pagination.
+var TotalCountMeta = MetaProviderFunc(func(_ *http.Request, s *query.Scope) map[string]interface{} {
+	total, ok := s.StoreGet(scopeTotalK)
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{jsonapi.KeyTotal: total}
+})
+
+// RequestIDMeta creates the MetaProvider that sets the 'request_id' meta to the value of the request 'header'
+// i.e. 'X-Request-ID'. The meta is not set if the header is empty.
+func RequestIDMeta(header string) MetaProvider {
+	return MetaProviderFunc(func(req *http.Request, _ *query.Scope) map[string]interface{} {
+		id := req.Header.Get(header)
+		if id == "" {
+			return nil
+		}
+		return map[string]interface{}{"request_id": id}
+	})
+}
+
+// RegisterMetaProvider registers the 'provider' of the top-level meta for the documents of all the endpoints.
+func (h *Creator) RegisterMetaProvider(provider MetaProvider) {
+	h.metaProviders = append(h.metaProviders, provider)
+}
+
+// Meta sets the static top-level meta 'value' with the 'key' for the documents of given endpoint handler.
+func (e *EndpointHandler) Meta(key string, value interface{}) *EndpointHandler {
+	e.options.setMeta(key, value)
+	return e
+}
+
+// MetaProvider adds the 'provider' of the top-level meta for the documents of given endpoint handler.
+func (e *EndpointHandler) MetaProvider(provider MetaProvider) *EndpointHandler {
+	e.options.metaProviders = append(e.options.metaProviders, provider)
+	return e
+}
+
+// Meta sets the static top-level meta 'value' with the 'key' for the documents of given list endpoint.
+func (l *ListHandlerCreator) Meta(key string, value interface{}) *ListHandlerCreator {
+	l.options.setMeta(key, value)
+	return l
+}
+
+// MetaProvider adds the 'provider' of the top-level meta for the documents of given list endpoint.
+func (l *ListHandlerCreator) MetaProvider(provider MetaProvider) *ListHandlerCreator {
+	l.options.metaProviders = append(l.options.metaProviders, provider)
+	return l
+}
+
+func (o *endpointOptions) setMeta(key string, value interface{}) {
+	meta := make(map[string]interface{}, len(o.meta)+1)
+	for k, v := range o.meta {
+		meta[k] = v
+	}
+	meta[key] = value
+	o.meta = meta
+}
+
+// setDocumentMeta stores the endpoint options 'o' with the document meta in the marshaled scope 's'.
+func setDocumentMeta(s *query.Scope, o *endpointOptions) {
+	if len(o.meta) > 0 || len(o.metaProviders) > 0 {
+		s.StoreSet(scopeEndpointMetaK, o)
+	}
+}
+
+// hasDocumentMeta checks if the documents of the scope 's' contains any meta or the jsonapi object set by the handler.
+func (h *Creator) hasDocumentMeta(s *query.Scope) bool {
+	if h.JSONAPIObject != nil || len(h.metaProviders) > 0 {
+		return true
+	}
+	_, ok := s.StoreGet(scopeEndpointMetaK)
+	return ok
+}
+
+// addDocumentMeta adds the top-level meta of the scope 's' document 'doc' and the jsonapi object.
+// The meta marshaled by the jsonapi package is overwritten by the static meta and then by the providers.
+func (h *Creator) addDocumentMeta(req *http.Request, s *query.Scope, doc document) {
+	h.addJSONAPIObject(doc)
+
+	meta, _ := doc["meta"].(map[string]interface{})
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	providers := h.metaProviders
+	if v, ok := s.StoreGet(scopeEndpointMetaK); ok {
+		o := v.(*endpointOptions)
+		for key, value := range o.meta {
+			meta[key] = value
+		}
+		providers = append(providers[:len(providers):len(providers)], o.metaProviders...)
+	}
+	for _, provider := range providers {
+		for key, value := range provider.Meta(req, s) {
+			meta[key] = value
+		}
+	}
+	if len(meta) > 0 {
+		doc["meta"] = meta
+	}
+}
+
+// addJSONAPIObject sets the Creator's JSONAPIObject in the document 'doc'.
+func (h *Creator) addJSONAPIObject(doc document) {
+	if h.JSONAPIObject != nil {
+		doc["jsonapi"] = h.JSONAPIObject
+	}
+}
+
+var scopeEndpointMetaK scopeEndpointMeta
+
+type scopeEndpointMeta struct{}
+
+var scopeTotalK scopeTotal
+
+type scopeTotal struct{}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+)
+
+// TestDocumentMeta tests the top-level meta and the jsonapi object of the response documents.
+func TestDocumentMeta(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, target string) *http.Request {
+		req, err := http.NewRequest("GET", target, nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		req.Header.Add("X-Request-ID", "abc")
+		return req.WithContext(context.WithValue(context.Background(), IDKey, "1"))
+	}
+
+	repo, err := c.GetRepository(House{})
+	require.NoError(t, err)
+
+	housesRepo, ok := repo.(*mocks.Repository)
+	require.True(t, ok)
+
+	type payload struct {
+		JSONAPI *JSONAPIObject         `json:"jsonapi"`
+		Meta    map[string]interface{} `json:"meta"`
+		Errors  []interface{}          `json:"errors"`
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		h := NewC(c)
+		h.JSONAPIObject = &JSONAPIObject{Version: "1.0"}
+		h.RegisterMetaProvider(RequestIDMeta("X-Request-ID"))
+		housesRepo.On("Get", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*House)
+			require.True(t, ok)
+			v.ID = 1
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.GetWith(House{}).Meta("copyright", "neuronlabs").Handler().ServeHTTP(resp, newRequest(t, "/houses/1?fields[houses]=address"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		p := payload{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+		if assert.NotNil(t, p.JSONAPI) {
+			assert.Equal(t, "1.0", p.JSONAPI.Version)
+		}
+		assert.Equal(t, map[string]interface{}{"copyright": "neuronlabs", "request_id": "abc"}, p.Meta)
+	})
+
+	t.Run("ListTotal", func(t *testing.T) {
+		h := NewC(c)
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*[]*House)
+			require.True(t, ok)
+			*v = append(*v, &House{ID: 1}, &House{ID: 2})
+		}).Return(nil)
+		housesRepo.On("Count", mock.Anything, mock.Anything).Once().Return(int64(12), nil)
+
+		resp := httptest.NewRecorder()
+		h.ListWith(House{}).MetaProvider(TotalCountMeta).Handler().ServeHTTP(resp, newRequest(t, "/houses?fields[houses]=address&page[size]=2"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		p := payload{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+		assert.Nil(t, p.JSONAPI)
+		assert.Equal(t, map[string]interface{}{"total": float64(12)}, p.Meta)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		h := NewC(c)
+		h.JSONAPIObject = &JSONAPIObject{Version: "1.0"}
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "/houses?page[size]=invalid"))
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+
+		p := payload{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+		if assert.NotNil(t, p.JSONAPI) {
+			assert.Equal(t, "1.0", p.JSONAPI.Version)
+		}
+		assert.NotEmpty(t, p.Errors)
+	})
+	housesRepo.AssertExpectations(t)
+}
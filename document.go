@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/query"
+)
+
+// document is the generic form of the JSON:API document. It is used to process the documents
+// marshaled by the jsonapi package before they're written into the response.
+type document map[string]interface{}
+
+// resourceObject is the generic form of the JSON:API resource object.
+type resourceObject map[string]interface{}
+
+// collection gets the resource object 'type'.
+func (r resourceObject) collection() string {
+	collection, _ := r["type"].(string)
+	return collection
+}
+
+// relationships gets the resource object relationships mapped by their names.
+func (r resourceObject) relationships() map[string]map[string]interface{} {
+	raw, ok := r["relationships"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	relationships := make(map[string]map[string]interface{}, len(raw))
+	for name, value := range raw {
+		if relationship, ok := value.(map[string]interface{}); ok {
+			relationships[name] = relationship
+		}
+	}
+	return relationships
+}
+
+// resources gets the primary data and included resource objects of the document.
+func (d document) resources() (resources []resourceObject) {
+	switch data := d["data"].(type) {
+	case map[string]interface{}:
+		resources = append(resources, data)
+	case []interface{}:
+		resources = appendResourceObjects(resources, data)
+	}
+	if included, ok := d["included"].([]interface{}); ok {
+		resources = appendResourceObjects(resources, included)
+	}
+	return resources
+}
+
+func appendResourceObjects(resources []resourceObject, values []interface{}) []resourceObject {
+	for _, value := range values {
+		if resource, ok := value.(map[string]interface{}); ok {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
+
+// processesDocuments checks if the marshaled documents of the scope 's' needs to be processed before writing.
+func (h *Creator) processesDocuments(s *query.Scope) bool {
+	return h.hasEndpointPolicies(s) || h.hasDocumentMeta(s) || h.hasResourceMeta(s) || hasRelationshipCounts(s)
+}
+
+// marshalProcessedScope marshals the scope 's' into the generic document, processes it and writes into 'w'.
+func (h *Creator) marshalProcessedScope(w io.Writer, req *http.Request, s *query.Scope, option ...*jsonapi.MarshalOptions) error {
+	doc, err := h.scopeDocument(req, s, option...)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(doc)
+}
+
+// scopeDocument marshals the scope 's' into the processed generic document.
+func (h *Creator) scopeDocument(req *http.Request, s *query.Scope, option ...*jsonapi.MarshalOptions) (document, error) {
+	buf := &bytes.Buffer{}
+	if err := jsonapi.MarshalScope(buf, s, option...); err != nil {
+		return nil, err
+	}
+	doc, err := decodeDocument(buf)
+	if err != nil {
+		return nil, err
+	}
+	h.processDocument(req, s, doc)
+	return doc, nil
+}
+
+// decodeDocument decodes the generic document from 'r'. The numbers are decoded as json.Number.
+func decodeDocument(r io.Reader) (document, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	doc := document{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// unmarshalGeneric unmarshals the JSON 'data' into the generic 'value' with the numbers as json.Number.
+func unmarshalGeneric(data []byte, value interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(value)
+}
+
+// processDocument processes the marshaled document 'doc' of the scope 's'. The resource objects are processed
+// by all the required processors within a single pass over the document.
+func (h *Creator) processDocument(req *http.Request, s *query.Scope, doc document) {
+	if h.hasDocumentMeta(s) {
+		h.addDocumentMeta(req, s, doc)
+	}
+	var processors []func(resource resourceObject)
+	if h.hasEndpointPolicies(s) {
+		processors = append(processors, h.hideRelationshipLinks)
+	}
+	if h.hasResourceMeta(s) {
+		processors = append(processors, h.resourceMetaProcessor(req.Context(), s))
+	}
+	if hasRelationshipCounts(s) {
+		processors = append(processors, relationshipCountsProcessor(s))
+	}
+	if len(processors) == 0 {
+		return
+	}
+	for _, resource := range doc.resources() {
+		for _, process := range processors {
+			process(resource)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/neuronlabs/jsonapi"
+
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// Response media types of the built-in encoders.
+const (
+	// MediaTypeJSON is the media type of the FlatJSONEncoder responses.
+	MediaTypeJSON = "application/json"
+	// MediaTypeMessagePack is the media type of the MessagePackEncoder responses.
+	MediaTypeMessagePack = "application/msgpack"
+)
+
+// Encoder is the interface used to write the handler responses in the media types other than the JSON:API.
+type Encoder interface {
+	// Encode writes the generic form of the JSON:API document 'doc' into 'w'. The document
+	// contains either the primary 'data' or the 'errors' member.
+	Encode(w io.Writer, doc map[string]interface{}) error
+}
+
+// EncoderFunc is the function that implements Encoder interface.
+type EncoderFunc func(w io.Writer, doc map[string]interface{}) error
+
+// Encode implements Encoder interface.
+func (f EncoderFunc) Encode(w io.Writer, doc map[string]interface{}) error {
+	return f(w, doc)
+}
+
+// RegisterEncoder registers the 'encoder' of the responses with the 'mediaType'. The encoder is used when the
+// 'mediaType' is preferred by the request 'Accept' header over the JSON:API media type, which remains
+// the default. Panics if the 'mediaType' is the JSON:API media type.
+func (h *Creator) RegisterEncoder(mediaType string, encoder Encoder) {
+	if mediaType == jsonapi.MediaType {
+		log.Panicf("Can't register encoder for the default media type: '%s'", mediaType)
+	}
+	h.encoders[mediaType] = encoder
+}
+
+// negotiateEncoder gets the response media type and its encoder for the 'req' Accept header.
+// The encoder is nil for the JSON:API media type.
+func (h *Creator) negotiateEncoder(req *http.Request) (string, Encoder) {
+	if len(h.encoders) == 0 {
+		return jsonapi.MediaType, nil
+	}
+	for _, accepted := range parseQVHeader(req.Header, "Accept") {
+		if accepted.Quality == 0 {
+			continue
+		}
+		if accepted.Value == jsonapi.MediaType || accepted.Value == "*/*" {
+			break
+		}
+		if encoder, ok := h.encoders[accepted.Value]; ok {
+			return accepted.Value, encoder
+		}
+	}
+	return jsonapi.MediaType, nil
+}
+
+// acceptsContent checks if the 'req' Accept header requires the response content. It is used by the endpoints
+// that might respond with no content.
+func (h *Creator) acceptsContent(req *http.Request) bool {
+	if req.Header.Get("Accept") == jsonapi.MediaType {
+		return true
+	}
+	_, encoder := h.negotiateEncoder(req)
+	return encoder != nil
+}
+
+// writeDocument writes the generic document 'doc' with the encoder negotiated for the 'req' request.
+func (h *Creator) writeDocument(rw http.ResponseWriter, req *http.Request, status int, doc document) {
+	mediaType, encoder := h.negotiateEncoder(req)
+	rw.Header().Add("Content-Type", mediaType)
+	w := h.writer(rw, req, status)
+	defer func() {
+		if err := w.Close(); err != nil {
+			log.Debugf("Close failed: %v", err)
+		}
+	}()
+
+	h.addJSONAPIObject(doc)
+	var err error
+	if encoder != nil {
+		err = encoder.Encode(w, doc)
+	} else {
+		err = json.NewEncoder(w).Encode(doc)
+	}
+	if err != nil {
+		log.Errorf("Writing document failed: %v", err)
+	}
+}
+
+// encodeMarshaled writes the document written by the 'marshal' function into 'w' with the 'encoder'.
+// The document is written with the jsonapi object. If the 'encoder' is nil the document is written as JSON.
+func (h *Creator) encodeMarshaled(w io.Writer, encoder Encoder, marshal func(w io.Writer) error) error {
+	buf := &bytes.Buffer{}
+	if err := marshal(buf); err != nil {
+		return err
+	}
+	doc, err := decodeDocument(buf)
+	if err != nil {
+		return err
+	}
+	h.addJSONAPIObject(doc)
+	if encoder == nil {
+		return json.NewEncoder(w).Encode(doc)
+	}
+	return encoder.Encode(w, doc)
+}
+
+// FlatJSONEncoder is the Encoder that writes the resources without the JSON:API envelope. Each resource
+// is written as a JSON object with the 'id', the attributes and the relationships ids. The collections are
+// written as JSON arrays. The documents with errors are written unchanged.
+type FlatJSONEncoder struct{}
+
+// Encode implements Encoder interface.
+func (FlatJSONEncoder) Encode(w io.Writer, doc map[string]interface{}) error {
+	if _, ok := doc["errors"]; ok {
+		return json.NewEncoder(w).Encode(doc)
+	}
+	var flat interface{}
+	switch data := doc["data"].(type) {
+	case map[string]interface{}:
+		flat = flattenResource(data)
+	case []interface{}:
+		resources := make([]interface{}, len(data))
+		for i, resource := range data {
+			if r, ok := resource.(map[string]interface{}); ok {
+				resources[i] = flattenResource(r)
+			}
+		}
+		flat = resources
+	}
+	return json.NewEncoder(w).Encode(flat)
+}
+
+func flattenResource(resource resourceObject) map[string]interface{} {
+	flat := map[string]interface{}{"id": resource["id"]}
+	if attributes, ok := resource["attributes"].(map[string]interface{}); ok {
+		for name, value := range attributes {
+			flat[name] = value
+		}
+	}
+	for name, relationship := range resource.relationships() {
+		data, ok := relationship["data"]
+		if !ok {
+			continue
+		}
+		switch linkage := data.(type) {
+		case map[string]interface{}:
+			flat[name] = linkage["id"]
+		case []interface{}:
+			ids := make([]interface{}, 0, len(linkage))
+			for _, identifier := range linkage {
+				if i, ok := identifier.(map[string]interface{}); ok {
+					ids = append(ids, i["id"])
+				}
+			}
+			flat[name] = ids
+		default:
+			flat[name] = nil
+		}
+	}
+	return flat
+}
+
+// MessagePackEncoder is the Encoder that writes the JSON:API documents in the MessagePack format.
+type MessagePackEncoder struct{}
+
+// Encode implements Encoder interface.
+func (MessagePackEncoder) Encode(w io.Writer, doc map[string]interface{}) error {
+	e := &msgpackEncoder{}
+	if err := e.encode(doc); err != nil {
+		return err
+	}
+	_, err := w.Write(e.buf)
+	return err
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+)
+
+// TestEncoders tests the response encoders negotiated by the 'Accept' header.
+func TestEncoders(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, target, accept string) *http.Request {
+		req, err := http.NewRequest("GET", target, nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", accept)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req.WithContext(context.WithValue(context.Background(), IDKey, "1"))
+	}
+
+	repo, err := c.GetRepository(House{})
+	require.NoError(t, err)
+
+	housesRepo, ok := repo.(*mocks.Repository)
+	require.True(t, ok)
+
+	getHouse := func() {
+		housesRepo.On("Get", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*House)
+			require.True(t, ok)
+			v.ID = 1
+			v.Address = "Main Rd 52"
+			v.Owner = &Human{ID: 3}
+		}).Return(nil)
+	}
+
+	t.Run("FlatJSON", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterEncoder(MediaTypeJSON, FlatJSONEncoder{})
+		getHouse()
+
+		resp := httptest.NewRecorder()
+		h.Get(House{}).ServeHTTP(resp, newRequest(t, "/houses/1?fields[houses]=address,owner", "application/json"))
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, MediaTypeJSON, resp.Header().Get("Content-Type"))
+
+		house := map[string]interface{}{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&house))
+		assert.Equal(t, map[string]interface{}{"id": "1", "address": "Main Rd 52", "owner": "3"}, house)
+	})
+
+	t.Run("FlatJSONCollection", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterEncoder(MediaTypeJSON, FlatJSONEncoder{})
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*[]*House)
+			require.True(t, ok)
+			*v = append(*v, &House{ID: 1, Address: "Main Rd 52"}, &House{ID: 2, Address: "Side Rd 1"})
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "/houses?fields[houses]=address", "application/json"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var houses []map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&houses))
+		assert.Equal(t, []map[string]interface{}{
+			{"id": "1", "address": "Main Rd 52"},
+			{"id": "2", "address": "Side Rd 1"},
+		}, houses)
+	})
+
+	t.Run("MessagePackErrors", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterEncoder(MediaTypeMessagePack, MessagePackEncoder{})
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "/houses?page[size]=invalid", MediaTypeMessagePack))
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Equal(t, MediaTypeMessagePack, resp.Header().Get("Content-Type"))
+		assert.Equal(t, []string{"Accept-Encoding", "Accept"}, resp.Header()["Vary"])
+		// fixmap with a single 'errors' key.
+		assert.True(t, bytes.HasPrefix(resp.Body.Bytes(), []byte{0x81, 0xa6, 'e', 'r', 'r', 'o', 'r', 's'}))
+	})
+
+	t.Run("Vary", func(t *testing.T) {
+		// the response varies by the 'Accept' header only if any encoder is registered.
+		for _, withEncoder := range []bool{true, false} {
+			h := NewC(c)
+			vary := []string{"Accept-Encoding"}
+			if withEncoder {
+				h.RegisterEncoder(MediaTypeJSON, FlatJSONEncoder{})
+				vary = append(vary, "Accept")
+			}
+			getHouse()
+
+			resp := httptest.NewRecorder()
+			h.Get(House{}).ServeHTTP(resp, newRequest(t, "/houses/1?fields[houses]=address", jsonapi.MediaType))
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, vary, resp.Header()["Vary"])
+		}
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterEncoder(MediaTypeJSON, FlatJSONEncoder{})
+
+		for _, accept := range []string{"", "*/*", "application/json;q=0.5, " + jsonapi.MediaType, "text/html"} {
+			mediaType, encoder := h.negotiateEncoder(newRequest(t, "/houses", accept))
+			assert.Equal(t, jsonapi.MediaType, mediaType, accept)
+			assert.Nil(t, encoder, accept)
+		}
+		mediaType, encoder := h.negotiateEncoder(newRequest(t, "/houses", jsonapi.MediaType+";q=0.5, application/json"))
+		assert.Equal(t, MediaTypeJSON, mediaType)
+		assert.NotNil(t, encoder)
+
+		assert.Panics(t, func() {
+			h.RegisterEncoder(jsonapi.MediaType, FlatJSONEncoder{})
+		})
+	})
+}
+
+// TestMessagePack tests the MessagePack encoding of the generic values.
+func TestMessagePack(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := MessagePackEncoder{}.Encode(buf, map[string]interface{}{
+		"a": json.Number("1"),
+		"b": []interface{}{true, nil, json.Number("-33")},
+		"c": json.Number("1.5"),
+		"d": 300,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{
+		0x84,
+		0xa1, 'a', 0x01,
+		0xa1, 'b', 0x93, 0xc3, 0xc0, 0xd0, 0xdf,
+		0xa1, 'c', 0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0,
+		0xa1, 'd', 0xcd, 0x01, 0x2c,
+	}, buf.Bytes())
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+
+	"github.com/neuronlabs/jsonapi-handler/errors"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// EndpointPolicy is the policy of the endpoints exposed for the model. The handlers of the endpoints
+// that are not allowed by the policy respond with the '405' ErrMethodNotAllowed error and the 'Allow' header.
+type EndpointPolicy struct {
+	// Endpoints are the allowed endpoint types of the model. If empty, all the endpoint types are allowed.
+	Endpoints []EndpointType
+	// Relationships are the neuron names of the relationships allowed for the GetRelated, GetRelationship
+	// and PatchRelationship endpoints. If nil, all the relationships are allowed. The links of the disallowed
+	// relationship endpoints are not marshaled.
+	Relationships []string
+}
+
+// ReadOnlyPolicy is the EndpointPolicy that allows only reading endpoints of the model.
+var ReadOnlyPolicy = EndpointPolicy{
+	Endpoints: []EndpointType{EndpointGet, EndpointList, EndpointGetRelated, EndpointGetRelationship, EndpointAggregate, EndpointExport},
+}
+
+type endpointPolicy struct {
+	endpoints     map[EndpointType]struct{}
+	relationships map[*mapping.StructField]struct{}
+}
+
+// RegisterEndpointPolicy registers the endpoint 'policy' of the 'model'. Panics if the policy
+// contains a relationship that is not found within the model.
+func (h *Creator) RegisterEndpointPolicy(model interface{}, policy EndpointPolicy) {
+	mappedModel := h.c.MustGetModelStruct(model)
+	p := &endpointPolicy{}
+	if len(policy.Endpoints) > 0 {
+		p.endpoints = map[EndpointType]struct{}{}
+		for _, endpoint := range policy.Endpoints {
+			p.endpoints[endpoint] = struct{}{}
+		}
+	}
+	if policy.Relationships != nil {
+		p.relationships = map[*mapping.StructField]struct{}{}
+		for _, name := range policy.Relationships {
+			field, ok := mappedModel.RelationField(name)
+			if !ok {
+				log.Panicf("Endpoint policy relationship: '%s' not found within model: '%s'", name, mappedModel.Collection())
+			}
+			p.relationships[field] = struct{}{}
+		}
+	}
+	h.endpointPolicies[mappedModel] = p
+}
+
+// isEndpointAllowed checks if the 'endpoint' of the 'model' and the relationship 'field' is allowed.
+func (h *Creator) isEndpointAllowed(endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField) bool {
+	p, ok := h.endpointPolicies[model]
+	if !ok {
+		return true
+	}
+	if p.endpoints != nil {
+		if _, ok = p.endpoints[endpoint]; !ok {
+			return false
+		}
+	}
+	if endpoint.IsRelationship() && p.relationships != nil {
+		if _, ok = p.relationships[field]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// endpointNotAllowed checks if the 'endpoint' is allowed. If not, it writes the '405' error with the 'Allow' header
+// into the 'rw' and returns true.
+func (h *Creator) endpointNotAllowed(rw http.ResponseWriter, req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField) bool {
+	if h.isEndpointAllowed(endpoint, model, field) {
+		return false
+	}
+	var methods []string
+	for _, pathEndpoint := range pathEndpoints(endpoint) {
+		if h.isEndpointAllowed(pathEndpoint, model, field) {
+			methods = append(methods, pathEndpoint.Method())
+		}
+	}
+	log.Debug2f("[%s][%s] endpoint not allowed", endpoint, model.Collection())
+	rw.Header().Set("Allow", strings.Join(methods, ", "))
+	err := errors.ErrMethodNotAllowed()
+	err.Detail = "The endpoint is not available for the resource."
+	h.marshalErrors(rw, req, http.StatusMethodNotAllowed, err)
+	return true
+}
+
+// pathEndpoints gets the endpoint types that shares the url path with the 'endpoint'.
+func pathEndpoints(endpoint EndpointType) []EndpointType {
+	switch endpoint {
+	case EndpointCreate, EndpointList:
+		return []EndpointType{EndpointList, EndpointCreate}
+	case EndpointGet, EndpointPatch, EndpointDelete:
+		return []EndpointType{EndpointGet, EndpointPatch, EndpointDelete}
+	case EndpointGetRelationship, EndpointPatchRelationship:
+		return []EndpointType{EndpointGetRelationship, EndpointPatchRelationship}
+	}
+	return []EndpointType{endpoint}
+}
+
+// hasEndpointPolicies checks if any of the models marshaled with the scope 's' has the endpoint policy.
+func (h *Creator) hasEndpointPolicies(s *query.Scope) bool {
+	if len(h.endpointPolicies) == 0 {
+		return false
+	}
+	if _, ok := h.endpointPolicies[s.Struct()]; ok {
+		return true
+	}
+	for _, included := range s.IncludedScopes() {
+		if _, ok := h.endpointPolicies[included.Struct()]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hideRelationshipLinks removes the links of the disallowed relationship endpoints from the 'resource' object.
+func (h *Creator) hideRelationshipLinks(resource resourceObject) {
+	model, err := h.c.ModelStruct(resource.collection())
+	if err != nil {
+		return
+	}
+	if _, ok := h.endpointPolicies[model]; !ok {
+		return
+	}
+	for name, relationship := range resource.relationships() {
+		field, ok := model.RelationField(name)
+		if !ok {
+			continue
+		}
+		links, ok := relationship["links"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if !h.isEndpointAllowed(EndpointGetRelationship, model, field) {
+			delete(links, "self")
+		}
+		if !h.isEndpointAllowed(EndpointGetRelated, model, field) {
+			delete(links, "related")
+		}
+		if len(links) == 0 {
+			delete(relationship, "links")
+		}
+	}
+}
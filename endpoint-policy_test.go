@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+)
+
+// TestEndpointPolicy tests the endpoint policies of the models.
+func TestEndpointPolicy(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{}, Parcel{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, method, target string) *http.Request {
+		req, err := http.NewRequest(method, target, nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req.WithContext(context.WithValue(context.Background(), IDKey, "1"))
+	}
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterEndpointPolicy(House{}, ReadOnlyPolicy)
+		h.RegisterEndpointPolicy(Human{}, EndpointPolicy{Relationships: []string{}})
+
+		resp := httptest.NewRecorder()
+		h.Delete(House{}).ServeHTTP(resp, newRequest(t, "DELETE", "/houses/1"))
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+		assert.Equal(t, "GET", resp.Header().Get("Allow"))
+
+		resp = httptest.NewRecorder()
+		h.PatchRelationship(House{}, "owner").ServeHTTP(resp, newRequest(t, "PATCH", "/houses/1/relationships/owner"))
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+		assert.Equal(t, "GET", resp.Header().Get("Allow"))
+
+		resp = httptest.NewRecorder()
+		h.GetRelationship(Human{}, "houses").ServeHTTP(resp, newRequest(t, "GET", "/humen/1/relationships/houses"))
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+		assert.Equal(t, "", resp.Header().Get("Allow"))
+
+		endpoints := h.Endpoints()
+		if assert.Len(t, endpoints, 0) {
+			h.Get(House{})
+			assert.Len(t, h.Endpoints(), 1)
+		}
+	})
+
+	t.Run("RelationshipHandlers", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterEndpointPolicy(Parcel{}, EndpointPolicy{
+			Endpoints:     []EndpointType{EndpointGet, EndpointGetRelated, EndpointGetRelationship},
+			Relationships: []string{"sender"},
+		})
+
+		// the handlers of the disallowed relationships are not returned.
+		for _, handlers := range []map[*mapping.StructField]http.HandlerFunc{
+			h.GetRelatedHandlers(Parcel{}),
+			h.GetRelationShipHandlers(Parcel{}),
+		} {
+			if assert.Len(t, handlers, 1) {
+				for field := range handlers {
+					assert.Equal(t, "sender", field.NeuronName())
+				}
+			}
+		}
+		assert.Len(t, h.PatchRelationshipHandlers(Parcel{}), 0)
+		assert.Len(t, h.Endpoints(), 2)
+	})
+
+	t.Run("RelationshipLinks", func(t *testing.T) {
+		h := NewC(c)
+		h.MarshalLinks = true
+		h.RegisterEndpointPolicy(House{}, EndpointPolicy{Relationships: []string{}})
+
+		repo, err := c.GetRepository(House{})
+		require.NoError(t, err)
+
+		housesRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		housesRepo.On("Get", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			v, ok := s.Value.(*House)
+			require.True(t, ok)
+			v.ID = 1
+			v.Address = "Main Rd 52"
+			v.Owner = &Human{ID: 3}
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.Get(House{}).ServeHTTP(resp, newRequest(t, "GET", "/houses/1?fields[houses]=address,owner"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		payload := struct {
+			Data struct {
+				Links         map[string]interface{}            `json:"links"`
+				Relationships map[string]map[string]interface{} `json:"relationships"`
+			} `json:"data"`
+		}{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		assert.Contains(t, payload.Data.Links, "self")
+		if assert.Contains(t, payload.Data.Relationships, "owner") {
+			assert.NotContains(t, payload.Data.Relationships["owner"], "links")
+			assert.Contains(t, payload.Data.Relationships["owner"], "data")
+		}
+	})
+
+	t.Run("ProcessesDocuments", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterEndpointPolicy(Human{}, ReadOnlyPolicy)
+
+		// only the documents with the models having the endpoint policy are processed.
+		s, err := query.NewC(c, &House{})
+		require.NoError(t, err)
+		assert.False(t, h.processesDocuments(s))
+
+		require.NoError(t, s.IncludeFields("owner"))
+		assert.True(t, h.processesDocuments(s))
+
+		s, err = query.NewC(c, &Human{})
+		require.NoError(t, err)
+		assert.True(t, h.processesDocuments(s))
+	})
+}
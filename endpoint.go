@@ -2,24 +2,187 @@ package handler
 
 import (
 	"net/http"
+	"path"
 
 	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
 )
 
 // EndpointHandler is the structure that allows to customize predefined handler func.
 type EndpointHandler struct {
-	handler  func(*mapping.ModelStruct, string) http.HandlerFunc
-	basePath string
-	model    *mapping.ModelStruct
+	handler func(*mapping.ModelStruct, *endpointOptions) http.HandlerFunc
+	model   *mapping.ModelStruct
+	options endpointOptions
 }
 
 // BasePath sets the BasePath for given endpoint handler.
 func (e *EndpointHandler) BasePath(basePath string) *EndpointHandler {
-	e.basePath = basePath
+	e.options.basePath = basePath
+	return e
+}
+
+// MaxBodySize sets the maximum size in bytes of the request body for given endpoint handler.
+// It overwrites the Creator's MaxBodySize. A negative value disables the limit for given endpoint.
+func (e *EndpointHandler) MaxBodySize(size int64) *EndpointHandler {
+	e.options.maxBodySize = size
 	return e
 }
 
 // Handler returns preset handler function.
 func (e *EndpointHandler) Handler() http.HandlerFunc {
-	return e.handler(e.model, e.basePath)
+	options := e.options
+	return e.handler(e.model, &options)
+}
+
+// endpointOptions are the endpoint specific options used by the handler functions.
+type endpointOptions struct {
+	basePath           string
+	maxBodySize        int64
+	allowDeletedFilter bool
+	filterableFields   map[string][]*query.Operator
+	sortableFields     map[string]struct{}
+	requiredFilters    []string
+	defaultFilters     []*query.FilterField
+
+	limitOffsetPagination bool
+	maxPageSize           int
+	clampPageSize         bool
+	allowNoPagination     bool
+	searcher              Searcher
+
+	meta          map[string]interface{}
+	metaProviders []MetaProvider
+	counts        []*mapping.StructField
+}
+
+// EndpointType is the type of the JSONAPI endpoint.
+type EndpointType int
+
+// Enumerated endpoint types.
+const (
+	EndpointUnknown EndpointType = iota
+	EndpointCreate
+	EndpointGet
+	EndpointList
+	EndpointPatch
+	EndpointDelete
+	EndpointGetRelated
+	EndpointGetRelationship
+	EndpointPatchRelationship
+	EndpointRestore
+	EndpointAggregate
+	EndpointExport
+)
+
+// Method gets the http method of the endpoint type.
+func (e EndpointType) Method() string {
+	switch e {
+	case EndpointCreate, EndpointRestore:
+		return http.MethodPost
+	case EndpointGet, EndpointList, EndpointGetRelated, EndpointGetRelationship, EndpointAggregate, EndpointExport:
+		return http.MethodGet
+	case EndpointPatch, EndpointPatchRelationship:
+		return http.MethodPatch
+	case EndpointDelete:
+		return http.MethodDelete
+	}
+	return ""
+}
+
+// IsRelationship checks if the endpoint type is related with the model's relationship field.
+func (e EndpointType) IsRelationship() bool {
+	switch e {
+	case EndpointGetRelated, EndpointGetRelationship, EndpointPatchRelationship:
+		return true
+	}
+	return false
+}
+
+// String implements fmt.Stringer interface.
+func (e EndpointType) String() string {
+	switch e {
+	case EndpointCreate:
+		return "Create"
+	case EndpointGet:
+		return "Get"
+	case EndpointList:
+		return "List"
+	case EndpointPatch:
+		return "Patch"
+	case EndpointDelete:
+		return "Delete"
+	case EndpointGetRelated:
+		return "GetRelated"
+	case EndpointGetRelationship:
+		return "GetRelationship"
+	case EndpointPatchRelationship:
+		return "PatchRelationship"
+	case EndpointRestore:
+		return "Restore"
+	case EndpointAggregate:
+		return "Aggregate"
+	case EndpointExport:
+		return "Export"
+	}
+	return "Unknown"
+}
+
+// Endpoint is the description of the endpoint handler created by the Creator.
+type Endpoint struct {
+	Type  EndpointType
+	Model *mapping.ModelStruct
+	// Field is the relationship field of the GetRelated, GetRelationship and PatchRelationship endpoints.
+	Field *mapping.StructField
+	// BasePath is the base path of the endpoint.
+	BasePath string
+}
+
+// Path gets the url path template of the endpoint, where the resource 'id' is marked as '{id}'.
+func (e Endpoint) Path() string {
+	switch e.Type {
+	case EndpointCreate, EndpointList:
+		return path.Join("/", e.BasePath, e.Model.Collection())
+	case EndpointGet, EndpointPatch, EndpointDelete:
+		return path.Join("/", e.BasePath, e.Model.Collection(), "{id}")
+	case EndpointGetRelated:
+		return path.Join("/", e.BasePath, e.Model.Collection(), "{id}", e.Field.NeuronName())
+	case EndpointGetRelationship, EndpointPatchRelationship:
+		return path.Join("/", e.BasePath, e.Model.Collection(), "{id}", "relationships", e.Field.NeuronName())
+	case EndpointRestore:
+		return path.Join("/", e.BasePath, e.Model.Collection(), "{id}", "restore")
+	case EndpointAggregate:
+		return path.Join("/", e.BasePath, e.Model.Collection(), "aggregate")
+	case EndpointExport:
+		return path.Join("/", e.BasePath, e.Model.Collection(), "export")
+	}
+	return ""
+}
+
+// Endpoints gets the endpoints created by the handler Creator in the order of their creation.
+// The endpoints not allowed by the models EndpointPolicy are omitted.
+func (h *Creator) Endpoints() []Endpoint {
+	h.endpointsLock.Lock()
+	defer h.endpointsLock.Unlock()
+
+	endpoints := make([]Endpoint, 0, len(h.endpoints))
+	for _, endpoint := range h.endpoints {
+		if h.isEndpointAllowed(endpoint.Type, endpoint.Model, endpoint.Field) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// registerEndpoint stores the endpoint created by the handler Creator.
+func (h *Creator) registerEndpoint(endpointType EndpointType, model *mapping.ModelStruct, field *mapping.StructField, basePath string) {
+	endpoint := Endpoint{Type: endpointType, Model: model, Field: field, BasePath: h.getBasePath(basePath)}
+
+	h.endpointsLock.Lock()
+	defer h.endpointsLock.Unlock()
+	for _, registered := range h.endpoints {
+		if registered == endpoint {
+			return
+		}
+	}
+	h.endpoints = append(h.endpoints, endpoint)
 }
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/neuron-core/config"
+	mocks "github.com/neuronlabs/neuron-mocks"
+)
+
+// TestEndpoints tests the endpoints registered by the Creator handler functions.
+func TestEndpoints(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{})
+	require.NoError(t, err)
+
+	h := NewC(c)
+	h.BasePath = "/v1"
+
+	h.List(House{})
+	h.ListWith(House{}).BasePath("/v2").Handler()
+	h.GetWith(House{}).Handler()
+	h.Get(House{})
+	h.Delete(House{})
+	h.GetRelated(House{}, "owner")
+	h.GetRelationship(Human{}, "houses")
+	h.PatchRelationship(Human{}, "houses")
+
+	endpoints := h.Endpoints()
+	require.Len(t, endpoints, 7)
+
+	type expected struct {
+		Type   EndpointType
+		Method string
+		Path   string
+	}
+	expectations := []expected{
+		{EndpointList, http.MethodGet, "/v1/houses"},
+		{EndpointList, http.MethodGet, "/v2/houses"},
+		{EndpointGet, http.MethodGet, "/v1/houses/{id}"},
+		{EndpointDelete, http.MethodDelete, "/v1/houses/{id}"},
+		{EndpointGetRelated, http.MethodGet, "/v1/houses/{id}/owner"},
+		{EndpointGetRelationship, http.MethodGet, "/v1/humen/{id}/relationships/houses"},
+		{EndpointPatchRelationship, http.MethodPatch, "/v1/humen/{id}/relationships/houses"},
+	}
+	for i, e := range expectations {
+		assert.Equal(t, e.Type, endpoints[i].Type, e.Type.String())
+		assert.Equal(t, e.Method, endpoints[i].Type.Method())
+		assert.Equal(t, e.Path, endpoints[i].Path())
+	}
+	assert.True(t, EndpointGetRelated.IsRelationship())
+	assert.False(t, EndpointList.IsRelationship())
+}
@@ -7,6 +7,10 @@ import (
 
 func init() {
 	registerQueryClasses()
+	registerInputClasses()
+	registerValidationClasses()
+	registerAccessClasses()
+	registerRepositoryClasses()
 }
 
 var (
@@ -16,6 +20,22 @@ var (
 	// QueryInvalidParameter is the error classification for invalid url queries parameters.
 	QueryInvalidParameter errors.Class
 
+	// QuerySortUnsupportedField is the error classification for the sort field not allowed by the endpoint.
+	QuerySortUnsupportedField errors.Class
+
+	// QueryPageSizeOutOfRange is the error classification for the page size greater than the maximum allowed.
+	QueryPageSizeOutOfRange errors.Class
+
+	// QueryInvalidFilterExpression is the error classification for the invalid filter expression query parameter.
+	QueryInvalidFilterExpression errors.Class
+
+	// QueryInvalidAggregateField is the error classification for the invalid field of the aggregate query parameter.
+	QueryInvalidAggregateField errors.Class
+
+	// QueryTooManyResources is the error classification for the query matching more resources than the endpoint
+	// might process.
+	QueryTooManyResources errors.Class
+
 	// MnrQueryTimeout is the minor error classification for timed out client queries.
 	MnrQueryTimeout errors.Minor
 
@@ -34,6 +54,11 @@ func registerQueryClasses() {
 
 	invalidParameter := errors.MustNewIndex(class.MjrQuery, MnrQueryParameter)
 	QueryInvalidParameter = errors.MustNewClass(class.MjrQuery, MnrQueryParameter, invalidParameter)
+	QuerySortUnsupportedField = errors.MustNewClassWIndex(class.MjrQuery, MnrQueryParameter)
+	QueryPageSizeOutOfRange = errors.MustNewClassWIndex(class.MjrQuery, MnrQueryParameter)
+	QueryInvalidFilterExpression = errors.MustNewClassWIndex(class.MjrQuery, MnrQueryParameter)
+	QueryInvalidAggregateField = errors.MustNewClassWIndex(class.MjrQuery, MnrQueryParameter)
+	QueryTooManyResources = errors.MustNewClassWIndex(class.MjrQuery, MnrQueryParameter)
 
 	MnrQueryTimeout = errors.MustNewMinor(class.MjrQuery)
 	QueryTimeout = errors.MustNewMinorClass(class.MjrQuery, MnrQueryTimeout)
@@ -41,3 +66,101 @@ func registerQueryClasses() {
 	MnrQueryURL = errors.MustNewMinor(class.MjrQuery)
 	QueryInvalidURL = errors.MustNewMinorClass(class.MjrQuery, MnrQueryURL)
 }
+
+var (
+	// MnrInputBody is the minor error classification for the request body input.
+	MnrInputBody errors.Minor
+
+	// InputBodyTooLarge is the error classification for the request body that exceeds the size limit.
+	InputBodyTooLarge errors.Class
+
+	// InputBodyTooDeep is the error classification for the request body document that exceeds the nesting limit.
+	InputBodyTooDeep errors.Class
+
+	// InputBodyTooManyIncluded is the error classification for the request body document
+	// that contains too many 'included' resources.
+	InputBodyTooManyIncluded errors.Class
+
+	// InputBodyUnsupportedEncoding is the error classification for the request body with unsupported 'Content-Encoding'.
+	InputBodyUnsupportedEncoding errors.Class
+
+	// InputBodyInvalidEncoding is the error classification for the request body that couldn't be decoded
+	// with provided 'Content-Encoding'.
+	InputBodyInvalidEncoding errors.Class
+)
+
+func registerInputClasses() {
+	MnrInputBody = errors.MustNewMinor(class.MjrEncoding)
+
+	InputBodyTooLarge = errors.MustNewClassWIndex(class.MjrEncoding, MnrInputBody)
+	InputBodyTooDeep = errors.MustNewClassWIndex(class.MjrEncoding, MnrInputBody)
+	InputBodyTooManyIncluded = errors.MustNewClassWIndex(class.MjrEncoding, MnrInputBody)
+	InputBodyUnsupportedEncoding = errors.MustNewClassWIndex(class.MjrEncoding, MnrInputBody)
+	InputBodyInvalidEncoding = errors.MustNewClassWIndex(class.MjrEncoding, MnrInputBody)
+}
+
+var (
+	// MnrInputValidation is the minor error classification for the input values that don't satisfy the validation rules.
+	MnrInputValidation errors.Minor
+
+	// InputValidationRequired is the error classification for the missing required input value.
+	InputValidationRequired errors.Class
+
+	// InputValidationRange is the error classification for the input value out of the allowed range.
+	InputValidationRange errors.Class
+
+	// InputValidationLength is the error classification for the input value with invalid length.
+	InputValidationLength errors.Class
+
+	// InputValidationPattern is the error classification for the input value that doesn't match the pattern.
+	InputValidationPattern errors.Class
+
+	// InputValidationEnum is the error classification for the input value that is not one of the allowed values.
+	InputValidationEnum errors.Class
+
+	// InputValidationFunc is the error classification for the input value rejected by the custom validation function.
+	InputValidationFunc errors.Class
+)
+
+func registerValidationClasses() {
+	MnrInputValidation = errors.MustNewMinor(class.MjrEncoding)
+
+	InputValidationRequired = errors.MustNewClassWIndex(class.MjrEncoding, MnrInputValidation)
+	InputValidationRange = errors.MustNewClassWIndex(class.MjrEncoding, MnrInputValidation)
+	InputValidationLength = errors.MustNewClassWIndex(class.MjrEncoding, MnrInputValidation)
+	InputValidationPattern = errors.MustNewClassWIndex(class.MjrEncoding, MnrInputValidation)
+	InputValidationEnum = errors.MustNewClassWIndex(class.MjrEncoding, MnrInputValidation)
+	InputValidationFunc = errors.MustNewClassWIndex(class.MjrEncoding, MnrInputValidation)
+}
+
+var (
+	// MnrAccess is the minor error classification for the access restricted by the handler policies.
+	MnrAccess errors.Minor
+
+	// AccessFieldForbidden is the error classification for the access to the forbidden model field.
+	AccessFieldForbidden errors.Class
+
+	// AccessForbidden is the error classification for the request denied by the authorization.
+	AccessForbidden errors.Class
+
+	// AccessEndpointForbidden is the error classification for the request to the forbidden endpoint.
+	AccessEndpointForbidden errors.Class
+)
+
+func registerAccessClasses() {
+	MnrAccess = errors.MustNewMinor(class.MjrQuery)
+
+	AccessFieldForbidden = errors.MustNewClassWIndex(class.MjrQuery, MnrAccess)
+	AccessForbidden = errors.MustNewClassWIndex(class.MjrQuery, MnrAccess)
+	AccessEndpointForbidden = errors.MustNewClassWIndex(class.MjrQuery, MnrAccess)
+}
+
+var (
+	// RepositoryNotImplementsAggregator is the error classification for the repository that doesn't implement
+	// the requested aggregation.
+	RepositoryNotImplementsAggregator errors.Class
+)
+
+func registerRepositoryClasses() {
+	RepositoryNotImplementsAggregator = errors.MustNewClassWIndex(class.MjrRepository, class.MnrRepositoryNotImplements)
+}
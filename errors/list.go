@@ -263,6 +263,14 @@ func ErrEndpointForbidden() *jsonapi.Error {
 	}
 }
 
+// ErrFieldForbidden forbidden access to the resource field.
+func ErrFieldForbidden() *jsonapi.Error {
+	return &jsonapi.Error{
+		Title:  "The access to the specified resource field is forbidden.",
+		Status: "403",
+	}
+}
+
 /**
 
 STATUS 404
@@ -359,6 +367,34 @@ func ErrRequestBodyTooLarge() *jsonapi.Error {
 
 /**
 
+STATUS 415
+
+*/
+
+// ErrUnsupportedContentEncoding the content encoding of the request body is not supported.
+func ErrUnsupportedContentEncoding() *jsonapi.Error {
+	return &jsonapi.Error{
+		Title:  "The content encoding of the request body is not supported.",
+		Status: "415",
+	}
+}
+
+/**
+
+STATUS 422
+
+*/
+
+// ErrValidationFailed the request document contains values that don't satisfy the validation rules.
+func ErrValidationFailed() *jsonapi.Error {
+	return &jsonapi.Error{
+		Title:  "The request document contains values that don't satisfy the validation rules.",
+		Status: "422",
+	}
+}
+
+/**
+
 STATUS 500
 
 */
@@ -381,6 +417,20 @@ func ErrOperationTimedOut() *jsonapi.Error {
 
 /**
 
+STATUS 501
+
+*/
+
+// ErrNotImplemented the server doesn't support the functionality required to fulfill the request.
+func ErrNotImplemented() *jsonapi.Error {
+	return &jsonapi.Error{
+		Title:  "The server doesn't support the functionality required to fulfill the request.",
+		Status: "501",
+	}
+}
+
+/**
+
 STATUS 503
 
 */
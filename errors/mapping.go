@@ -7,6 +7,7 @@ import (
 	"github.com/neuronlabs/jsonapi"
 	"github.com/neuronlabs/neuron-core/class"
 
+	handlerClass "github.com/neuronlabs/jsonapi-handler/errors/class"
 	"github.com/neuronlabs/jsonapi-handler/log"
 )
 
@@ -37,6 +38,9 @@ var DefaultClassMapper = &ClassMapper{
 		class.MnrRepositoryNotImplements: ErrInternalError,
 		class.MnrRepositoryConnection:    ErrServiceUnavailable,
 		class.MnrRepositoryReplica:       ErrInternalError,
+
+		handlerClass.MnrInputValidation: ErrValidationFailed,
+		handlerClass.MnrAccess:          ErrForbidden,
 	},
 	Class: map[errors.Class]Creator{
 		class.EncodingUnmarshalCollection:      ErrTypeConflict,
@@ -87,6 +91,25 @@ var DefaultClassMapper = &ClassMapper{
 
 		class.CommonParseBrackets: ErrInvalidQueryParameter,
 		class.ModelSchemaNotFound: ErrInternalError,
+
+		handlerClass.QuerySortUnsupportedField:    ErrUnsupportedField,
+		handlerClass.QueryPageSizeOutOfRange:      ErrQueryParameterValueOutOfRange,
+		handlerClass.QueryInvalidFilterExpression: ErrInvalidQueryParameter,
+		handlerClass.QueryInvalidAggregateField:   ErrInvalidQueryParameter,
+		handlerClass.QueryTooManyResources:        ErrInvalidQueryParameter,
+
+		handlerClass.InputBodyTooLarge:        ErrRequestBodyTooLarge,
+		handlerClass.InputBodyTooDeep:         ErrInvalidJSONDocument,
+		handlerClass.InputBodyTooManyIncluded: ErrInvalidJSONDocument,
+
+		handlerClass.InputBodyUnsupportedEncoding: ErrUnsupportedContentEncoding,
+		handlerClass.InputBodyInvalidEncoding:     ErrInvalidInput,
+
+		handlerClass.AccessFieldForbidden:    ErrFieldForbidden,
+		handlerClass.AccessForbidden:         ErrForbidden,
+		handlerClass.AccessEndpointForbidden: ErrEndpointForbidden,
+
+		handlerClass.RepositoryNotImplementsAggregator: ErrNotImplemented,
 	},
 }
 
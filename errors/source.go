@@ -0,0 +1,52 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/neuronlabs/jsonapi"
+)
+
+// ErrorSource contains the references to the source of the error.
+type ErrorSource struct {
+	// Pointer is the JSON Pointer to the value in the request document that caused the error
+	// i.e. '/data/attributes/title'.
+	Pointer string `json:"pointer,omitempty"`
+	// Parameter is the name of the URI query parameter that caused the error.
+	Parameter string `json:"parameter,omitempty"`
+}
+
+// SourceError is the API error with the reference to the source of the problem.
+type SourceError struct {
+	*jsonapi.Error
+	Source *ErrorSource `json:"source,omitempty"`
+}
+
+// PointerError creates the SourceError for the 'err' with the source 'pointer'.
+func PointerError(err *jsonapi.Error, pointer string) *SourceError {
+	return &SourceError{Error: err, Source: &ErrorSource{Pointer: pointer}}
+}
+
+// ParameterError creates the SourceError for the 'err' with the source query 'parameter'.
+func ParameterError(err *jsonapi.Error, parameter string) *SourceError {
+	return &SourceError{Error: err, Source: &ErrorSource{Parameter: parameter}}
+}
+
+// SourceErrors is the multiple SourceError wrapper.
+type SourceErrors []*SourceError
+
+// Status gets the most significant api error status.
+func (s SourceErrors) Status() int {
+	multi := make(MultiError, len(s))
+	for i, err := range s {
+		multi[i] = err.Error
+	}
+	return multi.Status()
+}
+
+// MarshalSourceErrors writes the JSONAPI errors document with the 'errs' into the 'w' writer.
+func MarshalSourceErrors(w io.Writer, errs ...*SourceError) error {
+	return json.NewEncoder(w).Encode(struct {
+		Errors []*SourceError `json:"errors"`
+	}{Errors: errs})
+}
@@ -0,0 +1,408 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/neuron-core/class"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+
+	handlerErrors "github.com/neuronlabs/jsonapi-handler/errors"
+	handlerClass "github.com/neuronlabs/jsonapi-handler/errors/class"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// Export media types.
+const (
+	// MediaTypeNDJSON is the media type of the newline delimited JSON export.
+	MediaTypeNDJSON = "application/x-ndjson"
+	// MediaTypeCSV is the media type of the comma separated values export.
+	MediaTypeCSV = "text/csv"
+)
+
+// QueryParamExportFormat is the query parameter of the export endpoints that selects the export format.
+// The supported values are 'ndjson' and 'csv'. It takes precedence over the 'Accept' header.
+const QueryParamExportFormat = "format"
+
+// ExportWith returns the ListHandlerCreator of the endpoint that exports the 'model' resources.
+// The filters, search and soft delete options of the creator applies to the export endpoint.
+// The pagination, default sort order, counts and meta options are ignored.
+func (h *Creator) ExportWith(model interface{}) *ListHandlerCreator {
+	return &ListHandlerCreator{
+		h:       h,
+		model:   h.c.MustGetModelStruct(model),
+		handler: h.handleExport,
+	}
+}
+
+// Export returns the http.HandlerFunc that streams the 'model' resources as the newline delimited JSON objects
+// or the CSV rows. The endpoint supports the filter, sort and fields query parameters of the List endpoint.
+// The columns are the resource 'id' and the attributes of the fieldset. The resources are listed from
+// the repository in batches of the Creator's ExportBatchSize and written into the response one batch at a time.
+// By default the resources are exported in the order of the primary field and the batches are paged by
+// the keyset of the primary field values. The batches of the resources sorted by other fields are paged by
+// the offset, with the primary field appended as the last sort field, so that the order of the resources is stable.
+// The export stops when the request context is done i.e. the client disconnects.
+func (h *Creator) Export(model interface{}) http.HandlerFunc {
+	return h.handleExport(h.c.MustGetModelStruct(model), &endpointOptions{})
+}
+
+func (h *Creator) handleExport(model *mapping.ModelStruct, o *endpointOptions) http.HandlerFunc {
+	h.registerEndpoint(EndpointExport, model, nil, o.basePath)
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if h.endpointNotAllowed(rw, req, EndpointExport, model, nil) {
+			return
+		}
+		ctx := req.Context()
+		s := query.NewModelC(h.c, model, true)
+		if err := h.parseQuery(ctx, req, EndpointExport, s, o); err != nil {
+			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
+			return
+		}
+		if err := h.excludeSoftDeleted(s); err != nil {
+			log.Errorf("[EXPORT][SCOPE][%s] Excluding soft deleted resources failed: %v", s.ID(), err)
+			h.marshalErrors(rw, req, 0, handlerErrors.ErrInternalError())
+			return
+		}
+		if err := checkRequiredFilters(s, o); err != nil {
+			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
+			return
+		}
+		if err := addDefaultFilters(s, o); err != nil {
+			log.Errorf("[EXPORT][SCOPE][%s] Adding default filters failed: %v", s.ID(), err)
+			h.marshalErrors(rw, req, 0, handlerErrors.ErrInternalError())
+			return
+		}
+		if !isSortedBy(s, model.Primary()) {
+			// the primary field keeps the order of the resources with equal sort fields values stable.
+			if err := s.SortField(model.Primary().NeuronName()); err != nil {
+				log.Errorf("[EXPORT][SCOPE][%s] Sorting by primary field failed: %v", s.ID(), err)
+				h.marshalErrors(rw, req, 0, handlerErrors.ErrInternalError())
+				return
+			}
+		}
+		// the resources sorted only by the primary field are paged by the keyset of the primary field values.
+		keyset := len(s.SortFields) == 1
+
+		if errs := h.authorize(req, EndpointExport, model, nil, s); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
+		isNoResult, err := h.resolveFilterGroups(ctx, s)
+		if err != nil {
+			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
+			return
+		}
+		if beforeListHook, ok := Hooks.getHook(model, BeforeList); ok {
+			if err = beforeListHook(ctx, s); err != nil {
+				h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
+				return
+			}
+		}
+
+		e := &exporter{h: h, format: exportFormat(req, s), columns: h.exportColumns(ctx, s)}
+		var batch *query.Scope
+		if !isNoResult {
+			if batch, err = h.listExportBatch(ctx, s, e.columns, nil, 0); err != nil {
+				if ctx.Err() != nil {
+					log.Debugf("[EXPORT][SCOPE][%s] Request context done: %v", s.ID(), ctx.Err())
+					return
+				}
+				h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
+				return
+			}
+		}
+
+		rw.Header().Add("Content-Type", e.format)
+		w := h.writer(rw, req, http.StatusOK)
+		defer func() {
+			if err := w.Close(); err != nil {
+				log.Debugf("Close failed: %v", err)
+			}
+		}()
+		if err = e.start(w); err != nil {
+			log.Debugf("[EXPORT][SCOPE][%s] Writing export failed: %v", s.ID(), err)
+			return
+		}
+		var offset int64
+		for batch != nil {
+			n, err := e.write(batch)
+			if err == nil {
+				err = e.flush(w)
+			}
+			if err != nil {
+				log.Debugf("[EXPORT][SCOPE][%s] Writing export failed: %v", s.ID(), err)
+				return
+			}
+			if n < h.exportBatchSize() {
+				return
+			}
+			var last interface{}
+			if keyset {
+				last = lastPrimary(batch)
+			} else {
+				offset += int64(n)
+			}
+			if batch, err = h.listExportBatch(ctx, s, e.columns, last, offset); err != nil {
+				// the response status is already written - the export is interrupted.
+				log.Debugf("[EXPORT][SCOPE][%s] Listing batch after: '%v' at offset: %d failed: %v", s.ID(), last, offset, err)
+				return
+			}
+		}
+	}
+}
+
+// listExportBatch lists the batch of the scope 's' resources following the resource with the primary value 'after'
+// or starting at the 'offset'. The batches of the scope sorted only by the primary field are paged by its keyset,
+// so that the repository doesn't scan the skipped resources. If the 'after' is nil the batch is listed at the 'offset'.
+// The batch contains only the 'columns' fields. Returns nil scope if there is no more resources.
+func (h *Creator) listExportBatch(ctx context.Context, s *query.Scope, columns []*mapping.StructField, after interface{}, offset int64) (*query.Scope, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	batch := query.NewModelC(h.c, s.Struct(), true)
+	fields := make([]interface{}, len(columns))
+	for i, column := range columns {
+		fields[i] = column
+	}
+	if err := batch.SetFields(fields...); err != nil {
+		return nil, err
+	}
+	if err := copyFilters(s, batch); err != nil {
+		return nil, err
+	}
+	for _, sortField := range s.SortFields {
+		batch.SortFields = append(batch.SortFields, sortField.Copy())
+	}
+	if after != nil {
+		op := query.OpGreaterThan
+		if s.SortFields[0].Order == query.DescendingOrder {
+			op = query.OpLessThan
+		}
+		if err := batch.FilterField(query.NewFilter(s.Struct().Primary(), op, after)); err != nil {
+			return nil, err
+		}
+	}
+	batch.Pagination = &query.Pagination{Type: query.LimitOffsetPagination, Size: int64(h.exportBatchSize()), Offset: offset}
+
+	if err := batch.ListContext(ctx); err != nil {
+		if e, ok := err.(errors.ClassError); ok && e.Class() == class.QueryValueNoResult {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if afterListHook, ok := Hooks.getHook(s.Struct(), AfterList); ok {
+		if err := afterListHook(ctx, batch); err != nil {
+			return nil, err
+		}
+	}
+	return batch, nil
+}
+
+// exportBatchSize gets the ExportBatchSize or the default batch size of 1000 if it is not greater than zero.
+func (h *Creator) exportBatchSize() int {
+	if h.ExportBatchSize <= 0 {
+		return 1000
+	}
+	return h.ExportBatchSize
+}
+
+// isSortedBy checks if the scope 's' is sorted by the 'field'.
+func isSortedBy(s *query.Scope, field *mapping.StructField) bool {
+	for _, sortField := range s.SortFields {
+		if sortField.StructField == field {
+			return true
+		}
+	}
+	return false
+}
+
+// lastPrimary gets the primary field value of the last resource listed within the 'batch'.
+func lastPrimary(batch *query.Scope) interface{} {
+	values := reflect.ValueOf(batch.Value).Elem()
+	for i := values.Len() - 1; i >= 0; i-- {
+		single := values.Index(i)
+		if single.Kind() == reflect.Ptr {
+			if single.IsNil() {
+				continue
+			}
+			single = single.Elem()
+		}
+		return single.FieldByIndex(batch.Struct().Primary().ReflectField().Index).Interface()
+	}
+	return nil
+}
+
+// exportColumns gets the primary field and the readable attributes of the scope 's' fieldset.
+// The fields of the compound id codec are always exported.
+func (h *Creator) exportColumns(ctx context.Context, s *query.Scope) []*mapping.StructField {
+	model := s.Struct()
+	columns := []*mapping.StructField{model.Primary()}
+	if codec, ok := h.idCodec(model).(CompoundIDCodec); ok {
+		for i := range codec.Fields {
+			if field, err := codec.field(model, i); err == nil && field != model.Primary() {
+				columns = append(columns, field)
+			}
+		}
+	}
+	for _, attr := range model.Attributes() {
+		if attr.IsHidden() || !h.canRead(ctx, model, attr) {
+			continue
+		}
+		if _, ok := s.Fieldset[attr.NeuronName()]; !ok {
+			continue
+		}
+		var found bool
+		for _, column := range columns {
+			if column == attr {
+				found = true
+				break
+			}
+		}
+		if !found {
+			columns = append(columns, attr)
+		}
+	}
+	return columns
+}
+
+// exportFormat gets the export media type from the scope 's' format query parameter or the 'req' Accept header.
+func exportFormat(req *http.Request, s *query.Scope) string {
+	if format, ok := s.StoreGet(scopeExportFormatK); ok {
+		return format.(string)
+	}
+	for _, accepted := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case MediaTypeCSV, MediaTypeNDJSON:
+			return mediaType
+		}
+	}
+	return MediaTypeNDJSON
+}
+
+// queryParameterExportFormat sets the export format of the scope 's' with the 'format' query 'value'.
+func (h *Creator) queryParameterExportFormat(s *query.Scope, value string) error {
+	switch value {
+	case "ndjson":
+		s.StoreSet(scopeExportFormatK, MediaTypeNDJSON)
+	case "csv":
+		s.StoreSet(scopeExportFormatK, MediaTypeCSV)
+	default:
+		err := errors.NewDetf(handlerClass.QueryInvalidParameter, "unsupported export format: '%s'", value)
+		err.SetDetailsf("The query parameter: '%s' value must be 'ndjson' or 'csv'.", QueryParamExportFormat)
+		return err
+	}
+	return nil
+}
+
+// exporter writes the exported resources in the selected format.
+type exporter struct {
+	h       *Creator
+	format  string
+	columns []*mapping.StructField
+
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+// start prepares the exporter for writing into 'w'. The CSV header row is written immediately.
+func (e *exporter) start(w io.Writer) error {
+	if e.format == MediaTypeNDJSON {
+		e.json = json.NewEncoder(w)
+		return nil
+	}
+	e.csv = csv.NewWriter(w)
+	header := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		header[i] = column.NeuronName()
+	}
+	return e.csv.Write(header)
+}
+
+// write writes the resources of the 'batch' scope. Returns the number of listed resources.
+func (e *exporter) write(batch *query.Scope) (int, error) {
+	model := batch.Struct()
+	values := reflect.ValueOf(batch.Value).Elem()
+	for i := 0; i < values.Len(); i++ {
+		single := values.Index(i)
+		if single.Kind() != reflect.Ptr {
+			single = single.Addr()
+		}
+		if single.IsNil() {
+			continue
+		}
+		// the raw primary value is exported - the url 'id' encoding applies to the links only.
+		id := mapping.StringValues(single.Elem().FieldByIndex(model.Primary().ReflectField().Index).Interface(), nil)[0]
+
+		if e.json != nil {
+			object := make(map[string]interface{}, len(e.columns))
+			object["id"] = id
+			for _, column := range e.columns[1:] {
+				object[column.NeuronName()] = fieldInterface(single.Elem().FieldByIndex(column.ReflectField().Index))
+			}
+			if err := e.json.Encode(object); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		record := make([]string, len(e.columns))
+		record[0] = csvValue(id)
+		for j, column := range e.columns[1:] {
+			record[j+1] = csvValue(fieldInterface(single.Elem().FieldByIndex(column.ReflectField().Index)))
+		}
+		if err := e.csv.Write(record); err != nil {
+			return 0, err
+		}
+	}
+	return values.Len(), nil
+}
+
+// flush writes the buffered data into the client.
+func (e *exporter) flush(w io.Writer) error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if f, ok := w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// csvValue formats the 'value' as the CSV cell. The strings starting with the characters interpreted
+// by the spreadsheet applications as the formula i.e. '=', '+', '-' or '@' are prefixed with the apostrophe.
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+var scopeExportFormatK scopeExportFormat
+
+type scopeExportFormat struct{}
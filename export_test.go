@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+)
+
+// TestExport tests the export endpoint.
+func TestExport(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, target, accept string) *http.Request {
+		req, err := http.NewRequest("GET", target, nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", accept)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req
+	}
+
+	repo, err := c.GetRepository(Human{})
+	require.NoError(t, err)
+
+	humansRepo, ok := repo.(*mocks.Repository)
+	require.True(t, ok)
+
+	listHumans := func(t *testing.T, after interface{}, humans ...*Human) {
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if assert.NotNil(t, s.Pagination) {
+				assert.Equal(t, int64(2), s.Pagination.Size)
+				assert.Equal(t, int64(0), s.Pagination.Offset)
+			}
+			if assert.Len(t, s.SortFields, 1) {
+				assert.Equal(t, "id", s.SortFields[0].StructField.NeuronName())
+			}
+			// the batches are paged by the primary field keyset.
+			if after == nil {
+				assert.Len(t, s.PrimaryFilters, 0)
+			} else if assert.Len(t, s.PrimaryFilters, 1) && assert.Len(t, s.PrimaryFilters[0].Values, 1) {
+				assert.Equal(t, query.OpGreaterThan, s.PrimaryFilters[0].Values[0].Operator)
+				assert.Equal(t, []interface{}{after}, s.PrimaryFilters[0].Values[0].Values)
+			}
+			assert.Len(t, s.AttributeFilters, 1)
+			v, ok := s.Value.(*[]*Human)
+			require.True(t, ok)
+			*v = append(*v, humans...)
+		}).Return(nil)
+	}
+
+	t.Run("NDJSON", func(t *testing.T) {
+		h := NewC(c)
+		h.ExportBatchSize = 2
+		listHumans(t, nil, &Human{ID: 1, Name: "Jon", Age: 20}, &Human{ID: 2, Name: "Ann", Age: 30})
+		listHumans(t, 2, &Human{ID: 3, Name: "Bob", Age: 40})
+
+		resp := httptest.NewRecorder()
+		h.Export(Human{}).ServeHTTP(resp, newRequest(t, "/humen/export?fields[humen]=name&filter[humen][age][$gt]=10", "*/*"))
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, MediaTypeNDJSON, resp.Header().Get("Content-Type"))
+
+		var rows []map[string]interface{}
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			row := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+			rows = append(rows, row)
+		}
+		assert.Equal(t, []map[string]interface{}{
+			{"id": "1", "name": "Jon"},
+			{"id": "2", "name": "Ann"},
+			{"id": "3", "name": "Bob"},
+		}, rows)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		h := NewC(c)
+		h.ExportBatchSize = 2
+		listHumans(t, nil, &Human{ID: 1, Name: "Doe, Jon", Age: 20})
+
+		resp := httptest.NewRecorder()
+		h.Export(Human{}).ServeHTTP(resp, newRequest(t, "/humen/export?fields[humen]=name,age&filter[humen][age][$gt]=10", MediaTypeCSV))
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, MediaTypeCSV, resp.Header().Get("Content-Type"))
+		assert.Equal(t, "id,name,age\n1,\"Doe, Jon\",20\n", resp.Body.String())
+	})
+
+	t.Run("IDCodec", func(t *testing.T) {
+		h := NewC(c)
+		h.ExportBatchSize = 2
+		h.RegisterIDCodec(Human{}, CompoundIDCodec{Fields: []string{"name", "id"}})
+		listHumans(t, nil, &Human{ID: 1, Name: "Doe~Jon", Age: 20})
+
+		// the raw primary value is exported instead of the encoded url 'id'.
+		resp := httptest.NewRecorder()
+		h.Export(Human{}).ServeHTTP(resp, newRequest(t, "/humen/export?fields[humen]=age&filter[humen][age][$gt]=10", MediaTypeCSV))
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "id,name,age\n1,Doe~Jon,20\n", resp.Body.String())
+	})
+
+	t.Run("CSVFormula", func(t *testing.T) {
+		h := NewC(c)
+		h.ExportBatchSize = 2
+		listHumans(t, nil, &Human{ID: 1, Name: "=HYPERLINK(\"x\")", Age: -1}, &Human{ID: 2, Name: "@SUM(A1)", Age: 20})
+		listHumans(t, 2, &Human{ID: 3, Name: "-2+3", Age: 30})
+
+		// the cells interpreted as formulas by the spreadsheets are escaped, the negative numbers are not.
+		resp := httptest.NewRecorder()
+		h.Export(Human{}).ServeHTTP(resp, newRequest(t, "/humen/export?fields[humen]=name,age&filter[humen][age][$gt]=-10", MediaTypeCSV))
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "id,name,age\n1,\"'=HYPERLINK(\"\"x\"\")\",-1\n2,'@SUM(A1),20\n3,'-2+3,30\n", resp.Body.String())
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		h := NewC(c)
+		h.ExportBatchSize = 2
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*[]*Human)
+			require.True(t, ok)
+			*v = append(*v, &Human{ID: 1}, &Human{ID: 2})
+		}).Return(nil)
+
+		// the client disconnects after receiving the first batch.
+		resp := &cancelRecorder{ResponseRecorder: httptest.NewRecorder(), cancel: cancel}
+		h.Export(Human{}).ServeHTTP(resp, newRequest(t, "/humen/export?format=csv&fields[humen]=name", "*/*").WithContext(ctx))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "id,name\n1,\n2,\n", resp.Body.String())
+	})
+
+	t.Run("Sort", func(t *testing.T) {
+		h := NewC(c)
+		h.ExportBatchSize = 0
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			// the default batch size is used.
+			if assert.NotNil(t, s.Pagination) {
+				assert.Equal(t, int64(1000), s.Pagination.Size)
+			}
+			if assert.Len(t, s.SortFields, 1) {
+				assert.Equal(t, "id", s.SortFields[0].StructField.NeuronName())
+				assert.Equal(t, query.DescendingOrder, s.SortFields[0].Order)
+			}
+			v, ok := s.Value.(*[]*Human)
+			require.True(t, ok)
+			*v = append(*v, &Human{ID: 1, Name: "Jon"})
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.Export(Human{}).ServeHTTP(resp, newRequest(t, "/humen/export?format=csv&fields[humen]=name&sort=-id", "*/*"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "id,name\n1,Jon\n", resp.Body.String())
+
+	})
+
+	t.Run("SortFields", func(t *testing.T) {
+		h := NewC(c)
+		h.ExportBatchSize = 2
+		listSorted := func(offset int64, humans ...*Human) {
+			humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+				s, ok := args[1].(*query.Scope)
+				require.True(t, ok)
+
+				// the batches of the resources sorted by other fields are paged by the offset.
+				if assert.NotNil(t, s.Pagination) {
+					assert.Equal(t, int64(2), s.Pagination.Size)
+					assert.Equal(t, offset, s.Pagination.Offset)
+				}
+				assert.Len(t, s.PrimaryFilters, 0)
+				// the primary field is the last sort field.
+				if assert.Len(t, s.SortFields, 2) {
+					assert.Equal(t, "name", s.SortFields[0].StructField.NeuronName())
+					assert.Equal(t, query.DescendingOrder, s.SortFields[0].Order)
+					assert.Equal(t, "id", s.SortFields[1].StructField.NeuronName())
+					assert.Equal(t, query.AscendingOrder, s.SortFields[1].Order)
+				}
+				v, ok := s.Value.(*[]*Human)
+				require.True(t, ok)
+				*v = append(*v, humans...)
+			}).Return(nil)
+		}
+		listSorted(0, &Human{ID: 3, Name: "Jon"}, &Human{ID: 1, Name: "Bob"})
+		listSorted(2, &Human{ID: 2, Name: "Ann"})
+
+		resp := httptest.NewRecorder()
+		h.Export(Human{}).ServeHTTP(resp, newRequest(t, "/humen/export?format=csv&fields[humen]=name&sort=-name", "*/*"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "id,name\n3,Jon\n1,Bob\n2,Ann\n", resp.Body.String())
+		humansRepo.AssertExpectations(t)
+	})
+
+	t.Run("ListOptions", func(t *testing.T) {
+		h := NewC(c)
+		handler := h.ExportWith(Human{}).RequiredFilters("age").Handler()
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, newRequest(t, "/humen/export?format=csv", "*/*"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			assert.Len(t, s.AttributeFilters, 1)
+		}).Return(nil)
+
+		resp = httptest.NewRecorder()
+		handler.ServeHTTP(resp, newRequest(t, "/humen/export?format=csv&fields[humen]=name&filter[humen][age][$gt]=10", "*/*"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "id,name\n", resp.Body.String())
+	})
+
+	t.Run("InvalidFormat", func(t *testing.T) {
+		h := NewC(c)
+		resp := httptest.NewRecorder()
+		h.Export(Human{}).ServeHTTP(resp, newRequest(t, "/humen/export?format=xml", "*/*"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+	humansRepo.AssertExpectations(t)
+}
+
+type cancelRecorder struct {
+	*httptest.ResponseRecorder
+	cancel context.CancelFunc
+}
+
+// Flush implements http.Flusher interface.
+func (c *cancelRecorder) Flush() {
+	c.ResponseRecorder.Flush()
+	c.cancel()
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/annotation"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+
+	handlerErrors "github.com/neuronlabs/jsonapi-handler/errors"
+	"github.com/neuronlabs/jsonapi-handler/errors/class"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// FieldPolicy is the interface used by the handlers to restrict the access to the model's attributes and relationships.
+// The policy is evaluated for each request with the request context 'ctx'. The primary and foreign key fields
+// are not restricted by the policy.
+type FieldPolicy interface {
+	// CanRead checks if the 'field' might be read with the 'ctx' request context. Unreadable fields are stripped from
+	// the responses, the fieldsets and the included relationships.
+	CanRead(ctx context.Context, field *mapping.StructField) bool
+	// CanWrite checks if the 'field' might be written with the 'ctx' request context. The create and patch documents
+	// with unwritable fields are rejected with the '403' errors.
+	CanWrite(ctx context.Context, field *mapping.StructField) bool
+}
+
+// compile time check for the FieldPolicy interface.
+var _ FieldPolicy = FieldPolicyFuncs{}
+
+// FieldPolicyFuncs is the FieldPolicy composed of the functions. If a function is nil the access is allowed.
+type FieldPolicyFuncs struct {
+	Read  func(ctx context.Context, field *mapping.StructField) bool
+	Write func(ctx context.Context, field *mapping.StructField) bool
+}
+
+// CanRead implements FieldPolicy interface.
+func (f FieldPolicyFuncs) CanRead(ctx context.Context, field *mapping.StructField) bool {
+	return f.Read == nil || f.Read(ctx, field)
+}
+
+// CanWrite implements FieldPolicy interface.
+func (f FieldPolicyFuncs) CanWrite(ctx context.Context, field *mapping.StructField) bool {
+	return f.Write == nil || f.Write(ctx, field)
+}
+
+// RegisterFieldPolicy registers the field access 'policy' used by the handlers of the 'model'.
+func (h *Creator) RegisterFieldPolicy(model interface{}, policy FieldPolicy) {
+	mappedModel := h.c.MustGetModelStruct(model)
+	h.fieldPolicies[mappedModel] = policy
+}
+
+// canRead checks if the 'field' of the 'model' is readable within the 'ctx' context.
+func (h *Creator) canRead(ctx context.Context, model *mapping.ModelStruct, field *mapping.StructField) bool {
+	policy, ok := h.fieldPolicies[model]
+	if !ok || !isPolicyField(field) {
+		return true
+	}
+	return policy.CanRead(ctx, field)
+}
+
+// canWrite checks if the 'field' of the 'model' is writable within the 'ctx' context.
+func (h *Creator) canWrite(ctx context.Context, model *mapping.ModelStruct, field *mapping.StructField) bool {
+	policy, ok := h.fieldPolicies[model]
+	if !ok || !isPolicyField(field) {
+		return true
+	}
+	return policy.CanWrite(ctx, field)
+}
+
+func isPolicyField(field *mapping.StructField) bool {
+	switch field.Kind() {
+	case mapping.KindAttribute, mapping.KindRelationshipSingle, mapping.KindRelationshipMultiple:
+		return true
+	}
+	return false
+}
+
+// readableIncludes gets the 'included' paths of the 'model' that contains only readable relationships.
+func (h *Creator) readableIncludes(ctx context.Context, model *mapping.ModelStruct, included []string) []string {
+	if len(h.fieldPolicies) == 0 {
+		return included
+	}
+	readable := included[:0:0]
+	for _, include := range included {
+		if h.isIncludeReadable(ctx, model, include) {
+			readable = append(readable, include)
+			continue
+		}
+		log.Debug2f("[%s] Forbidden include: '%s' stripped", model.Collection(), include)
+	}
+	return readable
+}
+
+func (h *Creator) isIncludeReadable(ctx context.Context, model *mapping.ModelStruct, include string) bool {
+	for _, name := range strings.Split(include, annotation.NestedSeparator) {
+		field, ok := model.RelationField(name)
+		if !ok {
+			// unknown fields are reported by the query.
+			return true
+		}
+		if !h.canRead(ctx, model, field) {
+			return false
+		}
+		model = field.Relationship().Struct()
+	}
+	return true
+}
+
+// stripUnreadableFields removes the unreadable fields from the fieldsets of the scope 's' and its included scopes.
+func (h *Creator) stripUnreadableFields(ctx context.Context, s *query.Scope) {
+	if len(h.fieldPolicies) == 0 {
+		return
+	}
+	for _, scope := range append([]*query.Scope{s}, s.IncludedScopes()...) {
+		for name, field := range scope.Fieldset {
+			if !h.canRead(ctx, scope.Struct(), field) {
+				delete(scope.Fieldset, name)
+			}
+		}
+	}
+}
+
+// unwritableFields gets the errors for all the unwritable fields within the fieldset of the unmarshaled scope 's'.
+// The deletion timestamp attribute of the soft deleted model is never writable - it is set by the Delete
+// and Restore endpoints only.
+func (h *Creator) unwritableFields(ctx context.Context, s *query.Scope) (errs []*handlerErrors.SourceError) {
+	_, hasPolicy := h.fieldPolicies[s.Struct()]
+	deletedAt, isSoftDeleted := h.softDeletes[s.Struct()]
+	if !hasPolicy && !isSoftDeleted {
+		return nil
+	}
+	for _, field := range s.OrderedFieldset() {
+		if field != deletedAt && h.canWrite(ctx, s.Struct(), field) {
+			continue
+		}
+		pointer := "/data/attributes/" + field.NeuronName()
+		if field.IsRelationship() {
+			pointer = "/data/relationships/" + field.NeuronName()
+		}
+		errs = append(errs, handlerErrors.PointerError(fieldForbiddenError(field, "Writing"), pointer))
+	}
+	return errs
+}
+
+// fieldForbiddenError creates the '403' api error for the forbidden 'operation' i.e. 'Reading' on the 'field'.
+func fieldForbiddenError(field *mapping.StructField, operation string) *jsonapi.Error {
+	err := errors.NewDetf(class.AccessFieldForbidden, "%s field: '%s' is forbidden", strings.ToLower(operation), field.NeuronName())
+	err.SetDetailsf("%s the field: '%s' is forbidden.", operation, field.NeuronName())
+	return handlerErrors.MapError(err)[0]
+}
+
+// checkReadableFilter checks if the fields of the filter 'f' and its nested filters are readable within the 'ctx'.
+func (h *Creator) checkReadableFilter(ctx context.Context, f *query.FilterField) error {
+	if !h.canRead(ctx, f.StructField.ModelStruct(), f.StructField) {
+		return readForbiddenError(f.StructField.NeuronName())
+	}
+	for _, nested := range f.Nested {
+		if err := h.checkReadableFilter(ctx, nested); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkReadablePath checks if the fields of the dot separated relationship 'path' of the 'model' are readable
+// within the 'ctx'. The unknown fields are reported by the query.
+func (h *Creator) checkReadablePath(ctx context.Context, model *mapping.ModelStruct, path string) error {
+	if len(h.fieldPolicies) == 0 {
+		return nil
+	}
+	segments := strings.Split(path, annotation.NestedSeparator)
+	for i, name := range segments {
+		var (
+			field *mapping.StructField
+			ok    bool
+		)
+		if i < len(segments)-1 {
+			field, ok = model.RelationField(name)
+		} else {
+			field, ok = model.FieldByName(name)
+		}
+		if !ok {
+			return nil
+		}
+		if !h.canRead(ctx, model, field) {
+			return readForbiddenError(name)
+		}
+		if field.IsRelationship() {
+			model = field.Relationship().Struct()
+		}
+	}
+	return nil
+}
+
+// readForbiddenError creates the error for the forbidden reading of the field with the neuron 'name'.
+func readForbiddenError(name string) errors.DetailedError {
+	err := errors.NewDetf(class.AccessFieldForbidden, "read field: '%s' is forbidden", name)
+	err.SetDetailsf("Reading the field: '%s' is forbidden.", name)
+	return err
+}
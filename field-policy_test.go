@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+
+	handlerErrors "github.com/neuronlabs/jsonapi-handler/errors"
+)
+
+// TestFieldPolicy tests the field access policies.
+func TestFieldPolicy(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{})
+	require.NoError(t, err)
+
+	type hrKey struct{}
+	isHR := func(ctx context.Context) bool {
+		hr, _ := ctx.Value(hrKey{}).(bool)
+		return hr
+	}
+
+	newHandler := func() *Creator {
+		h := NewC(c)
+		h.RegisterFieldPolicy(House{}, FieldPolicyFuncs{
+			Read: func(ctx context.Context, field *mapping.StructField) bool {
+				return field.NeuronName() != "owner" || isHR(ctx)
+			},
+		})
+		h.RegisterFieldPolicy(Human{}, FieldPolicyFuncs{
+			Read: func(ctx context.Context, field *mapping.StructField) bool {
+				return field.NeuronName() != "age" || isHR(ctx)
+			},
+			Write: func(ctx context.Context, field *mapping.StructField) bool {
+				return field.NeuronName() != "name"
+			},
+		})
+		return h
+	}
+
+	t.Run("Read", func(t *testing.T) {
+		t.Run("Forbidden", func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/houses/1?include=owner&fields[houses]=address,owner", nil)
+			require.NoError(t, err)
+
+			req.Header.Add("Accept", jsonapi.MediaType)
+			req.Header.Add("Accept-Encoding", "identity")
+			req = req.WithContext(context.WithValue(context.Background(), IDKey, "1"))
+
+			repo, err := c.GetRepository(House{})
+			require.NoError(t, err)
+
+			housesRepo, ok := repo.(*mocks.Repository)
+			require.True(t, ok)
+
+			housesRepo.On("Get", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+				s, ok := args[1].(*query.Scope)
+				require.True(t, ok)
+
+				// the forbidden include is stripped.
+				assert.Len(t, s.IncludedScopes(), 0)
+
+				v, ok := s.Value.(*House)
+				require.True(t, ok)
+				v.ID = 1
+				v.Address = "Main Rd 52"
+				v.Owner = &Human{ID: 3}
+			}).Return(nil)
+
+			resp := httptest.NewRecorder()
+			newHandler().Get(House{}).ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			payload := map[string]map[string]interface{}{}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+			assert.NotContains(t, payload["data"], "relationships")
+			assert.NotContains(t, payload, "included")
+		})
+
+		t.Run("Allowed", func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/humen/3?fields[humen]=name,age", nil)
+			require.NoError(t, err)
+
+			req.Header.Add("Accept", jsonapi.MediaType)
+			req.Header.Add("Accept-Encoding", "identity")
+			ctx := context.WithValue(context.Background(), IDKey, "3")
+			req = req.WithContext(context.WithValue(ctx, hrKey{}, true))
+
+			repo, err := c.GetRepository(Human{})
+			require.NoError(t, err)
+
+			humenRepo, ok := repo.(*mocks.Repository)
+			require.True(t, ok)
+
+			humenRepo.On("Get", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+				s, ok := args[1].(*query.Scope)
+				require.True(t, ok)
+
+				v, ok := s.Value.(*Human)
+				require.True(t, ok)
+				v.ID = 3
+				v.Name = "John"
+				v.Age = 40
+			}).Return(nil)
+
+			resp := httptest.NewRecorder()
+			newHandler().Get(Human{}).ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			human := &Human{}
+			require.NoError(t, jsonapi.UnmarshalC(c, resp.Body, human))
+			assert.Equal(t, 40, human.Age)
+		})
+
+		t.Run("Relationship", func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/houses/1/relationships/owner", nil)
+			require.NoError(t, err)
+
+			req.Header.Add("Accept", jsonapi.MediaType)
+			req.Header.Add("Accept-Encoding", "identity")
+			req = req.WithContext(context.WithValue(context.Background(), IDKey, "1"))
+
+			resp := httptest.NewRecorder()
+			newHandler().GetRelationship(House{}, "owner").ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusForbidden, resp.Code)
+		})
+
+		t.Run("Query", func(t *testing.T) {
+			for name, target := range map[string]string{
+				"Filter":           "/humen?filter[humen][age][$gt]=1",
+				"Sort":             "/humen?sort=-age",
+				"FilterExpression": "/humen?filter[expression]=name+eq+John+or+age+gt+1",
+				"Search":           "/humen?search=4",
+			} {
+				t.Run(name, func(t *testing.T) {
+					req, err := http.NewRequest("GET", target, nil)
+					require.NoError(t, err)
+
+					req.Header.Add("Accept", jsonapi.MediaType)
+					req.Header.Add("Accept-Encoding", "identity")
+
+					resp := httptest.NewRecorder()
+					newHandler().ListWith(Human{}).SearchFields("age").Handler().ServeHTTP(resp, req)
+
+					assert.Equal(t, http.StatusForbidden, resp.Code)
+				})
+			}
+		})
+
+		t.Run("SearchReadable", func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/humen?search=John", nil)
+			require.NoError(t, err)
+
+			req.Header.Add("Accept", jsonapi.MediaType)
+			req.Header.Add("Accept-Encoding", "identity")
+
+			repo, err := c.GetRepository(Human{})
+			require.NoError(t, err)
+
+			humenRepo, ok := repo.(*mocks.Repository)
+			require.True(t, ok)
+
+			humenRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+				s, ok := args[1].(*query.Scope)
+				require.True(t, ok)
+
+				// the unreadable 'age' attribute is not searched.
+				if assert.Len(t, s.AttributeFilters, 1) {
+					assert.Equal(t, "name", s.AttributeFilters[0].StructField.NeuronName())
+				}
+				s.Value = &[]*Human{}
+			}).Return(nil)
+
+			resp := httptest.NewRecorder()
+			newHandler().ListWith(Human{}).SearchFields("name", "age").Handler().ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusOK, resp.Code)
+			humenRepo.AssertExpectations(t)
+		})
+	})
+
+	t.Run("Write", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/humen", strings.NewReader(`{"data":{"type":"humen","attributes":{"name":"John","age":40}}}`))
+		require.NoError(t, err)
+
+		req.Header.Add("Content-Type", jsonapi.MediaType)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+
+		resp := httptest.NewRecorder()
+		newHandler().Create(Human{}).ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+
+		payload := struct {
+			Errors []*handlerErrors.SourceError `json:"errors"`
+		}{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		if assert.Len(t, payload.Errors, 1) {
+			assert.Equal(t, "403", payload.Errors[0].Status)
+			if assert.NotNil(t, payload.Errors[0].Source) {
+				assert.Equal(t, "/data/attributes/name", payload.Errors[0].Source.Pointer)
+			}
+		}
+	})
+}
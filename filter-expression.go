@@ -0,0 +1,403 @@
+package handler
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/neuron-core/annotation"
+	"github.com/neuronlabs/neuron-core/class"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+
+	handlerClass "github.com/neuronlabs/jsonapi-handler/errors/class"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// QueryParamFilterExpression is the query parameter of the list endpoints that contains the filter expression.
+// The expression allows to combine the filters with the logical 'and' and 'or' operators. The grammar is:
+//
+//	expression = term { "or" term }
+//	term       = factor { "and" factor }
+//	factor     = "(" expression ")" | comparison
+//	comparison = field operator [ value ]
+//
+// The 'field' is the neuron name of the model field or the dot separated path of the relationship field
+// i.e. 'owner.name'. The 'operator' is the raw neuron filter operator name without the '$' prefix
+// i.e. 'eq', 'ne', 'gt', 'in', 'not_in', 'contains', 'starts_with'. The 'is_null', 'not_null', 'exists' and
+// 'not_exists' operators don't take any value. The 'value' is a single word or a single quoted text, where the quote is escaped by
+// doubling it. The 'and' operator binds stronger than the 'or' operator. Example:
+//
+//	filter[expression]=(status eq open or assignee eq 'John Doe') and priority gt 2
+//
+// The expression filters are combined with the other filters using the 'and' operator. The 'or' groups
+// are resolved into the primary field filters by listing the primary values of the matching resources, thus
+// each 'or' term costs an additional repository query. The group queries contain all the filters of the endpoint
+// scope, including the default, authorizer and soft delete filters, so that they match only the accessible resources.
+// The primary values of all the resources matched by a group are loaded into memory and sent back to the repository
+// as the 'in' filter - the groups matching large number of resources are costly. The depth of the nested groups
+// is limited by the Creator's MaxFilterExpressionDepth. The number of the resources matched by a group is not limited
+// unless the Creator's MaxFilterExpressionResources is set.
+const QueryParamFilterExpression = "filter[expression]"
+
+// filterGroup is the group of the filter expression terms joined by the same logical operator.
+// The 'or' groups contains only the 'and' groups.
+type filterGroup struct {
+	or      bool
+	filters []*query.FilterField
+	groups  []*filterGroup
+}
+
+type filterToken struct {
+	value    string
+	position int
+	quoted   bool
+}
+
+// is checks if the token is not quoted and matches the 'keyword'.
+func (t filterToken) is(keyword string) bool {
+	return !t.quoted && strings.EqualFold(t.value, keyword)
+}
+
+// queryParameterFilterExpression parses the filter expression 'value' for the scope 's'. The 'and' filters are
+// added directly to the scope and the 'or' groups are stored for the resolveFilterGroups.
+func (h *Creator) queryParameterFilterExpression(ctx context.Context, s *query.Scope, value string, o *endpointOptions) error {
+	tokens, err := tokenizeFilterExpression(value)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		err := errors.NewDet(handlerClass.QueryInvalidFilterExpression, "empty filter expression")
+		err.SetDetails("The filter expression is empty.")
+		return err
+	}
+	p := &filterExpressionParser{ctx: ctx, h: h, model: s.Struct(), o: o, tokens: tokens}
+	group, err := p.parseExpression(0)
+	if err != nil {
+		return err
+	}
+	if p.index < len(p.tokens) {
+		return p.tokenError(p.tokens[p.index], "unexpected token")
+	}
+
+	if group.or {
+		addFilterGroups(s, group)
+		return nil
+	}
+	for _, f := range group.filters {
+		if err = s.FilterField(f); err != nil {
+			return err
+		}
+	}
+	addFilterGroups(s, group.groups...)
+	return nil
+}
+
+// addFilterGroups stores the 'or' filter 'groups' within the scope 's'.
+func addFilterGroups(s *query.Scope, groups ...*filterGroup) {
+	if len(groups) == 0 {
+		return
+	}
+	if stored, ok := s.StoreGet(scopeFilterGroupsK); ok {
+		groups = append(stored.([]*filterGroup), groups...)
+	}
+	s.StoreSet(scopeFilterGroupsK, groups)
+}
+
+// resolveFilterGroups resolves the 'or' filter groups of the scope 's' into the primary field filters.
+// The groups are resolved within the scope's filters. Returns true if any of the groups doesn't match any resource.
+func (h *Creator) resolveFilterGroups(ctx context.Context, s *query.Scope) (bool, error) {
+	groups, ok := s.StoreGet(scopeFilterGroupsK)
+	if !ok {
+		return false, nil
+	}
+	for _, group := range groups.([]*filterGroup) {
+		primaries, err := h.resolveFilterGroup(ctx, s, group)
+		if err != nil {
+			return false, err
+		}
+		if len(primaries) == 0 {
+			return true, nil
+		}
+		if err = s.FilterField(query.NewFilter(s.Struct().Primary(), query.OpIn, primaries...)); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// resolveFilterGroup gets the primary field values of the 'root' scope resources that matches the filter 'group'.
+func (h *Creator) resolveFilterGroup(ctx context.Context, root *query.Scope, group *filterGroup) ([]interface{}, error) {
+	if !group.or {
+		return h.listPrimaries(ctx, root, group)
+	}
+	var primaries []interface{}
+	unique := map[interface{}]struct{}{}
+	for _, term := range group.groups {
+		termPrimaries, err := h.resolveFilterGroup(ctx, root, term)
+		if err != nil {
+			return nil, err
+		}
+		for _, primary := range termPrimaries {
+			if _, ok := unique[primary]; !ok {
+				unique[primary] = struct{}{}
+				primaries = append(primaries, primary)
+			}
+		}
+		if err = h.checkFilterGroupPrimaries(primaries); err != nil {
+			return nil, err
+		}
+	}
+	return primaries, nil
+}
+
+// checkFilterGroupPrimaries checks if the number of the filter group 'primaries' doesn't exceed
+// the MaxFilterExpressionResources.
+func (h *Creator) checkFilterGroupPrimaries(primaries []interface{}) error {
+	if h.MaxFilterExpressionResources <= 0 || len(primaries) <= h.MaxFilterExpressionResources {
+		return nil
+	}
+	err := errors.NewDetf(handlerClass.QueryInvalidFilterExpression, "filter expression group matches more than: %d resources", h.MaxFilterExpressionResources)
+	err.SetDetailsf("The filter expression 'or' group must not match more than: %d resources. Narrow the filter expression.", h.MaxFilterExpressionResources)
+	return err
+}
+
+// listPrimaries lists the primary field values of the 'root' scope resources that matches the 'and' filter 'group'.
+// The listing scope contains the copies of the 'root' scope filters.
+func (h *Creator) listPrimaries(ctx context.Context, root *query.Scope, group *filterGroup) ([]interface{}, error) {
+	model := root.Struct()
+	s := query.NewModelC(h.c, model, true)
+	if err := copyFilters(root, s); err != nil {
+		return nil, err
+	}
+	for _, f := range group.filters {
+		if err := s.FilterField(f.Copy()); err != nil {
+			return nil, err
+		}
+	}
+	for _, nested := range group.groups {
+		primaries, err := h.resolveFilterGroup(ctx, root, nested)
+		if err != nil || len(primaries) == 0 {
+			return nil, err
+		}
+		if err = s.FilterField(query.NewFilter(model.Primary(), query.OpIn, primaries...)); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.SetFields(model.Primary()); err != nil {
+		return nil, err
+	}
+	if h.MaxFilterExpressionResources > 0 {
+		// list at most one resource more than the maximum to find out if the group exceeds it.
+		if err := s.Limit(int64(h.MaxFilterExpressionResources)+1, 0); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.ListContext(ctx); err != nil {
+		if ce, ok := err.(errors.ClassError); ok && ce.Class() == class.QueryValueNoResult {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	values := reflect.ValueOf(s.Value).Elem()
+	primaries := make([]interface{}, 0, values.Len())
+	for i := 0; i < values.Len(); i++ {
+		single := values.Index(i)
+		if single.Kind() == reflect.Ptr {
+			if single.IsNil() {
+				continue
+			}
+			single = single.Elem()
+		}
+		primaries = append(primaries, single.FieldByIndex(model.Primary().ReflectField().Index).Interface())
+	}
+	log.Debug3f("[FILTER][%s] Filter group primaries: %v", model.Collection(), primaries)
+	if err := h.checkFilterGroupPrimaries(primaries); err != nil {
+		return nil, err
+	}
+	return primaries, nil
+}
+
+type filterExpressionParser struct {
+	ctx    context.Context
+	h      *Creator
+	model  *mapping.ModelStruct
+	o      *endpointOptions
+	tokens []filterToken
+	index  int
+}
+
+func (p *filterExpressionParser) parseExpression(depth int) (*filterGroup, error) {
+	term, err := p.parseTerm(depth)
+	if err != nil {
+		return nil, err
+	}
+	terms := []*filterGroup{term}
+	for p.index < len(p.tokens) && p.tokens[p.index].is("or") {
+		p.index++
+		if term, err = p.parseTerm(depth); err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 1 {
+		return term, nil
+	}
+	return &filterGroup{or: true, groups: terms}, nil
+}
+
+func (p *filterExpressionParser) parseTerm(depth int) (*filterGroup, error) {
+	group := &filterGroup{}
+	for {
+		token, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if token.is("(") {
+			if p.h.MaxFilterExpressionDepth > 0 && depth+1 > p.h.MaxFilterExpressionDepth {
+				return nil, p.tokenError(token, "filter expression is too deep")
+			}
+			nested, err := p.parseExpression(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			closing, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			if !closing.is(")") {
+				return nil, p.tokenError(closing, "expected closing parenthesis")
+			}
+			if nested.or {
+				group.groups = append(group.groups, nested)
+			} else {
+				group.filters = append(group.filters, nested.filters...)
+				group.groups = append(group.groups, nested.groups...)
+			}
+		} else {
+			f, err := p.parseComparison(token)
+			if err != nil {
+				return nil, err
+			}
+			group.filters = append(group.filters, f)
+		}
+
+		if p.index >= len(p.tokens) || !p.tokens[p.index].is("and") {
+			return group, nil
+		}
+		p.index++
+	}
+}
+
+func (p *filterExpressionParser) parseComparison(field filterToken) (*query.FilterField, error) {
+	if field.quoted || field.is(")") || field.is("and") || field.is("or") {
+		return nil, p.tokenError(field, "expected field name")
+	}
+	operatorToken, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	operator, ok := query.FilterOperators.Get("$" + strings.ToLower(operatorToken.value))
+	if operatorToken.quoted || !ok {
+		return nil, p.tokenError(operatorToken, "unknown filter operator")
+	}
+
+	var values []interface{}
+	switch operator {
+	case query.OpIsNull, query.OpNotNull, query.OpExists, query.OpNotExists:
+	default:
+		value, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if !value.quoted && (value.is("(") || value.is(")")) {
+			return nil, p.tokenError(value, "expected filter value")
+		}
+		values = append(values, value.value)
+	}
+
+	key := query.ParamFilter + "[" + p.model.Collection() + "][" + strings.Replace(field.value, annotation.NestedSeparator, "][", -1) + "][" + operator.Raw + "]"
+	f, err := query.NewStringFilter(p.h.c, key, values...)
+	if err == nil {
+		err = checkFilterable(p.o, "", f)
+	}
+	if err == nil {
+		err = p.h.checkReadableFilter(p.ctx, f)
+	}
+	if err == nil {
+		err = p.h.checkSoftDeleteFilter(f)
+	}
+	if err != nil {
+		if detailed, ok := err.(errors.DetailedError); ok {
+			detailed.WrapDetailsf("Invalid filter expression field: '%s' at position: %d.", field.value, field.position)
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// next gets the next token of the expression.
+func (p *filterExpressionParser) next() (filterToken, error) {
+	if p.index >= len(p.tokens) {
+		err := errors.NewDet(handlerClass.QueryInvalidFilterExpression, "unexpected end of the filter expression")
+		err.SetDetails("The filter expression ended unexpectedly.")
+		return filterToken{}, err
+	}
+	token := p.tokens[p.index]
+	p.index++
+	return token, nil
+}
+
+func (p *filterExpressionParser) tokenError(token filterToken, message string) error {
+	err := errors.NewDetf(handlerClass.QueryInvalidFilterExpression, "%s: '%s'", message, token.value)
+	err.SetDetailsf("Invalid filter expression token: '%s' at position: %d - %s.", token.value, token.position, message)
+	return err
+}
+
+// tokenizeFilterExpression splits the filter 'expression' into tokens.
+func tokenizeFilterExpression(expression string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(expression); {
+		switch c := expression[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, filterToken{value: string(c), position: i})
+			i++
+		case c == '\'':
+			start := i
+			var sb strings.Builder
+			for i++; ; i++ {
+				if i >= len(expression) {
+					err := errors.NewDet(handlerClass.QueryInvalidFilterExpression, "unterminated quoted value")
+					err.SetDetailsf("Unterminated quoted value at position: %d.", start)
+					return nil, err
+				}
+				if expression[i] == '\'' {
+					if i+1 < len(expression) && expression[i+1] == '\'' {
+						sb.WriteByte('\'')
+						i++
+						continue
+					}
+					i++
+					break
+				}
+				sb.WriteByte(expression[i])
+			}
+			tokens = append(tokens, filterToken{value: sb.String(), position: start, quoted: true})
+		default:
+			start := i
+			for i < len(expression) && !strings.ContainsRune(" \t\n\r()'", rune(expression[i])) {
+				i++
+			}
+			tokens = append(tokens, filterToken{value: expression[start:i], position: start})
+		}
+	}
+	return tokens, nil
+}
+
+var scopeFilterGroupsK scopeFilterGroups
+
+type scopeFilterGroups struct{}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+)
+
+// TestFilterExpression tests the list filter expression query parameter.
+func TestFilterExpression(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, expression string) *http.Request {
+		req, err := http.NewRequest("GET", "/houses?fields[houses]=address&filter%5Bexpression%5D="+url.QueryEscape(expression), nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req
+	}
+
+	repo, err := c.GetRepository(House{})
+	require.NoError(t, err)
+
+	housesRepo, ok := repo.(*mocks.Repository)
+	require.True(t, ok)
+
+	listHouses := func(t *testing.T, check func(s *query.Scope), houses ...*House) {
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			check(s)
+			v, ok := s.Value.(*[]*House)
+			require.True(t, ok)
+			*v = append(*v, houses...)
+		}).Return(nil)
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		h := NewC(c)
+		h.MaxFilterExpressionDepth = 1
+
+		for expression, detail := range map[string]string{
+			"address like Main":                  "'like' at position: 8",
+			"address eq 'Main":                   "position: 11",
+			"address eq Main and":                "ended unexpectedly",
+			"address eq Main) or id eq 1":        "')' at position: 15",
+			"((address eq Main))":                "'(' at position: 1",
+			"unknown eq 1":                       "'unknown' at position: 0",
+			"(address eq Main or id eq 1) id":    "'id' at position: 29",
+			"address eq Main or or address eq 1": "'or' at position: 19",
+		} {
+			resp := httptest.NewRecorder()
+			h.List(House{}).ServeHTTP(resp, newRequest(t, expression))
+			if assert.Equal(t, http.StatusBadRequest, resp.Code, expression) {
+				payload, err := jsonapi.UnmarshalErrors(resp.Body)
+				require.NoError(t, err)
+				if assert.Len(t, payload.Errors, 1) {
+					assert.Contains(t, payload.Errors[0].Detail, detail, expression)
+				}
+			}
+		}
+	})
+
+	t.Run("And", func(t *testing.T) {
+		h := NewC(c)
+		listHouses(t, func(s *query.Scope) {
+			assert.Len(t, s.AttributeFilters, 1)
+			assert.Len(t, s.PrimaryFilters, 1)
+		})
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "address eq 'Main Rd' AND id gt 1"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("Or", func(t *testing.T) {
+		h := NewC(c)
+		// the group queries contains the root scope filters.
+		listHouses(t, func(s *query.Scope) {
+			assert.Len(t, s.AttributeFilters, 2)
+		}, &House{ID: 1}, &House{ID: 2})
+		listHouses(t, func(s *query.Scope) {
+			assert.Len(t, s.PrimaryFilters, 1)
+			assert.Len(t, s.AttributeFilters, 1)
+		}, &House{ID: 2}, &House{ID: 3})
+		listHouses(t, func(s *query.Scope) {
+			if assert.Len(t, s.PrimaryFilters, 1) && assert.Len(t, s.PrimaryFilters[0].Values, 1) {
+				assert.Equal(t, query.OpIn, s.PrimaryFilters[0].Values[0].Operator)
+				assert.Equal(t, []interface{}{1, 2, 3}, s.PrimaryFilters[0].Values[0].Values)
+			}
+			assert.Len(t, s.AttributeFilters, 1)
+		}, &House{ID: 1, Address: "Main Rd"})
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "(address contains Main or id eq 2) and address ne Side"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("ScopeFilters", func(t *testing.T) {
+		h := NewC(c)
+		h.Authorizer = AuthorizerFunc(func(req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField, s *query.Scope) error {
+			owner, ok := model.ForeignKey("owner_id")
+			require.True(t, ok)
+			return s.FilterField(query.NewFilter(owner, query.OpEqual, 3))
+		})
+		// each group query is restricted by the authorizer filters.
+		for i := 0; i < 3; i++ {
+			listHouses(t, func(s *query.Scope) {
+				if assert.Len(t, s.ForeignFilters, 1) {
+					assert.Equal(t, 3, s.ForeignFilters[0].Values[0].Values[0])
+				}
+			}, &House{ID: 1})
+		}
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "address eq Main or address eq Side"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		housesRepo.AssertExpectations(t)
+	})
+
+	t.Run("UnlimitedDepth", func(t *testing.T) {
+		h := NewC(c)
+		h.MaxFilterExpressionDepth = 0
+		listHouses(t, func(s *query.Scope) {
+			assert.Len(t, s.AttributeFilters, 1)
+		})
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "(((address eq Main)))"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("NoResult", func(t *testing.T) {
+		h := NewC(c)
+		listHouses(t, func(s *query.Scope) {})
+		listHouses(t, func(s *query.Scope) {})
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "address eq Main or address eq Side"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		housesRepo.AssertExpectations(t)
+	})
+
+	t.Run("Valueless", func(t *testing.T) {
+		h := NewC(c)
+		listHouses(t, func(s *query.Scope) {
+			if assert.Len(t, s.AttributeFilters, 2) {
+				for i, operator := range []*query.Operator{query.OpNotNull, query.OpNotExists} {
+					if assert.Len(t, s.AttributeFilters[i].Values, 1) {
+						assert.Equal(t, operator, s.AttributeFilters[i].Values[0].Operator)
+						assert.Empty(t, s.AttributeFilters[i].Values[0].Values)
+					}
+				}
+			}
+		})
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "address not_null and address not_exists"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		// the operators are matched by the raw neuron operator names.
+		resp = httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "address isnull"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("TooManyResources", func(t *testing.T) {
+		h := NewC(c)
+		h.MaxFilterExpressionResources = 1
+		listHouses(t, func(s *query.Scope) {
+			// a single resource more than the maximum is listed.
+			if assert.NotNil(t, s.Pagination) {
+				assert.Equal(t, int64(2), s.Pagination.Size)
+			}
+		}, &House{ID: 1}, &House{ID: 2})
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "address eq Main or address eq Side"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		housesRepo.AssertExpectations(t)
+	})
+	t.Run("Unlimited", func(t *testing.T) {
+		h := NewC(c)
+		// the number of the resources matched by a group is not limited by default.
+		for i := 0; i < 2; i++ {
+			listHouses(t, func(s *query.Scope) {
+				assert.Nil(t, s.Pagination)
+			}, &House{ID: 1}, &House{ID: 2})
+		}
+		listHouses(t, func(s *query.Scope) {
+			if assert.Len(t, s.PrimaryFilters, 1) && assert.Len(t, s.PrimaryFilters[0].Values, 1) {
+				assert.Len(t, s.PrimaryFilters[0].Values[0].Values, 2)
+			}
+		}, &House{ID: 1}, &House{ID: 2})
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "address eq Main or address eq Side"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		housesRepo.AssertExpectations(t)
+	})
+
+	t.Run("Parameter", func(t *testing.T) {
+		h := NewC(c)
+		// the expression is not parsed from the bare 'filter' query parameter.
+		req, err := http.NewRequest("GET", "/houses?filter="+url.QueryEscape("address eq Main"), nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
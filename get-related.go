@@ -3,8 +3,8 @@ package handler
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"reflect"
-	"strings"
 
 	neuronErrors "github.com/neuronlabs/errors"
 	"github.com/neuronlabs/jsonapi"
@@ -31,6 +31,7 @@ func (h *Creator) GetRelated(model interface{}, field string, basePath ...string
 }
 
 // GetRelatedHandlers returns all handler functions for the JSONAPI 'GET RELATED' relationship fields for given model.
+// The relationship fields disallowed by the model's EndpointPolicy are skipped.
 func (h *Creator) GetRelatedHandlers(model interface{}, basePath ...string) map[*mapping.StructField]http.HandlerFunc {
 	mappedModel := h.c.MustGetModelStruct(model)
 	handlers := make(map[*mapping.StructField]http.HandlerFunc)
@@ -39,16 +40,29 @@ func (h *Creator) GetRelatedHandlers(model interface{}, basePath ...string) map[
 		bp = basePath[0]
 	}
 	for _, relation := range mappedModel.RelationFields() {
+		if !h.isEndpointAllowed(EndpointGetRelated, mappedModel, relation) {
+			continue
+		}
 		handlers[relation] = h.handleGetRelated(mappedModel, relation, bp)
 	}
 	return handlers
 }
 
 func (h *Creator) handleGetRelated(model *mapping.ModelStruct, field *mapping.StructField, basePath string) http.HandlerFunc {
+	h.registerEndpoint(EndpointGetRelated, model, field, basePath)
+
 	return func(rw http.ResponseWriter, req *http.Request) {
+		if h.endpointNotAllowed(rw, req, EndpointGetRelated, model, field) {
+			return
+		}
 		ctx := req.Context()
+		if !h.canRead(ctx, model, field) {
+			log.Debug2f("[GET-RELATED][%s] Reading field: '%s' is forbidden", model.Collection(), field.NeuronName())
+			h.marshalErrors(rw, req, 0, fieldForbiddenError(field, "Reading"))
+			return
+		}
 		// Check the URL 'id' value.
-		id := CtxMustGetID(ctx)
+		id := h.getID(req, model)
 		if id == "" {
 			log.Debugf("[GET-RELATED][%s] Empty id params", model.Collection())
 			err := errors.ErrBadRequest()
@@ -57,7 +71,7 @@ func (h *Creator) handleGetRelated(model *mapping.ModelStruct, field *mapping.St
 			return
 		}
 
-		idValue, err := model.Primary().ValueFromString(id)
+		idValues, err := h.decodeID(model, id)
 		if err != nil {
 			log.Debugf("[GET-RELATED][%s] Invalid URL id value: '%s': '%v'", model.Collection(), id, err)
 			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
@@ -66,20 +80,16 @@ func (h *Creator) handleGetRelated(model *mapping.ModelStruct, field *mapping.St
 
 		// check the fieldset for the relatedScope
 		relatedScope := query.NewModelC(h.c, field.Relationship().Struct(), field.Kind() == mapping.KindRelationshipMultiple)
-		for k, v := range req.URL.Query() {
-			if strings.HasPrefix(k, query.ParamFields) {
-				if err = h.queryParameterFields(relatedScope, k, v[0]); err != nil {
-					log.Debug2f("[GET-RELATED][%s] Setting related fieldset failed: %v", model.Collection(), err)
-					h.marshalErrors(rw, req, 0, errors.MapError(err)...)
-					return
-				}
-			}
+		if err = h.parseQuery(ctx, req, EndpointGetRelated, relatedScope, &endpointOptions{}); err != nil {
+			log.Debug2f("[GET-RELATED][%s] Parsing related query failed: %v", model.Collection(), err)
+			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
+			return
 		}
 
 		// Set preset filters
 		s := query.NewModelC(h.c, model, false)
 		// Set the primary field value.
-		if err = s.FilterField(query.NewFilter(model.Primary(), query.OpEqual, idValue)); err != nil {
+		if err = filterID(s, idValues); err != nil {
 			log.Errorf("[GET-RELATED][%s][%s] Adding param primary filter with value: '%s' failed: %v", model.Collection(), field.NeuronName(), id, err)
 			h.marshalErrors(rw, req, 0, errors.ErrInternalError())
 			return
@@ -91,6 +101,26 @@ func (h *Creator) handleGetRelated(model *mapping.ModelStruct, field *mapping.St
 			return
 		}
 
+		// exclude the soft deleted root and related resources.
+		if err = h.excludeSoftDeleted(s); err == nil {
+			err = h.excludeSoftDeleted(relatedScope)
+		}
+		if err != nil {
+			log.Errorf("[GET-RELATED][%s][%s] Adding soft deleted filters failed: %v", model.Collection(), field.NeuronName(), err)
+			h.marshalErrors(rw, req, 0, errors.ErrInternalError())
+			return
+		}
+
+		if errs := h.authorize(req, EndpointGetRelated, model, field, s); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
+		// authorize the related resources scope.
+		if errs := h.authorize(req, EndpointGetRelated, model, field, relatedScope); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
+
 		if err = s.GetContext(ctx); err != nil {
 			log.Debug("[GET-RELATED][SCOPE][%s] Getting /%s/%s root scope failed: %v", s.Struct().Collection(), id, err)
 			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
@@ -107,7 +137,7 @@ func (h *Creator) handleGetRelated(model *mapping.ModelStruct, field *mapping.St
 			Link: jsonapi.LinkOptions{
 				Type:         linkType,
 				BaseURL:      h.getBasePath(basePath),
-				RootID:       id,
+				RootID:       url.PathEscape(id),
 				Collection:   model.Collection(),
 				RelatedField: field.NeuronName(),
 			},
@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"context"
 	"net/http"
+	"net/url"
 	"reflect"
 
+	neuronErrors "github.com/neuronlabs/errors"
 	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/class"
 	"github.com/neuronlabs/neuron-core/mapping"
 	"github.com/neuronlabs/neuron-core/query"
 
@@ -24,7 +28,7 @@ func (h *Creator) GetRelationship(model interface{}, field string) http.HandlerF
 }
 
 // GetRelationShipHandlers returns mapping of 'model' relationship fields to related http.HandlerFunc
-// for the JSONAPI get relationship endpoints.
+// for the JSONAPI get relationship endpoints. The relationship fields disallowed by the model's EndpointPolicy are skipped.
 func (h *Creator) GetRelationShipHandlers(model interface{}, basePath ...string) map[*mapping.StructField]http.HandlerFunc {
 	mappedModel := h.c.MustGetModelStruct(model)
 	handlers := make(map[*mapping.StructField]http.HandlerFunc)
@@ -33,16 +37,33 @@ func (h *Creator) GetRelationShipHandlers(model interface{}, basePath ...string)
 		bp = basePath[0]
 	}
 	for _, relation := range mappedModel.RelationFields() {
+		if !h.isEndpointAllowed(EndpointGetRelationship, mappedModel, relation) {
+			continue
+		}
 		handlers[relation] = h.handleGetRelationship(mappedModel, relation, bp)
 	}
 	return handlers
 }
 
 func (h *Creator) handleGetRelationship(model *mapping.ModelStruct, field *mapping.StructField, basePath string) http.HandlerFunc {
+	h.registerEndpoint(EndpointGetRelationship, model, field, basePath)
+
 	return func(rw http.ResponseWriter, req *http.Request) {
+		if h.endpointNotAllowed(rw, req, EndpointGetRelationship, model, field) {
+			return
+		}
+		if err := h.parseQuery(req.Context(), req, EndpointGetRelationship, nil, &endpointOptions{}); err != nil {
+			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
+			return
+		}
 		ctx := req.Context()
+		if !h.canRead(ctx, model, field) {
+			log.Debug2f("[GET-RELATIONSHIP][%s] Reading field: '%s' is forbidden", model.Collection(), field.NeuronName())
+			h.marshalErrors(rw, req, 0, fieldForbiddenError(field, "Reading"))
+			return
+		}
 		// Check the URL 'id' value.
-		id := CtxMustGetID(ctx)
+		id := h.getID(req, model)
 		if id == "" {
 			log.Debugf("[GET-RELATIONSHIP][%s] Empty id params", model.Collection())
 			err := errors.ErrBadRequest()
@@ -51,7 +72,7 @@ func (h *Creator) handleGetRelationship(model *mapping.ModelStruct, field *mappi
 			return
 		}
 
-		idValue, err := model.Primary().ValueFromString(id)
+		idValues, err := h.decodeID(model, id)
 		if err != nil {
 			log.Debugf("[GET-RELATIONSHIP][%s] Invalid URL id value: '%s': '%v'", model.Collection(), id, err)
 			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
@@ -59,7 +80,7 @@ func (h *Creator) handleGetRelationship(model *mapping.ModelStruct, field *mappi
 		}
 
 		s := query.NewModelC(h.c, model, false)
-		if err = s.FilterField(query.NewFilter(model.Primary(), query.OpEqual, idValue)); err != nil {
+		if err = filterID(s, idValues); err != nil {
 			log.Errorf("[GET-RELATIONSHIP][SCOPE][%s] Adding param primary filter with value: '%s' failed: %v", s.ID(), id, err)
 			h.marshalErrors(rw, req, 0, errors.ErrInternalError())
 			return
@@ -70,6 +91,25 @@ func (h *Creator) handleGetRelationship(model *mapping.ModelStruct, field *mappi
 			h.marshalErrors(rw, req, 0, errors.ErrInternalError())
 			return
 		}
+		// exclude the soft deleted root and related resources.
+		relatedScope := query.NewModelC(h.c, field.Relationship().Struct(), true)
+		if err = h.excludeSoftDeleted(s); err == nil {
+			err = h.excludeSoftDeleted(relatedScope)
+		}
+		if err != nil {
+			log.Errorf("[GET-RELATIONSHIP][SCOPE][%s] Adding soft deleted filter failed: %v", s.ID(), err)
+			h.marshalErrors(rw, req, 0, errors.ErrInternalError())
+			return
+		}
+		if errs := h.authorize(req, EndpointGetRelationship, model, field, s); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
+		// authorize the related resources scope.
+		if errs := h.authorize(req, EndpointGetRelationship, model, field, relatedScope); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
 		if beforeGetHook, ok := Hooks.getHook(model, BeforeGetRelationship); ok {
 			if err := beforeGetHook(ctx, s); err != nil {
 				h.marshalErrors(rw, req, 0, errors.MapError(err)...)
@@ -97,6 +137,15 @@ func (h *Creator) handleGetRelationship(model *mapping.ModelStruct, field *mappi
 			fieldValue = fieldValue.Addr()
 		}
 
+		if !fieldValue.IsNil() && hasFilters(relatedScope) {
+			// only the related resources accessible within the related scope are marshaled.
+			if fieldValue, err = h.accessibleRelated(ctx, relatedScope, fieldValue); err != nil {
+				log.Debugf("[GET-RELATIONSHIP][SCOPE][%s] Listing accessible related resources failed: %v", relatedScope.ID(), err)
+				h.marshalErrors(rw, req, 0, errors.MapError(err)...)
+				return
+			}
+		}
+
 		var relationshipScope *query.Scope
 		if fieldValue.IsNil() {
 			relationshipScope = query.NewModelC(h.c, field.Relationship().Struct(), field.Kind() == mapping.KindRelationshipMultiple)
@@ -125,10 +174,77 @@ func (h *Creator) handleGetRelationship(model *mapping.ModelStruct, field *mappi
 		options := &jsonapi.MarshalOptions{Link: jsonapi.LinkOptions{
 			Type:         linkType,
 			BaseURL:      h.getBasePath(basePath),
-			RootID:       id,
+			RootID:       url.PathEscape(id),
 			Collection:   model.Collection(),
 			RelatedField: field.NeuronName(),
 		}}
 		h.marshalScope(relationshipScope, rw, req, http.StatusOK, options)
 	}
 }
+
+// accessibleRelated gets the related resources 'value' that are accessible within the 'relatedScope' filters.
+// The 'value' is the pointer to the single related resource or to the slice of related resources. If the single
+// related resource is not accessible the result is the nil pointer.
+func (h *Creator) accessibleRelated(ctx context.Context, relatedScope *query.Scope, value reflect.Value) (reflect.Value, error) {
+	primary := relatedScope.Struct().Primary()
+	elems := value.Elem()
+	if elems.Kind() != reflect.Slice {
+		elems = reflect.Append(reflect.MakeSlice(reflect.SliceOf(value.Type()), 0, 1), value)
+	}
+	var primaries []interface{}
+	for i := 0; i < elems.Len(); i++ {
+		if elem := reflect.Indirect(elems.Index(i)); elem.IsValid() {
+			primaries = append(primaries, elem.FieldByIndex(primary.ReflectField().Index).Interface())
+		}
+	}
+	accessible := map[interface{}]struct{}{}
+	if len(primaries) > 0 {
+		if err := relatedScope.FilterField(query.NewFilter(primary, query.OpIn, primaries...)); err != nil {
+			return value, err
+		}
+		if err := relatedScope.SetFieldset(primary); err != nil {
+			return value, err
+		}
+		if err := relatedScope.ListContext(ctx); err != nil {
+			if e, ok := err.(neuronErrors.ClassError); !ok || e.Class() != class.QueryValueNoResult {
+				return value, err
+			}
+		}
+		listed := reflect.ValueOf(relatedScope.Value).Elem()
+		for i := 0; i < listed.Len(); i++ {
+			if elem := reflect.Indirect(listed.Index(i)); elem.IsValid() {
+				accessible[elem.FieldByIndex(primary.ReflectField().Index).Interface()] = struct{}{}
+			}
+		}
+	}
+
+	isAccessible := func(elem reflect.Value) bool {
+		elem = reflect.Indirect(elem)
+		if !elem.IsValid() {
+			return false
+		}
+		_, ok := accessible[elem.FieldByIndex(primary.ReflectField().Index).Interface()]
+		return ok
+	}
+	if value.Elem().Kind() != reflect.Slice {
+		if isAccessible(value) {
+			return value, nil
+		}
+		return reflect.Zero(value.Type()), nil
+	}
+	result := reflect.MakeSlice(value.Elem().Type(), 0, elems.Len())
+	for i := 0; i < elems.Len(); i++ {
+		if isAccessible(elems.Index(i)) {
+			result = reflect.Append(result, elems.Index(i))
+		}
+	}
+	ptr := reflect.New(result.Type())
+	ptr.Elem().Set(result)
+	return ptr, nil
+}
+
+// hasFilters checks if the scope 's' contains any filter.
+func hasFilters(s *query.Scope) bool {
+	return len(s.PrimaryFilters)+len(s.AttributeFilters)+len(s.ForeignFilters)+len(s.RelationFilters)+
+		len(s.FilterKeyFilters) > 0 || s.LanguageFilters != nil
+}
@@ -2,11 +2,10 @@ package handler
 
 import (
 	"net/http"
-	"strings"
+	"net/url"
 
 	"github.com/neuronlabs/errors"
 	"github.com/neuronlabs/jsonapi"
-	"github.com/neuronlabs/neuron-core/annotation"
 	"github.com/neuronlabs/neuron-core/mapping"
 	"github.com/neuronlabs/neuron-core/query"
 
@@ -26,19 +25,32 @@ func (h *Creator) GetWith(model interface{}) *EndpointHandler {
 // Get returns JSONAPI Get http.HandlerFunc for given 'model'.
 func (h *Creator) Get(model interface{}) http.HandlerFunc {
 	mappedModel := h.c.MustGetModelStruct(model)
-	return h.handleGet(mappedModel, "")
+	return h.handleGet(mappedModel, &endpointOptions{})
 }
 
-func (h *Creator) handleGet(model *mapping.ModelStruct, basePath string) http.HandlerFunc {
+func (h *Creator) handleGet(model *mapping.ModelStruct, o *endpointOptions) http.HandlerFunc {
+	h.registerEndpoint(EndpointGet, model, nil, o.basePath)
 	return func(rw http.ResponseWriter, req *http.Request) {
+		if h.endpointNotAllowed(rw, req, EndpointGet, model, nil) {
+			return
+		}
 		ctx := req.Context()
-		s, err := h.createGetScope(req, model)
+		s, err := h.createGetScope(req, model, o)
 		if err != nil {
 			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
 			return
 		}
 		log.Debug3f("Fieldset: %v", s.Fieldset)
 
+		if errs := h.authorize(req, EndpointGet, model, nil, s); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
+		if errs := h.authorizeIncludes(req, EndpointGet, model, s); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
+
 		// execute the before patcher API hook if given model defines it.
 		if beforeGetHook, ok := Hooks.getHook(model, BeforeGet); ok {
 			if err = beforeGetHook(ctx, s); err != nil {
@@ -51,6 +63,10 @@ func (h *Creator) handleGet(model *mapping.ModelStruct, basePath string) http.Ha
 			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
 			return
 		}
+		if errs := h.countRelationships(req, EndpointGet, s, o); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
 
 		// execute the before patcher API hook if given model defines it.
 		if afterGetHook, ok := Hooks.getHook(model, AfterGet); ok {
@@ -60,10 +76,6 @@ func (h *Creator) handleGet(model *mapping.ModelStruct, basePath string) http.Ha
 			}
 		}
 
-		if basePath != "" {
-			basePath = h.basePath()
-		}
-
 		linkType := jsonapi.ResourceLink
 		// but if the config doesn't allow that - set 'jsonapi.NoLink'
 		if !h.MarshalLinks {
@@ -72,98 +84,40 @@ func (h *Creator) handleGet(model *mapping.ModelStruct, basePath string) http.Ha
 
 		options := &jsonapi.MarshalOptions{Link: jsonapi.LinkOptions{
 			Type:       linkType,
-			BaseURL:    basePath,
-			RootID:     CtxMustGetID(ctx),
+			BaseURL:    h.getBasePath(o.basePath),
+			RootID:     url.PathEscape(h.getID(req, model)),
 			Collection: model.Collection(),
 		}}
+		setDocumentMeta(s, o)
 		h.marshalScope(s, rw, req, http.StatusOK, options)
 	}
 }
 
-func (h *Creator) createGetScope(req *http.Request, model *mapping.ModelStruct) (*query.Scope, error) {
-	id := CtxMustGetID(req.Context())
+func (h *Creator) createGetScope(req *http.Request, model *mapping.ModelStruct, o *endpointOptions) (*query.Scope, error) {
+	id := h.getID(req, model)
 	if id == "" {
 		log.Errorf("ID value stored in the context is empty.")
 		err := errors.NewDet(class.QueryInvalidURL, "invalid 'id' url parameter")
 		err.SetDetails("Provided empty ID in query url")
 		return nil, err
 	}
-	// get the value from the string
-	idValue, err := model.Primary().ValueFromString(id)
+	// decode the id values from the string
+	idValues, err := h.decodeID(model, id)
 	if err != nil {
 		log.Debugf("[GET][%s] Invalid URL id value: '%s': '%v'", model.Collection(), id, err)
 		return nil, err
 	}
 
-	var multiErrors errors.MultiError
-
 	s := query.NewModelC(h.c, model, false)
-	q := req.URL.Query()
-
-	included, ok := q[query.ParamInclude]
-	if ok {
-		var splitIncludes []string
-		for _, includedQ := range included {
-			splitIncludes = append(splitIncludes, strings.Split(includedQ, annotation.Separator)...)
-		}
-		log.Debug2f("Including fields: %v", splitIncludes)
-		err := s.IncludeFields(splitIncludes...)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	languages, ok := q[query.ParamLanguage]
-	if ok {
-		err := h.queryParameterLanguage(s, languages[0])
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	if err = s.FilterField(query.NewFilter(model.Primary(), query.OpEqual, idValue)); err != nil {
+	if err = filterID(s, idValues); err != nil {
 		log.Errorf("Creating preset primary filter in GET request for model: '%s' failed: %v.", model.Collection(), err)
 		return nil, err
 	}
-
-	for key, values := range q {
-		if len(multiErrors) >= h.QueryErrorsLimit {
-			return nil, multiErrors
-		}
-
-		if len(values) > 1 {
-			err := errors.NewDetf(class.QueryInvalidParameter, "provided invalid query parameters")
-			err.SetDetailsf("The query parameter: '%s' used more than once.", key)
-			multiErrors = append(multiErrors, err)
-			continue
-		}
-
-		value := values[0]
-		var err error
-		switch {
-		case key == query.ParamInclude, key == query.ParamLanguage:
-			continue
-		case strings.HasPrefix(key, query.ParamFields):
-			err = h.queryParameterFields(s, key, value)
-		// case strings.HasPrefix(key, query.ParamFilter):
-		// 	err = h.queryParameterFilters(s, key, value)
-		case key == QueryParamLinks:
-			err = h.queryParameterLinks(s, key, value)
-		default:
-			err = h.defaultQueryParameter(key)
-		}
-
-		if err != nil {
-			if ce, ok := err.(errors.ClassError); ok {
-				multiErrors = append(multiErrors, ce)
-			} else {
-				return nil, err
-			}
-		}
+	if err = h.parseQuery(req.Context(), req, EndpointGet, s, o); err != nil {
+		return nil, err
 	}
-
-	if len(multiErrors) > 0 {
-		return s, multiErrors
+	if err = h.excludeSoftDeleted(s); err != nil {
+		return nil, err
 	}
 	return s, nil
 }
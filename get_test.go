@@ -347,6 +347,24 @@ func TestHandleGet(t *testing.T) {
 		require.Equal(t, http.StatusBadRequest, resp.Code)
 	})
 
+	t.Run("MissingID", func(t *testing.T) {
+		h := NewC(c)
+		h.NoPanicOnMissingID = true
+
+		req, err := http.NewRequest("GET", "/houses/1", nil)
+		require.NoError(t, err)
+
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+
+		resp := httptest.NewRecorder()
+		require.NotPanics(t, func() {
+			h.Get(House{}).ServeHTTP(resp, req)
+		})
+
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
 	t.Run("Links", func(t *testing.T) {
 		t.Run("Invalid", func(t *testing.T) {
 			h := NewC(c)
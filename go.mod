@@ -3,6 +3,7 @@ module github.com/neuronlabs/jsonapi-handler
 go 1.14
 
 require (
+	github.com/klauspost/compress v1.11.13
 	github.com/neuronlabs/brotli v1.0.1
 	github.com/neuronlabs/errors v1.2.0
 	github.com/neuronlabs/jsonapi v0.11.2
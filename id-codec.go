@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/neuronlabs/errors"
+	neuronClass "github.com/neuronlabs/neuron-core/class"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+
+	"github.com/neuronlabs/jsonapi-handler/errors/class"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// IDValue is the value of the field that identifies the resource.
+type IDValue struct {
+	Field *mapping.StructField
+	Value interface{}
+}
+
+// IDCodec is the interface used to encode and decode the url 'id' for given model.
+// It allows to use the resources identified by multiple fields (compound keys) or the primary values
+// with custom string encoding. The 'id' is the unescaped value of the url path segment - the same as stored
+// in the context by the CtxSetID function. The handlers path escape the encoded 'id' when writing the links.
+type IDCodec interface {
+	// DecodeID decodes the url 'id' into the values of the fields that identify the resource
+	// of the 'model'. For the models identified by a single primary field the result should contain only
+	// the primary field value.
+	DecodeID(model *mapping.ModelStruct, id string) ([]IDValue, error)
+	// EncodeID encodes the identifying fields of the 'value' model instance into the url 'id'.
+	EncodeID(model *mapping.ModelStruct, value interface{}) (string, error)
+}
+
+// RegisterIDCodec registers the 'codec' used by the handlers to decode and encode url 'id' of the 'model'.
+func (h *Creator) RegisterIDCodec(model interface{}, codec IDCodec) {
+	mappedModel := h.c.MustGetModelStruct(model)
+	h.idCodecs[mappedModel] = codec
+}
+
+// compile time check for the IDCodec interface.
+var _ IDCodec = PrimaryIDCodec{}
+
+// PrimaryIDCodec is the default IDCodec that decodes the url 'id' into the model's primary field value.
+type PrimaryIDCodec struct{}
+
+// DecodeID implements IDCodec interface.
+func (PrimaryIDCodec) DecodeID(model *mapping.ModelStruct, id string) ([]IDValue, error) {
+	value, err := model.Primary().ValueFromString(id)
+	if err != nil {
+		return nil, err
+	}
+	return []IDValue{{Field: model.Primary(), Value: value}}, nil
+}
+
+// EncodeID implements IDCodec interface.
+func (PrimaryIDCodec) EncodeID(model *mapping.ModelStruct, value interface{}) (string, error) {
+	fieldValue, err := modelFieldValue(value, model.Primary())
+	if err != nil {
+		return "", err
+	}
+	return mapping.StringValues(fieldValue, nil)[0], nil
+}
+
+// compile time check for the IDCodec interface.
+var _ IDCodec = CompoundIDCodec{}
+
+// CompoundIDCodec is the IDCodec for the resources identified by multiple fields. The url 'id' segment
+// is the Separator joined string values of the Fields in given order i.e. for the Fields: 'tenant_id', 'id'
+// and the default Separator the url might look like: '/invoices/acme~12'. The '%' and the Separator characters
+// within the field values are percent-encoded i.e. the tenant: 'a~b' is encoded as: 'a%7Eb~12'.
+// The 'id' is split by the Separator before its values are unescaped.
+type CompoundIDCodec struct {
+	// Fields are the neuron names of the primary, attribute or foreign key fields that identify the resource.
+	Fields []string
+	// Separator is the separator of the field values. If empty the '~' is used.
+	Separator string
+}
+
+// DecodeID implements IDCodec interface.
+func (c CompoundIDCodec) DecodeID(model *mapping.ModelStruct, id string) ([]IDValue, error) {
+	parts := strings.Split(id, c.separator())
+	if len(parts) != len(c.Fields) {
+		err := errors.NewDet(class.QueryInvalidURL, "invalid compound 'id' url parameter")
+		err.SetDetailsf("Provided 'id' should consist of: %d values separated by: '%s'", len(c.Fields), c.separator())
+		return nil, err
+	}
+	values := make([]IDValue, len(parts))
+	for i, part := range parts {
+		field, err := c.field(model, i)
+		if err != nil {
+			return nil, err
+		}
+		part, err = url.PathUnescape(part)
+		if err != nil {
+			err := errors.NewDet(class.QueryInvalidURL, "invalid compound 'id' url parameter")
+			err.SetDetailsf("Provided 'id' value: '%s' is not properly escaped", id)
+			return nil, err
+		}
+		value, err := field.ValueFromString(part)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = IDValue{Field: field, Value: value}
+	}
+	return values, nil
+}
+
+// EncodeID implements IDCodec interface.
+func (c CompoundIDCodec) EncodeID(model *mapping.ModelStruct, value interface{}) (string, error) {
+	parts := make([]string, len(c.Fields))
+	for i := range c.Fields {
+		field, err := c.field(model, i)
+		if err != nil {
+			return "", err
+		}
+		fieldValue, err := modelFieldValue(value, field)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = c.escape(mapping.StringValues(fieldValue, nil)[0])
+	}
+	return strings.Join(parts, c.separator()), nil
+}
+
+// escape percent-encodes the '%' and the separator characters of the 'value'.
+func (c CompoundIDCodec) escape(value string) string {
+	separator := c.separator() + "%"
+	if !strings.ContainsAny(value, separator) {
+		return value
+	}
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if strings.IndexByte(separator, value[i]) != -1 {
+			fmt.Fprintf(&sb, "%%%02X", value[i])
+			continue
+		}
+		sb.WriteByte(value[i])
+	}
+	return sb.String()
+}
+
+func (c CompoundIDCodec) field(model *mapping.ModelStruct, i int) (*mapping.StructField, error) {
+	field, ok := model.Field(c.Fields[i])
+	if !ok || field.IsRelationship() {
+		log.Errorf("Compound id field: '%s' not found within model: '%s'", c.Fields[i], model.Collection())
+		return nil, errors.NewDetf(class.QueryInvalidURL, "compound id field: '%s' not found", c.Fields[i])
+	}
+	return field, nil
+}
+
+func (c CompoundIDCodec) separator() string {
+	if c.Separator == "" {
+		return "~"
+	}
+	return c.Separator
+}
+
+// idCodec gets the IDCodec registered for the 'model'.
+func (h *Creator) idCodec(model *mapping.ModelStruct) IDCodec {
+	if codec, ok := h.idCodecs[model]; ok {
+		return codec
+	}
+	return PrimaryIDCodec{}
+}
+
+// decodeID decodes the url 'id' for the 'model'.
+func (h *Creator) decodeID(model *mapping.ModelStruct, id string) ([]IDValue, error) {
+	return h.idCodec(model).DecodeID(model, id)
+}
+
+// encodeID encodes the url 'id' of the model instance 'value'.
+func (h *Creator) encodeID(model *mapping.ModelStruct, value interface{}) (string, error) {
+	return h.idCodec(model).EncodeID(model, value)
+}
+
+// filterID adds the 'id' values filters to the 's' scope.
+func filterID(s *query.Scope, id []IDValue) error {
+	for _, v := range id {
+		if err := s.FilterField(query.NewFilter(v.Field, query.OpEqual, v.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conflictingIDValue gets the 'id' value that doesn't match the scope 's' value. The primary field is always
+// compared, where the other 'id' fields only if they're set within the scope's fieldset.
+// The second returned value is the conflicting scope's field value.
+func conflictingIDValue(s *query.Scope, id []IDValue) (IDValue, interface{}, bool) {
+	for _, v := range id {
+		if _, ok := s.Fieldset[v.Field.NeuronName()]; !ok && !v.Field.IsPrimary() {
+			continue
+		}
+		value, err := modelFieldValue(s.Value, v.Field)
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(value, v.Value) {
+			return v, value, true
+		}
+	}
+	return IDValue{}, nil, false
+}
+
+func modelFieldValue(value interface{}, field *mapping.StructField) (interface{}, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, errors.NewDetf(neuronClass.ModelValueNil, "invalid model value: '%T'", value)
+	}
+	return v.Elem().FieldByIndex(field.ReflectField().Index).Interface(), nil
+}
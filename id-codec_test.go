@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+)
+
+// TestIDCodec tests the IDCodec implementations.
+func TestIDCodec(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{})
+	require.NoError(t, err)
+
+	model := c.MustGetModelStruct(House{})
+
+	t.Run("Primary", func(t *testing.T) {
+		values, err := PrimaryIDCodec{}.DecodeID(model, "12")
+		require.NoError(t, err)
+
+		if assert.Len(t, values, 1) {
+			assert.Equal(t, model.Primary(), values[0].Field)
+			assert.Equal(t, 12, values[0].Value)
+		}
+
+		_, err = PrimaryIDCodec{}.DecodeID(model, "invalid")
+		assert.Error(t, err)
+
+		id, err := PrimaryIDCodec{}.EncodeID(model, &House{ID: 12})
+		require.NoError(t, err)
+		assert.Equal(t, "12", id)
+	})
+
+	t.Run("Compound", func(t *testing.T) {
+		codec := CompoundIDCodec{Fields: []string{"owner_id", "id"}}
+
+		values, err := codec.DecodeID(model, "3~12")
+		require.NoError(t, err)
+
+		if assert.Len(t, values, 2) {
+			assert.Equal(t, "owner_id", values[0].Field.NeuronName())
+			assert.Equal(t, 3, values[0].Value)
+			assert.Equal(t, model.Primary(), values[1].Field)
+			assert.Equal(t, 12, values[1].Value)
+		}
+
+		_, err = codec.DecodeID(model, "12")
+		assert.Error(t, err)
+
+		id, err := codec.EncodeID(model, &House{ID: 12, OwnerID: 3})
+		require.NoError(t, err)
+		assert.Equal(t, "3~12", id)
+	})
+
+	t.Run("CompoundEscaped", func(t *testing.T) {
+		codec := CompoundIDCodec{Fields: []string{"address", "id"}}
+
+		id, err := codec.EncodeID(model, &House{ID: 12, Address: "a~b%c"})
+		require.NoError(t, err)
+		assert.Equal(t, "a%7Eb%25c~12", id)
+
+		values, err := codec.DecodeID(model, id)
+		require.NoError(t, err)
+		if assert.Len(t, values, 2) {
+			assert.Equal(t, "a~b%c", values[0].Value)
+			assert.Equal(t, 12, values[1].Value)
+		}
+
+		_, err = codec.DecodeID(model, "a%zz~12")
+		assert.Error(t, err)
+	})
+
+	t.Run("PatchConflict", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterIDCodec(House{}, CompoundIDCodec{Fields: []string{"owner_id", "address"}})
+
+		req, err := http.NewRequest("PATCH", "/houses/3~Main", strings.NewReader(`{"data":{"type":"houses","id":"12","attributes":{"address":"Other"}}}`))
+		require.NoError(t, err)
+
+		req.Header.Add("Content-Type", jsonapi.MediaType)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		req = req.WithContext(CtxSetID(req.Context(), "3~Main"))
+
+		resp := httptest.NewRecorder()
+		h.Patch(House{}).ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+		assert.Contains(t, resp.Body.String(), "field: 'address'")
+	})
+
+	t.Run("Handler", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterIDCodec(House{}, CompoundIDCodec{Fields: []string{"owner_id", "id"}, Separator: ":"})
+
+		req, err := http.NewRequest("DELETE", "/houses/3:12", nil)
+		require.NoError(t, err)
+
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req = req.WithContext(CtxSetID(req.Context(), "3:12"))
+
+		repo, err := c.GetRepository(House{})
+		require.NoError(t, err)
+
+		housesRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		housesRepo.On("Begin", mock.Anything, mock.Anything).Once().Return(nil)
+		// the compound id filters are reduced to the primary filters by the list query.
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if assert.Len(t, s.PrimaryFilters, 1) {
+				assert.Equal(t, 12, s.PrimaryFilters[0].Values[0].Values[0])
+			}
+			if assert.Len(t, s.ForeignFilters, 1) {
+				assert.Equal(t, 3, s.ForeignFilters[0].Values[0].Values[0])
+			}
+			v, ok := s.Value.(*[]*House)
+			require.True(t, ok)
+			*v = append(*v, &House{ID: 12})
+		}).Return(nil)
+		housesRepo.On("Delete", mock.Anything, mock.Anything).Once().Return(nil)
+		housesRepo.On("Commit", mock.Anything, mock.Anything).Once().Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.Delete(House{}).ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+	})
+	t.Run("RoundTrip", func(t *testing.T) {
+		h := NewC(c)
+		h.MarshalLinks = true
+		h.IDExtractor = PathSuffixIDExtractor{}
+		h.RegisterIDCodec(House{}, CompoundIDCodec{Fields: []string{"address", "id"}})
+
+		repo, err := c.GetRepository(House{})
+		require.NoError(t, err)
+
+		housesRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		req, err := http.NewRequest("POST", "/houses", strings.NewReader(`{"data":{"type":"houses","id":"12","attributes":{"address":"a~b c"}}}`))
+		require.NoError(t, err)
+
+		req.Header.Add("Content-Type", jsonapi.MediaType)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+
+		housesRepo.On("Begin", mock.Anything, mock.Anything).Once().Return(nil)
+		housesRepo.On("Create", mock.Anything, mock.Anything).Once().Return(nil)
+		housesRepo.On("Commit", mock.Anything, mock.Anything).Once().Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.Create(House{}).ServeHTTP(resp, req)
+		require.Equal(t, http.StatusCreated, resp.Code)
+
+		document := struct {
+			Links struct {
+				Self string `json:"self"`
+			} `json:"links"`
+		}{}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &document))
+		assert.Equal(t, "/houses/a%257Eb%20c~12", document.Links.Self)
+
+		// the link sent back to the handler must identify the created resource.
+		req, err = http.NewRequest("GET", document.Links.Self, nil)
+		require.NoError(t, err)
+
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+
+		housesRepo.On("Get", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if assert.Len(t, s.PrimaryFilters, 1) {
+				assert.Equal(t, 12, s.PrimaryFilters[0].Values[0].Values[0])
+			}
+			if assert.Len(t, s.AttributeFilters, 1) {
+				assert.Equal(t, "a~b c", s.AttributeFilters[0].Values[0].Values[0])
+			}
+			v, ok := s.Value.(*House)
+			require.True(t, ok)
+
+			v.ID = 12
+			v.Address = "a~b c"
+		}).Return(nil)
+
+		resp = httptest.NewRecorder()
+		h.Get(House{}).ServeHTTP(resp, req)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &document))
+		assert.Equal(t, "/houses/a%257Eb%20c~12", document.Links.Self)
+	})
+
+	t.Run("ContextID", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterIDCodec(House{}, CompoundIDCodec{Fields: []string{"address", "id"}})
+
+		repo, err := c.GetRepository(House{})
+		require.NoError(t, err)
+
+		housesRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		// the context id is already unescaped by the router - it is not unescaped again.
+		housesRepo.On("Get", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if assert.Len(t, s.AttributeFilters, 1) {
+				assert.Equal(t, "100% b", s.AttributeFilters[0].Values[0].Values[0])
+			}
+			v, ok := s.Value.(*House)
+			require.True(t, ok)
+
+			v.ID = 12
+			v.Address = "100% b"
+		}).Return(nil)
+
+		req, err := http.NewRequest("GET", "/houses/100%2525%20b~12", nil)
+		require.NoError(t, err)
+
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		req = req.WithContext(CtxSetID(req.Context(), "100%25 b~12"))
+
+		resp := httptest.NewRecorder()
+		h.Get(House{}).ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		housesRepo.AssertExpectations(t)
+	})
+}
@@ -2,6 +2,11 @@ package handler
 
 import (
 	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/neuronlabs/neuron-core/mapping"
 )
 
 type idKeyStruct struct{}
@@ -10,7 +15,7 @@ type idKeyStruct struct{}
 var IDKey = &idKeyStruct{}
 
 // CtxMustGetID gets ID from the context or panics if no id is found there.
-// ID should be in the raw string form.
+// ID should be the unescaped value of the url path segment.
 func CtxMustGetID(ctx context.Context) string {
 	id, ok := ctx.Value(IDKey).(string)
 	if !ok {
@@ -19,7 +24,124 @@ func CtxMustGetID(ctx context.Context) string {
 	return id
 }
 
+// CtxGetID gets ID from the context. The second returned value is false if no id is found there.
+func CtxGetID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(IDKey).(string)
+	return id, ok
+}
+
 // CtxSetID sets the 'id' value in the given 'ctx' and returns it.
 func CtxSetID(ctx context.Context, id string) context.Context {
 	return context.WithValue(ctx, IDKey, id)
 }
+
+// IDExtractor is the interface used by the handlers to get the 'id' value of the 'model' from the request.
+// It allows to mount the handlers on any router without the need of setting the 'id' into the request context.
+// The 'id' is the unescaped value of the url path segment - the extractors that read the raw path unescape it.
+type IDExtractor interface {
+	// ExtractID gets the unescaped 'id' value from the 'req' request for provided 'model'.
+	// The second returned value is false if no id is found.
+	ExtractID(req *http.Request, model *mapping.ModelStruct) (string, bool)
+}
+
+// IDExtractorFunc is the function that implements IDExtractor interface.
+type IDExtractorFunc func(req *http.Request, model *mapping.ModelStruct) (string, bool)
+
+// ExtractID implements IDExtractor interface.
+func (f IDExtractorFunc) ExtractID(req *http.Request, model *mapping.ModelStruct) (string, bool) {
+	return f(req, model)
+}
+
+// compile time check for the IDExtractor interface.
+var _ IDExtractor = ContextIDExtractor{}
+
+// ContextIDExtractor is the IDExtractor that gets the 'id' from the request context stored under the 'Key'.
+// If the 'Key' is nil, the IDKey is used. The value stored in the context must be a string with the unescaped
+// path segment. It might be used with the routers that stores the url parameters within the request context.
+type ContextIDExtractor struct {
+	Key interface{}
+}
+
+// ExtractID implements IDExtractor interface.
+func (c ContextIDExtractor) ExtractID(req *http.Request, _ *mapping.ModelStruct) (string, bool) {
+	key := c.Key
+	if key == nil {
+		key = IDKey
+	}
+	id, ok := req.Context().Value(key).(string)
+	return id, ok
+}
+
+// compile time check for the IDExtractor interface.
+var _ IDExtractor = PathValueIDExtractor{}
+
+// PathValueIDExtractor is the IDExtractor that gets the 'id' from the http.Request PathValue with the 'Name'
+// wildcard i.e.: for the http.ServeMux pattern 'GET /houses/{id}'. If the 'Name' is empty, the "id" is used.
+// The request PathValue method is available since go 1.22. For older versions no id is found.
+// The http.ServeMux unescapes the path values, thus the value is returned as is.
+type PathValueIDExtractor struct {
+	Name string
+}
+
+type pathValuer interface {
+	PathValue(name string) string
+}
+
+// ExtractID implements IDExtractor interface.
+func (p PathValueIDExtractor) ExtractID(req *http.Request, _ *mapping.ModelStruct) (string, bool) {
+	pv, ok := interface{}(req).(pathValuer)
+	if !ok {
+		return "", false
+	}
+	name := p.Name
+	if name == "" {
+		name = "id"
+	}
+	id := pv.PathValue(name)
+	return id, id != ""
+}
+
+// compile time check for the IDExtractor interface.
+var _ IDExtractor = PathSuffixIDExtractor{}
+
+// PathSuffixIDExtractor is the IDExtractor that parses the request url path. The 'id' is the path segment
+// that follows the last model's collection segment i.e.:
+//   - /houses/1
+//   - /api/v1/houses/1/owner
+//   - /houses/1/relationships/owner
+//
+// The 'id' path segment is unescaped. The segments that are not properly escaped are not found.
+type PathSuffixIDExtractor struct{}
+
+// ExtractID implements IDExtractor interface.
+func (PathSuffixIDExtractor) ExtractID(req *http.Request, model *mapping.ModelStruct) (string, bool) {
+	// the escaped path is split so that the encoded slashes don't separate the segments.
+	segments := strings.Split(strings.Trim(req.URL.EscapedPath(), "/"), "/")
+	for i := len(segments) - 2; i >= 0; i-- {
+		if segments[i] != model.Collection() {
+			continue
+		}
+		id, err := url.PathUnescape(segments[i+1])
+		if err != nil || id == "" {
+			return "", false
+		}
+		return id, true
+	}
+	return "", false
+}
+
+// getID gets the 'id' value for the 'model' from provided request. If the Creator's IDExtractor is set,
+// it is used to get the 'id'. Otherwise the 'id' is taken from the request context. If no 'id' is found
+// and the IDExtractor is set or the NoPanicOnMissingID is true, the function returns an empty string.
+// Otherwise it panics.
+func (h *Creator) getID(req *http.Request, model *mapping.ModelStruct) string {
+	if h.IDExtractor != nil {
+		id, _ := h.IDExtractor.ExtractID(req, model)
+		return id
+	}
+	if h.NoPanicOnMissingID {
+		id, _ := CtxGetID(req.Context())
+		return id
+	}
+	return CtxMustGetID(req.Context())
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/mapping"
+	mocks "github.com/neuronlabs/neuron-mocks"
+)
+
+// TestIDExtractors tests the IDExtractor implementations.
+func TestIDExtractors(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{})
+	require.NoError(t, err)
+
+	model := c.MustGetModelStruct(House{})
+
+	t.Run("Context", func(t *testing.T) {
+		type customKey struct{}
+
+		req, err := http.NewRequest("GET", "/houses/1", nil)
+		require.NoError(t, err)
+
+		_, ok := ContextIDExtractor{}.ExtractID(req, model)
+		assert.False(t, ok)
+
+		req = req.WithContext(context.WithValue(CtxSetID(req.Context(), "1"), customKey{}, "2"))
+		id, ok := ContextIDExtractor{}.ExtractID(req, model)
+		if assert.True(t, ok) {
+			assert.Equal(t, "1", id)
+		}
+
+		id, ok = ContextIDExtractor{Key: customKey{}}.ExtractID(req, model)
+		if assert.True(t, ok) {
+			assert.Equal(t, "2", id)
+		}
+	})
+
+	t.Run("PathSuffix", func(t *testing.T) {
+		paths := map[string]string{
+			"/houses/1":                         "1",
+			"/api/v1/houses/2/":                 "2",
+			"/houses/3/owner":                   "3",
+			"/api/houses/4/relationships/owner": "4",
+		}
+		for path, expected := range paths {
+			req, err := http.NewRequest("GET", path, nil)
+			require.NoError(t, err)
+
+			id, ok := PathSuffixIDExtractor{}.ExtractID(req, model)
+			if assert.True(t, ok, path) {
+				assert.Equal(t, expected, id)
+			}
+		}
+
+		req, err := http.NewRequest("GET", "/humans/1", nil)
+		require.NoError(t, err)
+
+		_, ok := PathSuffixIDExtractor{}.ExtractID(req, model)
+		assert.False(t, ok)
+
+		// the escaped segments are unescaped and the encoded slashes don't split the id.
+		req, err = http.NewRequest("GET", "/houses/a%2Fb%20c/owner", nil)
+		require.NoError(t, err)
+
+		id, ok := PathSuffixIDExtractor{}.ExtractID(req, model)
+		if assert.True(t, ok) {
+			assert.Equal(t, "a/b c", id)
+		}
+	})
+
+	t.Run("PathValue", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/houses/1", nil)
+		require.NoError(t, err)
+
+		_, ok := PathValueIDExtractor{}.ExtractID(req, model)
+		assert.False(t, ok)
+
+		setter, ok := interface{}(req).(interface{ SetPathValue(name, value string) })
+		if !ok {
+			t.Skip("http.Request doesn't implement PathValue")
+		}
+		setter.SetPathValue("id", "1")
+		setter.SetPathValue("house", "2")
+
+		id, ok := PathValueIDExtractor{}.ExtractID(req, model)
+		if assert.True(t, ok) {
+			assert.Equal(t, "1", id)
+		}
+
+		id, ok = PathValueIDExtractor{Name: "house"}.ExtractID(req, model)
+		if assert.True(t, ok) {
+			assert.Equal(t, "2", id)
+		}
+
+		// the path value unescaped by the router is returned as is.
+		req, err = http.NewRequest("GET", "/houses/a%257Eb~12", nil)
+		require.NoError(t, err)
+
+		setter = interface{}(req).(interface{ SetPathValue(name, value string) })
+		setter.SetPathValue("id", "a%7Eb~12")
+
+		id, ok = PathValueIDExtractor{}.ExtractID(req, model)
+		if assert.True(t, ok) {
+			assert.Equal(t, "a%7Eb~12", id)
+		}
+	})
+
+	t.Run("Handler", func(t *testing.T) {
+		h := NewC(c)
+		h.IDExtractor = IDExtractorFunc(func(req *http.Request, model *mapping.ModelStruct) (string, bool) {
+			return "", false
+		})
+
+		req, err := http.NewRequest("DELETE", "/houses/1", nil)
+		require.NoError(t, err)
+
+		resp := httptest.NewRecorder()
+		h.Delete(House{}).ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
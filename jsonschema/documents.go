@@ -0,0 +1,264 @@
+package jsonschema
+
+import (
+	"github.com/neuronlabs/neuron-core/mapping"
+)
+
+// DocumentType is the type of the JSONAPI document.
+type DocumentType int
+
+// Enumerated document types.
+const (
+	// CreateDocument is the request document of the create endpoint.
+	CreateDocument DocumentType = iota
+	// UpdateDocument is the request document of the patch endpoint.
+	UpdateDocument
+	// ResponseDocument is the single resource response document.
+	ResponseDocument
+	// CollectionDocument is the multiple resources response document.
+	CollectionDocument
+)
+
+// DocumentTypes are all the document types in the order of their definition.
+var DocumentTypes = []DocumentType{CreateDocument, UpdateDocument, ResponseDocument, CollectionDocument}
+
+// String implements fmt.Stringer interface.
+func (d DocumentType) String() string {
+	switch d {
+	case CreateDocument:
+		return "create"
+	case UpdateDocument:
+		return "update"
+	case ResponseDocument:
+		return "response"
+	case CollectionDocument:
+		return "collection"
+	}
+	return "unknown"
+}
+
+// Options are the options for the generated document schemas.
+type Options struct {
+	// BaseID is the base URI of the schemas '$id'. If not empty the '$id' of the schema is the
+	// BaseID joined with the schema file name.
+	BaseID string
+	// Required gets the neuron names of the attributes required within the create document of the 'model'.
+	// In order to derive them from the validations registered within the handler Creator use its
+	// RequiredAttributes method.
+	Required func(model *mapping.ModelStruct) []string
+}
+
+// Document gets the JSON Schema of the 'model' document of given type 'd'.
+func Document(model *mapping.ModelStruct, d DocumentType, o *Options) *Schema {
+	if o == nil {
+		o = &Options{}
+	}
+	var schema *Schema
+	switch d {
+	case CreateDocument:
+		schema = createDocument(model, o)
+	case UpdateDocument:
+		schema = updateDocument(model)
+	case ResponseDocument:
+		schema = responseDocument(model)
+	case CollectionDocument:
+		schema = collectionDocument(model)
+	default:
+		return nil
+	}
+	schema.Schema = Draft
+	schema.Title = model.Collection() + " " + d.String() + " document"
+	if o.BaseID != "" {
+		schema.ID = o.BaseID + FileName(model, d)
+	}
+	return schema
+}
+
+// FileName gets the file name of the 'model' document schema of the type 'd'.
+func FileName(model *mapping.ModelStruct, d DocumentType) string {
+	return model.Collection() + "." + d.String() + ".schema.json"
+}
+
+func createDocument(model *mapping.ModelStruct, o *Options) *Schema {
+	attributes := AttributesSchema(model)
+	if o.Required != nil {
+		attributes.Required = o.Required(model)
+	}
+	data := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"type":          {Type: "string", Const: model.Collection()},
+			"attributes":    attributes,
+			"relationships": RelationshipsSchema(model, false),
+			"meta":          MetaSchema(),
+		},
+		Required:             []string{"type"},
+		AdditionalProperties: false,
+	}
+	if len(attributes.Required) > 0 {
+		data.Required = append(data.Required, "attributes")
+	}
+	// the client generated identifiers are allowed only if the model allows it.
+	if model.AllowClientID() {
+		data.Properties["id"] = &Schema{Type: "string"}
+	}
+	return &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"data": data, "meta": MetaSchema()},
+		Required:   []string{"data"},
+	}
+}
+
+func updateDocument(model *mapping.ModelStruct) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"data": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"type":          {Type: "string", Const: model.Collection()},
+					"id":            {Type: "string"},
+					"attributes":    AttributesSchema(model),
+					"relationships": RelationshipsSchema(model, false),
+					"meta":          MetaSchema(),
+				},
+				Required:             []string{"type", "id"},
+				AdditionalProperties: false,
+			},
+			"meta": MetaSchema(),
+		},
+		Required: []string{"data"},
+	}
+}
+
+func responseDocument(model *mapping.ModelStruct) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"data":     {OneOf: []*Schema{resourceSchema(model), {Type: "null"}}},
+			"included": {Type: "array", Items: includedSchema()},
+			"links":    LinksSchema(),
+			"meta":     MetaSchema(),
+		},
+		Required: []string{"data"},
+	}
+}
+
+func collectionDocument(model *mapping.ModelStruct) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"data":     {Type: "array", Items: resourceSchema(model)},
+			"included": {Type: "array", Items: includedSchema()},
+			"links":    LinksSchema(),
+			"meta":     MetaSchema(),
+		},
+		Required: []string{"data"},
+	}
+}
+
+func resourceSchema(model *mapping.ModelStruct) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"type":          {Type: "string", Const: model.Collection()},
+			"id":            {Type: "string"},
+			"attributes":    AttributesSchema(model),
+			"relationships": RelationshipsSchema(model, true),
+			"links":         LinksSchema(),
+			"meta":          MetaSchema(),
+		},
+		Required: []string{"type", "id"},
+	}
+}
+
+func includedSchema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"type":          {Type: "string"},
+			"id":            {Type: "string"},
+			"attributes":    {Type: "object"},
+			"relationships": {Type: "object"},
+			"links":         LinksSchema(),
+			"meta":          MetaSchema(),
+		},
+		Required: []string{"type", "id"},
+	}
+}
+
+// AttributesSchema gets the attributes object schema of the 'model'. The hidden attributes are omitted.
+func AttributesSchema(model *mapping.ModelStruct) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+	for _, attr := range model.Attributes() {
+		if attr.IsHidden() {
+			continue
+		}
+		schema.Properties[attr.NeuronName()] = FieldSchema(attr)
+	}
+	return schema
+}
+
+// RelationshipsSchema gets the relationships object schema. The response relationships might
+// contain links and might not contain the resource linkage.
+func RelationshipsSchema(model *mapping.ModelStruct, response bool) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+	for _, relation := range model.RelationFields() {
+		if relation.IsHidden() {
+			continue
+		}
+		relationship := &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"data": LinkageSchema(relation), "meta": MetaSchema()},
+		}
+		if response {
+			relationship.Properties["links"] = LinksSchema()
+		} else {
+			relationship.Required = []string{"data"}
+		}
+		schema.Properties[relation.NeuronName()] = relationship
+	}
+	return schema
+}
+
+// IdentifierSchema gets the resource identifier object schema of the 'model'.
+func IdentifierSchema(model *mapping.ModelStruct) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"type": {Type: "string", Const: model.Collection()},
+			"id":   {Type: "string"},
+			"meta": MetaSchema(),
+		},
+		Required: []string{"type", "id"},
+	}
+}
+
+// LinkageSchema gets the resource linkage schema for the relationship 'field'.
+func LinkageSchema(field *mapping.StructField) *Schema {
+	identifier := IdentifierSchema(field.Relationship().Struct())
+	if field.Kind() == mapping.KindRelationshipMultiple {
+		return &Schema{Type: "array", Items: identifier}
+	}
+	return &Schema{OneOf: []*Schema{identifier, {Type: "null"}}}
+}
+
+// LinksSchema gets the links object schema.
+func LinksSchema() *Schema {
+	return &Schema{
+		Type: "object",
+		AdditionalProperties: &Schema{OneOf: []*Schema{
+			{Type: "string", Format: "uri-reference"},
+			{Type: "object", Properties: map[string]*Schema{
+				"href": {Type: "string", Format: "uri-reference"},
+				"meta": MetaSchema(),
+			}, Required: []string{"href"}},
+			{Type: "null"},
+		}},
+	}
+}
+
+// MetaSchema gets the meta object schema.
+func MetaSchema() *Schema {
+	return &Schema{Type: "object"}
+}
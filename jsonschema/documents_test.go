@@ -0,0 +1,163 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/controller"
+	mocks "github.com/neuronlabs/neuron-mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	handler "github.com/neuronlabs/jsonapi-handler"
+)
+
+// Author is the model used by the jsonschema tests.
+type Author struct {
+	ID       int
+	Name     string
+	Password string  `neuron:"-"`
+	Books    []*Book `neuron:"foreign=AuthorID"`
+}
+
+// Book is the model used by the jsonschema tests.
+type Book struct {
+	ID       string `neuron:"type=primary;flags=client-id"`
+	Title    string
+	Pages    *uint
+	Author   *Author
+	AuthorID int `neuron:"type=fk"`
+}
+
+func testController(t *testing.T) *controller.Controller {
+	t.Helper()
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	require.NoError(t, c.RegisterModels(Author{}, Book{}))
+	return c
+}
+
+// TestDocument tests the document schemas.
+func TestDocument(t *testing.T) {
+	c := testController(t)
+	books := c.MustGetModelStruct(Book{})
+	authors := c.MustGetModelStruct(Author{})
+
+	t.Run("Create", func(t *testing.T) {
+		schema := Document(books, CreateDocument, &Options{BaseID: "https://example.com/schemas/"})
+		assert.Equal(t, Draft, schema.Schema)
+		assert.Equal(t, "https://example.com/schemas/books.create.schema.json", schema.ID)
+		assert.Equal(t, "books create document", schema.Title)
+
+		data := schema.Properties["data"]
+		require.NotNil(t, data)
+		assert.Equal(t, []string{"type"}, data.Required)
+		assert.Equal(t, false, data.AdditionalProperties)
+		// the books allows the client generated ids.
+		assert.Contains(t, data.Properties, "id")
+		assert.Equal(t, "books", data.Properties["type"].Const)
+
+		attributes := data.Properties["attributes"]
+		assert.Equal(t, &Schema{Type: "string"}, attributes.Properties["title"])
+		assert.Equal(t, []string{"integer", "null"}, attributes.Properties["pages"].Type)
+		assert.NotContains(t, attributes.Properties, "author_id")
+
+		relationship := data.Properties["relationships"].Properties["author"]
+		require.NotNil(t, relationship)
+		assert.Equal(t, []string{"data"}, relationship.Required)
+		assert.NotContains(t, relationship.Properties, "links")
+
+		schema = Document(authors, CreateDocument, nil)
+		assert.NotContains(t, schema.Properties["data"].Properties, "id")
+		assert.Empty(t, schema.ID)
+	})
+
+	t.Run("Required", func(t *testing.T) {
+		h := handler.NewC(c)
+		h.Validation(Book{}).Field("title").Required().Field("pages").Min(1)
+
+		schema := Document(books, CreateDocument, &Options{Required: h.RequiredAttributes})
+		data := schema.Properties["data"]
+		assert.Equal(t, []string{"type", "attributes"}, data.Required)
+		assert.Equal(t, []string{"title"}, data.Properties["attributes"].Required)
+
+		// the required attributes are not required within the update document.
+		schema = Document(books, UpdateDocument, &Options{Required: h.RequiredAttributes})
+		assert.Empty(t, schema.Properties["data"].Properties["attributes"].Required)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		data := Document(authors, UpdateDocument, nil).Properties["data"]
+		assert.Equal(t, []string{"type", "id"}, data.Required)
+		// hidden attributes are not exposed.
+		assert.NotContains(t, data.Properties["attributes"].Properties, "password")
+	})
+
+	t.Run("Response", func(t *testing.T) {
+		schema := Document(authors, ResponseDocument, nil)
+		if assert.Len(t, schema.Properties["data"].OneOf, 2) {
+			resource := schema.Properties["data"].OneOf[0]
+			assert.Equal(t, []string{"type", "id"}, resource.Required)
+
+			relationship := resource.Properties["relationships"].Properties["books"]
+			require.NotNil(t, relationship)
+			assert.Contains(t, relationship.Properties, "links")
+			assert.Empty(t, relationship.Required)
+			assert.Equal(t, "array", relationship.Properties["data"].Type)
+		}
+
+		schema = Document(authors, CollectionDocument, nil)
+		assert.Equal(t, "array", schema.Properties["data"].Type)
+		assert.Nil(t, Document(authors, DocumentType(10), nil))
+	})
+
+	t.Run("Linkage", func(t *testing.T) {
+		field, ok := books.RelationField("author")
+		require.True(t, ok)
+
+		linkage := LinkageSchema(field)
+		if assert.Len(t, linkage.OneOf, 2) {
+			assert.Equal(t, IdentifierSchema(authors), linkage.OneOf[0])
+			assert.Equal(t, &Schema{Type: "null"}, linkage.OneOf[1])
+		}
+	})
+}
+
+// TestWriteFiles tests writing the schemas with the Run command.
+func TestWriteFiles(t *testing.T) {
+	c := testController(t)
+	h := handler.NewC(c)
+	h.Validation(Author{}).Field("name").Required()
+
+	dir, err := ioutil.TempDir("", "jsonschema")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = Run(c, []string{"-out", dir, "-collections", "authors", "-base-id", "https://example.com/"}, &Options{Required: h.RequiredAttributes})
+	require.NoError(t, err)
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, len(DocumentTypes))
+
+	authors := c.MustGetModelStruct(Author{})
+	data, err := ioutil.ReadFile(filepath.Join(dir, FileName(authors, CreateDocument)))
+	require.NoError(t, err)
+
+	schema := &Schema{}
+	require.NoError(t, json.Unmarshal(data, schema))
+	assert.Equal(t, "https://example.com/authors.create.schema.json", schema.ID)
+	assert.Equal(t, []string{"name"}, schema.Properties["data"].Properties["attributes"].Required)
+
+	err = Run(c, []string{"-out", dir, "-collections", "unknown"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+// Package jsonschema generates the JSON Schemas (draft 2020-12) of the JSONAPI documents for the neuron models.
+//
+// The schemas describe the create, update and response documents of the model's collection endpoints.
+// The package's Main function allows to write the schemas into the files. In order to use it as a command
+// create the main package that registers the models within the controller:
+//
+//	func main() {
+//		c, err := neuron.NewController(config.Default())
+//		...
+//		if err = c.RegisterModels(&models.User{}, &models.Post{}); err != nil {
+//			...
+//		}
+//		h := handler.NewC(c)
+//		h.Validation(&models.User{}).Field("email").Required()
+//		// the required attributes are derived from the handler validations.
+//		jsonschema.Main(c, &jsonschema.Options{Required: h.RequiredAttributes})
+//	}
+//
+// and run it with the output directory flag: 'go run ./cmd/schemas -out ./schemas'.
+package jsonschema
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/neuronlabs/neuron-core/mapping"
+)
+
+// Draft is the JSON Schema dialect of the generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the JSON Schema object.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Const                interface{}        `json:"const,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// FieldSchema gets the schema of the attribute 'field' value.
+func FieldSchema(field *mapping.StructField) *Schema {
+	t := field.ReflectField().Type
+	if field.IsTime() || field.IsTimePointer() {
+		schema := &Schema{Type: "integer", Format: "int64", Description: "Unix timestamp"}
+		if field.IsISO8601() {
+			schema = &Schema{Type: "string", Format: "date-time"}
+		}
+		if t.Kind() == reflect.Ptr {
+			schema.Type = []string{schema.Type.(string), "null"}
+		}
+		return schema
+	}
+	if field.IsNestedStruct() && field.Nested() != nil {
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for name, nested := range field.Nested().Fields() {
+			schema.Properties[name] = TypeSchema(nested.StructField().ReflectField().Type)
+		}
+		if t.Kind() == reflect.Ptr {
+			schema.Type = []string{"object", "null"}
+		}
+		return schema
+	}
+	return TypeSchema(t)
+}
+
+// TypeSchema gets the schema of the values of the 't' type.
+func TypeSchema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := TypeSchema(t.Elem())
+		if tp, ok := schema.Type.(string); ok {
+			schema.Type = []string{tp, "null"}
+		}
+		return schema
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint, reflect.Uint64:
+		var minimum float64
+		return &Schema{Type: "integer", Minimum: &minimum}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: TypeSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: TypeSchema(t.Elem())}
+	case reflect.Struct:
+		if t == timeType || t.ConvertibleTo(timeType) {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		return &Schema{Type: "object"}
+	}
+	return &Schema{}
+}
@@ -0,0 +1,83 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/neuronlabs/neuron-core/controller"
+	"github.com/neuronlabs/neuron-core/mapping"
+
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// WriteFiles writes the document schemas of all document types for provided 'models' into the 'dir' directory.
+// If the directory doesn't exist it is created. The file names are created by the FileName function.
+func WriteFiles(dir string, o *Options, models ...*mapping.ModelStruct) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, model := range models {
+		for _, d := range DocumentTypes {
+			data, err := json.MarshalIndent(Document(model, d, o), "", "  ")
+			if err != nil {
+				return err
+			}
+			fileName := filepath.Join(dir, FileName(model, d))
+			if err = ioutil.WriteFile(fileName, append(data, '\n'), 0644); err != nil {
+				return err
+			}
+			log.Debugf("Written JSON Schema: '%s'", fileName)
+		}
+	}
+	return nil
+}
+
+// Main is the command that writes the document schemas of the models registered within the controller 'c'.
+// The optional 'options' are used for all the schemas. The command flags are:
+//   - out - the output directory (default: 'schemas'),
+//   - collections - comma separated collections to write (default: all),
+//   - base-id - the base URI of the schemas '$id', overwrites the options BaseID.
+//
+// On failure the command prints the error and exits with the status code 1.
+func Main(c *controller.Controller, options ...*Options) {
+	if err := Run(c, os.Args[1:], options...); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Run runs the command with provided 'args' and optional 'options'. For the command flags see the Main function.
+func Run(c *controller.Controller, args []string, options ...*Options) error {
+	flags := flag.NewFlagSet("jsonschema", flag.ContinueOnError)
+	out := flags.String("out", "schemas", "the output directory of the schema files")
+	collections := flags.String("collections", "", "comma separated collections to write - by default all collections are written")
+	baseID := flags.String("base-id", "", "the base URI of the schemas '$id'")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	models := c.ListModels()
+	if *collections != "" {
+		models = models[:0:0]
+		for _, collection := range strings.Split(*collections, ",") {
+			model, err := c.ModelStruct(strings.TrimSpace(collection))
+			if err != nil {
+				return fmt.Errorf("collection: '%s' not found", collection)
+			}
+			models = append(models, model)
+		}
+	}
+	o := &Options{}
+	if len(options) > 0 && options[0] != nil {
+		*o = *options[0]
+	}
+	if *baseID != "" {
+		o.BaseID = *baseID
+	}
+	return WriteFiles(*out, o, models...)
+}
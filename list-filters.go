@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/neuron-core/annotation"
+	"github.com/neuronlabs/neuron-core/class"
+	"github.com/neuronlabs/neuron-core/query"
+
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// RequiredFilters sets the fields that must be filtered by the client at given list endpoint. The 'fields' are the
+// neuron names of the model fields or the dot separated paths of the relationship fields i.e. 'owner.name'.
+// The requests without any of the required filters are rejected with the ErrMissingRequiredQueryParameter error.
+// Panics if any of the fields is not found within the model.
+func (l *ListHandlerCreator) RequiredFilters(fields ...string) *ListHandlerCreator {
+	for _, field := range fields {
+		if _, ok := fieldByPath(l.model, field); !ok {
+			log.Panicf("Required filter field: '%s' not found within model: '%s'", field, l.model.Collection())
+		}
+		l.options.requiredFilters = append(l.options.requiredFilters, field)
+	}
+	return l
+}
+
+// DefaultFilter sets the default filter for given list endpoint. The filter is added to the query if the client
+// doesn't filter the same field. The 'filter' is in format: 'filter[collection][field][operator]' and the 'values'
+// are the filter values. Panics if the filter is not valid for the model.
+func (l *ListHandlerCreator) DefaultFilter(filter string, values ...interface{}) *ListHandlerCreator {
+	f, err := query.NewStringFilter(l.h.c, filter, values...)
+	if err != nil {
+		log.Panicf("Default filter: '%s' for the model: '%s' failed: %v", filter, l.model.Collection(), err)
+	}
+	if f.StructField.ModelStruct() != l.model {
+		log.Panicf("Default filter: '%s' is not related with the model: '%s'", filter, l.model.Collection())
+	}
+	l.options.defaultFilters = append(l.options.defaultFilters, f)
+	return l
+}
+
+// checkRequiredFilters checks if the scope 's' contains all the filters required by the endpoint options 'o'.
+func checkRequiredFilters(s *query.Scope, o *endpointOptions) error {
+	if len(o.requiredFilters) == 0 {
+		return nil
+	}
+	paths := filterPaths(s)
+	var multiErrors errors.MultiError
+	for _, field := range o.requiredFilters {
+		if _, ok := paths[field]; ok {
+			continue
+		}
+		key := query.ParamFilter + "[" + s.Struct().Collection() + "][" + strings.Replace(field, annotation.NestedSeparator, "][", -1) + "]"
+		err := errors.NewDetf(class.QueryFilterMissingRequired, "missing required filter: '%s'", key)
+		err.SetDetailsf("The filter: '%s' is required.", key)
+		multiErrors = append(multiErrors, err)
+	}
+	if len(multiErrors) > 0 {
+		return multiErrors
+	}
+	return nil
+}
+
+// addDefaultFilters adds the default filters of the endpoint options 'o' that are not filtered within the scope 's'.
+func addDefaultFilters(s *query.Scope, o *endpointOptions) error {
+	if len(o.defaultFilters) == 0 {
+		return nil
+	}
+	paths := filterPaths(s)
+	for _, f := range o.defaultFilters {
+		var (
+			filtered bool
+			fPaths   = map[string]struct{}{}
+		)
+		addFilterPaths(fPaths, "", f)
+		for path := range fPaths {
+			if _, filtered = paths[path]; filtered {
+				break
+			}
+		}
+		if filtered {
+			continue
+		}
+		if err := s.FilterField(f.Copy()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterPaths gets the relationship paths of the fields filtered within the scope 's' and its included scopes.
+func filterPaths(s *query.Scope) map[string]struct{} {
+	paths := map[string]struct{}{}
+	for _, scope := range append([]*query.Scope{s}, s.IncludedScopes()...) {
+		prefixes := []string{""}
+		if scope != s {
+			prefixes = relationPaths(s, scope.Struct())
+		}
+		for _, filters := range []query.Filters{scope.PrimaryFilters, scope.AttributeFilters, scope.ForeignFilters, scope.RelationFilters, scope.FilterKeyFilters} {
+			for _, f := range filters {
+				for _, prefix := range prefixes {
+					addFilterPaths(paths, prefix, f)
+				}
+			}
+		}
+	}
+	return paths
+}
+
+func addFilterPaths(paths map[string]struct{}, prefix string, f *query.FilterField) {
+	path := prefix + f.StructField.NeuronName()
+	if len(f.Nested) == 0 {
+		paths[path] = struct{}{}
+		return
+	}
+	for _, nested := range f.Nested {
+		addFilterPaths(paths, path+annotation.NestedSeparator, nested)
+	}
+}
+
+// copyFilters adds the copies of all the 'from' scope filters into the 'to' scope.
+func copyFilters(from, to *query.Scope) error {
+	for _, filters := range []query.Filters{from.PrimaryFilters, from.AttributeFilters, from.ForeignFilters, from.RelationFilters, from.FilterKeyFilters} {
+		for _, f := range filters {
+			if err := to.FilterField(f.Copy()); err != nil {
+				return err
+			}
+		}
+	}
+	if from.LanguageFilters != nil {
+		return to.FilterField(from.LanguageFilters.Copy())
+	}
+	return nil
+}
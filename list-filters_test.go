@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/class"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+)
+
+// TestListFilters tests the required and default filters of the list endpoint.
+func TestListFilters(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, target string) *http.Request {
+		req, err := http.NewRequest("GET", target, nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req
+	}
+
+	repo, err := c.GetRepository(House{})
+	require.NoError(t, err)
+
+	housesRepo, ok := repo.(*mocks.Repository)
+	require.True(t, ok)
+
+	h := NewC(c)
+	assert.Panics(t, func() { h.ListWith(House{}).RequiredFilters("unknown") })
+	assert.Panics(t, func() { h.ListWith(House{}).DefaultFilter("filter[humen][name]", "John") })
+
+	t.Run("Required", func(t *testing.T) {
+		handler := h.ListWith(House{}).RequiredFilters("address", "id").Handler()
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, newRequest(t, "/houses?filter[houses][address]=Main"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		payload, err := jsonapi.UnmarshalErrors(resp.Body)
+		require.NoError(t, err)
+		if assert.Len(t, payload.Errors, 1) {
+			code, err := strconv.ParseInt(payload.Errors[0].Code, 16, 32)
+			require.NoError(t, err)
+			assert.Equal(t, class.QueryFilterMissingRequired, errors.Class(code))
+			assert.Contains(t, payload.Errors[0].Detail, "filter[houses][id]")
+		}
+
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Return(nil)
+
+		resp = httptest.NewRecorder()
+		handler.ServeHTTP(resp, newRequest(t, "/houses?filter[houses][address]=Main&filter[houses][id]=1&fields[houses]=address"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		handler := h.ListWith(House{}).DefaultFilter("filter[houses][address][$contains]", "Main").Handler()
+
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if assert.Len(t, s.AttributeFilters, 1) && assert.Len(t, s.AttributeFilters[0].Values, 1) {
+				assert.Equal(t, query.OpContains, s.AttributeFilters[0].Values[0].Operator)
+			}
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, newRequest(t, "/houses?fields[houses]=address"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if assert.Len(t, s.AttributeFilters, 1) && assert.Len(t, s.AttributeFilters[0].Values, 1) {
+				assert.Equal(t, query.OpEqual, s.AttributeFilters[0].Values[0].Operator)
+			}
+		}).Return(nil)
+
+		resp = httptest.NewRecorder()
+		handler.ServeHTTP(resp, newRequest(t, "/houses?filter[houses][address]=Side&fields[houses]=address"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+}
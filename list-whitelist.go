@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/neuron-core/annotation"
+	"github.com/neuronlabs/neuron-core/class"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+
+	handlerClass "github.com/neuronlabs/jsonapi-handler/errors/class"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// FilterableFields restricts the fields that might be filtered at given list endpoint. The 'fields' are the neuron
+// names of the model fields or the dot separated paths of the relationship fields i.e. 'owner.name'. The filters
+// on the included collections are matched by the paths of the included relationship fields. As the included
+// collection filters apply to all the relationships that include the collection i.e. 'include=sender,recipient',
+// the field must be filterable for each of these relationship paths. If not set, all the fields are filterable.
+// Panics if any of the fields is not found within the model.
+func (l *ListHandlerCreator) FilterableFields(fields ...string) *ListHandlerCreator {
+	for _, field := range fields {
+		l.setFilterable(field)
+	}
+	return l
+}
+
+// FilterOperators sets the filter 'operators' allowed for the 'field' at given list endpoint.
+// The 'field' becomes filterable, but only with provided operators.
+func (l *ListHandlerCreator) FilterOperators(field string, operators ...*query.Operator) *ListHandlerCreator {
+	l.setFilterable(field)
+	l.options.filterableFields[field] = append(l.options.filterableFields[field], operators...)
+	return l
+}
+
+// SortableFields restricts the fields that the list endpoint might be sorted by. The 'fields' are the neuron
+// names of the model fields or the dot separated paths of the relationship fields. If not set, all the fields are
+// sortable. The SortOrder of the endpoint is not restricted. Panics if any of the fields is not found within the model.
+func (l *ListHandlerCreator) SortableFields(fields ...string) *ListHandlerCreator {
+	if l.options.sortableFields == nil {
+		l.options.sortableFields = map[string]struct{}{}
+	}
+	for _, field := range fields {
+		if _, ok := fieldByPath(l.model, field); !ok {
+			log.Panicf("Sortable field: '%s' not found within model: '%s'", field, l.model.Collection())
+		}
+		l.options.sortableFields[field] = struct{}{}
+	}
+	return l
+}
+
+func (l *ListHandlerCreator) setFilterable(field string) {
+	if _, ok := fieldByPath(l.model, field); !ok {
+		log.Panicf("Filterable field: '%s' not found within model: '%s'", field, l.model.Collection())
+	}
+	if l.options.filterableFields == nil {
+		l.options.filterableFields = map[string][]*query.Operator{}
+	}
+	if _, ok := l.options.filterableFields[field]; !ok {
+		l.options.filterableFields[field] = nil
+	}
+}
+
+// fieldByPath gets the field of the 'model' by the dot separated relationship 'path'.
+func fieldByPath(model *mapping.ModelStruct, path string) (*mapping.StructField, bool) {
+	segments := strings.Split(path, annotation.NestedSeparator)
+	for _, relation := range segments[:len(segments)-1] {
+		field, ok := model.RelationField(relation)
+		if !ok {
+			return nil, false
+		}
+		model = field.Relationship().Struct()
+	}
+	name := segments[len(segments)-1]
+	field, ok := model.FieldByName(name)
+	if !ok || field.NeuronName() != name {
+		return nil, false
+	}
+	return field, true
+}
+
+// checkFilterable checks if the filter 'f' is allowed by the endpoint options 'o'. The 'prefix' is the relationship
+// path of the filtered collection.
+func checkFilterable(o *endpointOptions, prefix string, f *query.FilterField) error {
+	if o.filterableFields == nil {
+		return nil
+	}
+	path := prefix + f.StructField.NeuronName()
+	if len(f.Nested) > 0 {
+		for _, nested := range f.Nested {
+			if err := checkFilterable(o, path+annotation.NestedSeparator, nested); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	operators, ok := o.filterableFields[path]
+	if !ok {
+		err := errors.NewDetf(class.QueryFilterUnsupportedField, "filtering field: '%s' is not supported", path)
+		err.SetDetailsf("Filtering by the field: '%s' is not supported.", path)
+		return err
+	}
+	if operators == nil {
+		return nil
+	}
+	for _, values := range f.Values {
+		if !containsOperator(operators, values.Operator) {
+			err := errors.NewDetf(class.QueryFilterUnsupportedOperator, "filter operator: '%s' is not supported for the field: '%s'", values.Operator.Raw, path)
+			err.SetDetailsf("Filter operator: '%s' is not supported for the field: '%s'.", values.Operator.Raw, path)
+			return err
+		}
+	}
+	return nil
+}
+
+func containsOperator(operators []*query.Operator, operator *query.Operator) bool {
+	for _, op := range operators {
+		if op.ID == operator.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSortable checks if the 'fields' sort parameters are allowed by the endpoint options 'o'.
+func checkSortable(o *endpointOptions, fields []string) error {
+	if o.sortableFields == nil {
+		return nil
+	}
+	for _, field := range fields {
+		field = strings.TrimPrefix(field, "-")
+		if _, ok := o.sortableFields[field]; !ok {
+			err := errors.NewDetf(handlerClass.QuerySortUnsupportedField, "sorting field: '%s' is not supported", field)
+			err.SetDetailsf("Sorting by the field: '%s' is not supported.", field)
+			return err
+		}
+	}
+	return nil
+}
+
+// includedPaths are the dot separated paths of the relationship fields included into the root scope,
+// mapped by the included model.
+type includedPaths map[*mapping.ModelStruct][]string
+
+// setIncludedPaths stores the relationship paths of the 'includes' of the scope 's'.
+func setIncludedPaths(s *query.Scope, includes []string) {
+	paths := includedPaths{}
+	for _, include := range includes {
+		model := s.Struct()
+		var path string
+		for _, name := range strings.Split(include, annotation.NestedSeparator) {
+			field, ok := model.RelationField(name)
+			if !ok {
+				break
+			}
+			path += field.NeuronName()
+			model = field.Relationship().Struct()
+			if !containsString(paths[model], path) {
+				paths[model] = append(paths[model], path)
+			}
+			path += annotation.NestedSeparator
+		}
+	}
+	s.StoreSet(scopeIncludedPathsK, paths)
+}
+
+// relationPaths gets the relationship path prefixes of the 'included' model within the root scope 's'.
+// The included scope is shared by all the relationship fields that include the model, thus a prefix is returned
+// for each of these relationship fields.
+func relationPaths(s *query.Scope, included *mapping.ModelStruct) []string {
+	v, _ := s.StoreGet(scopeIncludedPathsK)
+	paths, _ := v.(includedPaths)
+	prefixes := make([]string, len(paths[included]))
+	for i, path := range paths[included] {
+		prefixes[i] = path + annotation.NestedSeparator
+	}
+	if len(prefixes) == 0 {
+		prefixes = append(prefixes, included.Collection()+annotation.NestedSeparator)
+	}
+	return prefixes
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var scopeIncludedPathsK scopeIncludedPaths
+
+type scopeIncludedPaths struct{}
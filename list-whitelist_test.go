@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/class"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+
+	handlerClass "github.com/neuronlabs/jsonapi-handler/errors/class"
+)
+
+// TestListWhitelist tests the filterable and sortable fields of the list endpoint.
+func TestListWhitelist(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{}, Parcel{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, target string) *http.Request {
+		req, err := http.NewRequest("GET", target, nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req
+	}
+
+	errorClass := func(t *testing.T, resp *httptest.ResponseRecorder) errors.Class {
+		payload, err := jsonapi.UnmarshalErrors(resp.Body)
+		require.NoError(t, err)
+		require.Len(t, payload.Errors, 1)
+
+		code, err := strconv.ParseInt(payload.Errors[0].Code, 16, 32)
+		require.NoError(t, err)
+		return errors.Class(code)
+	}
+
+	h := NewC(c)
+	assert.Panics(t, func() { h.ListWith(House{}).FilterableFields("unknown") })
+	assert.Panics(t, func() { h.ListWith(House{}).SortableFields("owner.unknown") })
+
+	handler := h.ListWith(House{}).
+		FilterableFields("owner.name").
+		FilterOperators("address", query.OpEqual, query.OpContains).
+		SortableFields("id", "address").
+		Handler()
+
+	t.Run("UnsupportedField", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, newRequest(t, "/houses?filter[houses][id][$eq]=1"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Equal(t, class.QueryFilterUnsupportedField, errorClass(t, resp))
+
+		resp = httptest.NewRecorder()
+		handler.ServeHTTP(resp, newRequest(t, "/houses?filter[houses][owner][age][$eq]=1"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Equal(t, class.QueryFilterUnsupportedField, errorClass(t, resp))
+	})
+
+	t.Run("UnsupportedOperator", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, newRequest(t, "/houses?filter[houses][address][$ne]=Main"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Equal(t, class.QueryFilterUnsupportedOperator, errorClass(t, resp))
+	})
+
+	t.Run("UnsupportedSort", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, newRequest(t, "/houses?sort=-owner_id"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Equal(t, handlerClass.QuerySortUnsupportedField, errorClass(t, resp))
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		repo, err := c.GetRepository(House{})
+		require.NoError(t, err)
+
+		housesRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			assert.Len(t, s.AttributeFilters, 1)
+			assert.Len(t, s.SortFields, 1)
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, newRequest(t, "/houses?filter[houses][address][$contains]=Main&sort=-address&fields[houses]=address"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("IncludedRelationship", func(t *testing.T) {
+		handler := h.ListWith(Parcel{}).FilterableFields("recipient.name").Handler()
+
+		repo, err := c.GetRepository(Parcel{})
+		require.NoError(t, err)
+
+		parcelsRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		parcelsRepo.On("List", mock.Anything, mock.Anything).Once().Return(nil)
+
+		// the included collection filter is matched by the path of the included relationship.
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, newRequest(t, "/parcels?include=recipient&filter[humen][name][$eq]=Jon"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		// the filter applies to all the relationships including the collection.
+		for _, target := range []string{
+			"/parcels?include=sender&filter[humen][name][$eq]=Jon",
+			"/parcels?include=sender,recipient&filter[humen][name][$eq]=Jon",
+		} {
+			resp = httptest.NewRecorder()
+			handler.ServeHTTP(resp, newRequest(t, target))
+			assert.Equal(t, http.StatusBadRequest, resp.Code, target)
+			assert.Equal(t, class.QueryFilterUnsupportedField, errorClass(t, resp), target)
+		}
+		parcelsRepo.AssertExpectations(t)
+	})
+}
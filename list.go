@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -19,29 +20,38 @@ import (
 	"github.com/neuronlabs/jsonapi-handler/log"
 )
 
-// ListHandlerCreator is the creator for the JSONAPI list endpoint http.Handler.
+// ListHandlerCreator is the creator for the JSONAPI list endpoint http.Handler. It is also used by the endpoints
+// that query the resources the same way as the list endpoint i.e. the aggregate and export endpoints.
 type ListHandlerCreator struct {
 	h          *Creator
 	model      *mapping.ModelStruct
-	basePath   string
+	options    endpointOptions
 	pageSize   int
 	sortFields []string
+	// handler is the handler function of the non list endpoints.
+	handler func(*mapping.ModelStruct, *endpointOptions) http.HandlerFunc
 }
 
 // BasePath sets the basePath for given endpoint.
 func (l *ListHandlerCreator) BasePath(basePath string) *ListHandlerCreator {
-	l.basePath = basePath
+	l.options.basePath = basePath
 	return l
 }
 
 // Handler returns http.HandlerFunc for given handler creator.
 func (l *ListHandlerCreator) Handler() http.HandlerFunc {
-	return l.h.handleList(l.model, l.pageSize, l.basePath, l.sortFields...)
+	options := l.options
+	if l.handler != nil {
+		return l.handler(l.model, &options)
+	}
+	return l.h.handleList(l.model, &options, l.pageSize, l.sortFields...)
 }
 
 // PageSize sets the default 'pageSize' for given endpoint.
+// It overwrites the limit-offset pagination set by the PageLimit.
 func (l *ListHandlerCreator) PageSize(pageSize int) *ListHandlerCreator {
 	l.pageSize = pageSize
+	l.options.limitOffsetPagination = false
 	return l
 }
 
@@ -62,20 +72,13 @@ func (h *Creator) ListWith(model interface{}) *ListHandlerCreator {
 
 // List returns JSONAPI list http.HandlerFunc for given 'model'.
 func (h *Creator) List(model interface{}) http.HandlerFunc {
-	return h.handleList(h.c.MustGetModelStruct(model), 0, "")
+	return h.handleList(h.c.MustGetModelStruct(model), &endpointOptions{}, 0)
 }
 
-func (h *Creator) handleList(model *mapping.ModelStruct, defaultPageSize int, basePath string, defaultSortOrder ...string) http.HandlerFunc {
-	var defaultPagination *query.Pagination
-	if defaultPageSize <= 0 && h.DefaultPageSize > 0 {
-		defaultPageSize = h.DefaultPageSize
-	}
-	if defaultPageSize > 0 {
-		defaultPagination = &query.Pagination{
-			Size:   int64(defaultPageSize),
-			Offset: 1,
-			Type:   query.PageNumberPagination,
-		}
+func (h *Creator) handleList(model *mapping.ModelStruct, o *endpointOptions, defaultPageSize int, defaultSortOrder ...string) http.HandlerFunc {
+	h.registerEndpoint(EndpointList, model, nil, o.basePath)
+	defaultPagination := h.defaultPagination(o, defaultPageSize)
+	if defaultPagination != nil {
 		log.Debug2f("Default pagination at 'GET /%s' is: %v", model.Collection(), defaultPagination.String())
 	}
 	var defaultSortOrderFields []*query.SortField
@@ -89,15 +92,27 @@ func (h *Creator) handleList(model *mapping.ModelStruct, defaultPageSize int, ba
 	}
 
 	return func(rw http.ResponseWriter, req *http.Request) {
+		if h.endpointNotAllowed(rw, req, EndpointList, model, nil) {
+			return
+		}
 		ctx := req.Context()
-		s, err := h.createListScope(ctx, model, req)
+		s, err := h.createListScope(ctx, model, req, o)
 		if err != nil {
 			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
 			return
 		}
 
-		if defaultPagination != nil && s.Pagination == nil {
-			// TODO: add possibility to set nil pagination
+		if err = checkRequiredFilters(s, o); err != nil {
+			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
+			return
+		}
+		if err = addDefaultFilters(s, o); err != nil {
+			log.Errorf("[LIST][SCOPE][%s] Adding default filters failed: %v", s.ID(), err)
+			h.marshalErrors(rw, req, 0, handlerErrors.ErrInternalError())
+			return
+		}
+
+		if _, noPagination := s.StoreGet(scopeNoPaginationK); defaultPagination != nil && s.Pagination == nil && !noPagination {
 			s.Pagination = &query.Pagination{}
 			*s.Pagination = *defaultPagination
 		}
@@ -114,6 +129,22 @@ func (h *Creator) handleList(model *mapping.ModelStruct, defaultPageSize int, ba
 			}
 		}
 
+		if errs := h.authorize(req, EndpointList, model, nil, s); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
+		if errs := h.authorizeIncludes(req, EndpointList, model, s); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
+
+		// resolve the filter expression 'or' groups.
+		isNoResult, err := h.resolveFilterGroups(ctx, s)
+		if err != nil {
+			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
+			return
+		}
+
 		if log.Level() >= log.LDEBUG3 {
 			log.Debug3f("[LIST] %s", s.String())
 		}
@@ -126,8 +157,9 @@ func (h *Creator) handleList(model *mapping.ModelStruct, defaultPageSize int, ba
 			}
 		}
 
-		var isNoResult bool
-		if err := s.ListContext(ctx); err != nil {
+		if isNoResult {
+			log.Debug2f("[LIST][SCOPE][%s] Filter expression doesn't match any resource", s.ID())
+		} else if err := s.ListContext(ctx); err != nil {
 			if e, ok := err.(errors.ClassError); ok {
 				if e.Class() == class.QueryValueNoResult {
 					isNoResult = true
@@ -138,6 +170,12 @@ func (h *Creator) handleList(model *mapping.ModelStruct, defaultPageSize int, ba
 				return
 			}
 		}
+		if !isNoResult {
+			if errs := h.countRelationships(req, EndpointList, s, o); errs != nil {
+				h.marshalErrors(rw, req, 0, errs...)
+				return
+			}
+		}
 		// execute the after list hook if given model implements it.
 		if afterListHook, ok := Hooks.getHook(model, AfterList); ok {
 			if err = afterListHook(ctx, s); err != nil {
@@ -146,13 +184,14 @@ func (h *Creator) handleList(model *mapping.ModelStruct, defaultPageSize int, ba
 			}
 		}
 
+		setDocumentMeta(s, o)
 		linkType := jsonapi.ResourceLink
 		if !h.MarshalLinks {
 			linkType = jsonapi.NoLink
 		}
 		options := &jsonapi.MarshalOptions{Link: jsonapi.LinkOptions{
 			Type:       linkType,
-			BaseURL:    h.getBasePath(basePath),
+			BaseURL:    h.getBasePath(o.basePath),
 			Collection: model.Collection(),
 		}}
 
@@ -166,6 +205,7 @@ func (h *Creator) handleList(model *mapping.ModelStruct, defaultPageSize int, ba
 		// if there is no pagination then the pagination doesn't need to be created.
 		// marshal the results if there were no pagination set
 		if s.Pagination == nil || isNoResult {
+			s.StoreSet(scopeTotalK, int64(reflect.ValueOf(s.Value).Elem().Len()))
 			h.marshalScope(s, rw, req, http.StatusOK, options)
 			return
 		}
@@ -178,6 +218,7 @@ func (h *Creator) handleList(model *mapping.ModelStruct, defaultPageSize int, ba
 			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
 			return
 		}
+		s.StoreSet(scopeTotalK, total)
 
 		temp := h.queryWithoutPagination(req)
 
@@ -255,96 +296,20 @@ const (
 	QueryParamLinks string = "links"
 )
 
-func (h *Creator) createListScope(ctx context.Context, model *mapping.ModelStruct, req *http.Request) (*query.Scope, error) {
-	var multiErrors errors.MultiError
+func (h *Creator) createListScope(ctx context.Context, model *mapping.ModelStruct, req *http.Request, o *endpointOptions) (*query.Scope, error) {
 	s := query.NewModelC(h.c, model, true)
-	q := req.URL.Query()
-
-	// Included
-	included, ok := q[query.ParamInclude]
-	if ok {
-		includedFields := strings.Split(included[0], annotation.Separator)
-		err := s.IncludeFields(includedFields...)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	languages, ok := q[query.ParamLanguage]
-	if ok {
-		err := h.queryParameterLanguage(s, languages[0])
-		if err != nil {
-			return nil, err
-		}
+	if err := h.parseQuery(ctx, req, EndpointList, s, o); err != nil {
+		return nil, err
 	}
 
-	for key, values := range q {
-		if len(multiErrors) >= h.QueryErrorsLimit && h.QueryErrorsLimit != 0 {
-			log.Debug2f("Reached single query error limit: %v", multiErrors)
-			return nil, multiErrors
-		}
-
-		if len(values) > 1 {
-			err := errors.NewDetf(handlerClass.QueryInvalidParameter, "provided invalid query parameters")
-			err.SetDetailsf("The query parameter: '%s' used more than once.", key)
-			multiErrors = append(multiErrors, err)
-			continue
-		}
-
-		value := values[0]
-		select {
-		case <-ctx.Done():
-			ctxErr := ctx.Err()
-			if ctxErr == context.DeadlineExceeded {
-				err := errors.NewDet(handlerClass.QueryTimeout, context.DeadlineExceeded.Error())
-				err.SetDetails("The query connection had timed out")
-				return nil, err
-			}
-			return nil, ctxErr
-		default:
-		}
-
-		var err error
-		switch {
-		case key == query.ParamInclude, key == query.ParamLanguage:
-			continue
-		case key == query.ParamPageLimit:
-			err = preparePagination(s, key, value, ppLimit)
-		case key == query.ParamPageOffset:
-			err = preparePagination(s, key, value, ppOffset)
-		case key == query.ParamPageNumber:
-			err = preparePagination(s, key, value, ppPageNumber)
-		case key == query.ParamPageSize:
-			err = preparePagination(s, key, value, ppPageSize)
-		case key == query.ParamSort:
-			err = h.queryParameterSort(s, value)
-		case strings.HasPrefix(key, query.ParamFilter):
-			err = h.queryParameterFilters(s, key, value)
-		case strings.HasPrefix(key, query.ParamFields):
-			err = h.queryParameterFields(s, key, value)
-		case key == QueryParamPageTotal:
-			err = h.queryParameterPageTotal(s, key, value)
-		case key == QueryParamLinks:
-			err = h.queryParameterLinks(s, key, value)
-		default:
-			err = h.defaultQueryParameter(key)
-		}
-
-		if err != nil {
-			if ce, ok := err.(errors.ClassError); ok {
-				multiErrors = append(multiErrors, ce)
-			} else if me, ok := err.(errors.MultiError); ok {
-				multiErrors = append(multiErrors, me...)
-			} else {
-				return nil, err
-			}
-		}
-	}
-	if len(multiErrors) > 0 {
-		log.Debug2f("Multiple errors: %v", multiErrors)
-		return nil, multiErrors
+	q := req.URL.Query()
+	_, sizeSet := q[query.ParamPageSize]
+	_, limitSet := q[query.ParamPageLimit]
+	if o.allowNoPagination && (sizeSet || limitSet) && s.Pagination != nil && s.Pagination.Size == 0 {
+		// the client explicitly disabled the pagination.
+		s.Pagination = nil
+		s.StoreSet(scopeNoPaginationK, true)
 	}
-
 	if s.Pagination != nil {
 		if s.Pagination.Type == query.PageNumberPagination && s.Pagination.Offset == 0 {
 			if _, ok := q[query.ParamPageNumber]; !ok {
@@ -353,10 +318,16 @@ func (h *Creator) createListScope(ctx context.Context, model *mapping.ModelStruc
 				s.Pagination.Offset = 1
 			}
 		}
+		if err := h.checkPageSize(s, o); err != nil {
+			return nil, err
+		}
 		if err := s.Pagination.IsValid(); err != nil {
 			return nil, err
 		}
 	}
+	if err := h.excludeSoftDeleted(s); err != nil {
+		return nil, err
+	}
 	return s, nil
 }
 
@@ -433,14 +404,27 @@ func (h *Creator) queryParameterFields(s *query.Scope, key, value string) error
 	return fieldsScope.SetFieldset(fields...)
 }
 
-func (h *Creator) queryParameterFilters(s *query.Scope, key, value string) error {
+func (h *Creator) queryParameterFilters(ctx context.Context, s *query.Scope, key, value string, o *endpointOptions) error {
+	if !strings.HasPrefix(key, query.ParamFilter+"[") {
+		err := errors.NewDetf(handlerClass.QueryInvalidParameter, "invalid filter query parameter: '%s'", key)
+		err.SetDetailsf("The query parameter: '%s' is not a valid filter. Use the '%s' query parameter for the filter expressions.", key, QueryParamFilterExpression)
+		return err
+	}
 	f, err := query.NewStringFilter(h.c, key, value)
 	if err != nil {
 		return err
 	}
+	if err = h.checkReadableFilter(ctx, f); err != nil {
+		return err
+	}
+	if err = h.checkSoftDeleteFilter(f); err != nil {
+		return err
+	}
 
+	prefixes := []string{""}
 	filterScope := s
 	if filterModel := f.StructField.ModelStruct(); filterModel != s.Struct() {
+		prefixes = relationPaths(s, filterModel)
 		filterScope, err = s.IncludedScope(filterModel)
 		if err != nil {
 			err := errors.NewDetf(class.QueryFilterUnknownCollection, "invalid query collection: '%s'", filterModel.Collection())
@@ -448,11 +432,25 @@ func (h *Creator) queryParameterFilters(s *query.Scope, key, value string) error
 			return err
 		}
 	}
+	// the filter of the included scope applies to all the relationships including its model.
+	for _, prefix := range prefixes {
+		if err = checkFilterable(o, prefix, f); err != nil {
+			return err
+		}
+	}
 	return filterScope.FilterField(f)
 }
 
-func (h *Creator) queryParameterSort(s *query.Scope, value string) error {
+func (h *Creator) queryParameterSort(ctx context.Context, s *query.Scope, value string, o *endpointOptions) error {
 	fields := strings.Split(value, annotation.Separator)
+	if err := checkSortable(o, fields); err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if err := h.checkReadablePath(ctx, s.Struct(), strings.TrimPrefix(field, "-")); err != nil {
+			return err
+		}
+	}
 	return s.Sort(fields...)
 }
 
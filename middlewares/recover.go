@@ -0,0 +1,169 @@
+package middlewares
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/neuronlabs/jsonapi"
+
+	handler "github.com/neuronlabs/jsonapi-handler"
+	"github.com/neuronlabs/jsonapi-handler/errors"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// Recover returns the middleware that recovers from the panics that occurred in the next handlers.
+// The panic is logged with its stack trace and the response is written as the internal error
+// using the compression aware writer of the 'h' Creator. If the 'h' is nil the error is written without compression.
+// If the response was already written when the panic occurred, only the log is written.
+func Recover(h *handler.Creator) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rec := &recoverWriter{ResponseWriter: rw}
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+				if r == http.ErrAbortHandler {
+					// the http.ErrAbortHandler is used to abort the handler - it should be passed to the server.
+					panic(r)
+				}
+				log.Errorf("[%s %s] Recovered from panic: %v\n%s", req.Method, req.URL.Path, r, debug.Stack())
+				if rec.written {
+					return
+				}
+				// the headers set by the next handlers don't describe the error response.
+				for _, key := range []string{"Content-Type", "Content-Encoding", "Vary"} {
+					rw.Header().Del(key)
+				}
+				if h != nil {
+					h.MarshalErrors(rw, req, http.StatusInternalServerError, errors.ErrInternalError())
+					return
+				}
+				rw.Header().Add("Content-Type", jsonapi.MediaType)
+				rw.WriteHeader(http.StatusInternalServerError)
+				if err := jsonapi.MarshalErrors(rw, errors.ErrInternalError()); err != nil {
+					log.Errorf("Marshaling errors failed: %v", err)
+				}
+			}()
+			next.ServeHTTP(rec.wrap(), req)
+		})
+	}
+}
+
+// recoverWriter is the http.ResponseWriter wrapper that checks if the response was already written.
+type recoverWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+// wrap gets the http.ResponseWriter that implements only those of the http.Flusher, http.Hijacker
+// and http.Pusher interfaces that are implemented by the wrapped writer, so that the next handlers
+// could detect the supported features with the type assertions.
+func (r *recoverWriter) wrap() http.ResponseWriter {
+	_, isFlusher := r.ResponseWriter.(http.Flusher)
+	_, isHijacker := r.ResponseWriter.(http.Hijacker)
+	_, isPusher := r.ResponseWriter.(http.Pusher)
+	switch {
+	case isFlusher && isHijacker && isPusher:
+		return struct {
+			*recoverWriter
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{r, flushFunc(r.flush), hijackFunc(r.hijack), pushFunc(r.push)}
+	case isFlusher && isHijacker:
+		return struct {
+			*recoverWriter
+			http.Flusher
+			http.Hijacker
+		}{r, flushFunc(r.flush), hijackFunc(r.hijack)}
+	case isFlusher && isPusher:
+		return struct {
+			*recoverWriter
+			http.Flusher
+			http.Pusher
+		}{r, flushFunc(r.flush), pushFunc(r.push)}
+	case isHijacker && isPusher:
+		return struct {
+			*recoverWriter
+			http.Hijacker
+			http.Pusher
+		}{r, hijackFunc(r.hijack), pushFunc(r.push)}
+	case isFlusher:
+		return struct {
+			*recoverWriter
+			http.Flusher
+		}{r, flushFunc(r.flush)}
+	case isHijacker:
+		return struct {
+			*recoverWriter
+			http.Hijacker
+		}{r, hijackFunc(r.hijack)}
+	case isPusher:
+		return struct {
+			*recoverWriter
+			http.Pusher
+		}{r, pushFunc(r.push)}
+	}
+	return r
+}
+
+// WriteHeader implements http.ResponseWriter interface.
+func (r *recoverWriter) WriteHeader(statusCode int) {
+	r.written = true
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write implements io.Writer interface.
+func (r *recoverWriter) Write(data []byte) (int, error) {
+	r.written = true
+	return r.ResponseWriter.Write(data)
+}
+
+// flush flushes the wrapped http.Flusher.
+func (r *recoverWriter) flush() {
+	r.written = true
+	r.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijack hijacks the connection of the wrapped http.Hijacker.
+func (r *recoverWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := r.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		// the connection is taken over by the handler - the response can't be written anymore.
+		r.written = true
+	}
+	return conn, buf, err
+}
+
+// push pushes the 'target' with the wrapped http.Pusher.
+func (r *recoverWriter) push(target string, opts *http.PushOptions) error {
+	return r.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// flushFunc is the function that implements http.Flusher interface.
+type flushFunc func()
+
+// Flush implements http.Flusher interface.
+func (f flushFunc) Flush() {
+	f()
+}
+
+// hijackFunc is the function that implements http.Hijacker interface.
+type hijackFunc func() (net.Conn, *bufio.ReadWriter, error)
+
+// Hijack implements http.Hijacker interface.
+func (f hijackFunc) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return f()
+}
+
+// pushFunc is the function that implements http.Pusher interface.
+type pushFunc func(target string, opts *http.PushOptions) error
+
+// Push implements http.Pusher interface.
+func (f pushFunc) Push(target string, opts *http.PushOptions) error {
+	return f(target, opts)
+}
@@ -0,0 +1,173 @@
+package middlewares
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	handler "github.com/neuronlabs/jsonapi-handler"
+)
+
+// TestRecover tests the panic recovery middleware.
+func TestRecover(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T) *http.Request {
+		req, err := http.NewRequest("GET", "/houses", nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req
+	}
+
+	panicking := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		panic("handler failed")
+	})
+
+	for name, h := range map[string]*handler.Creator{"Creator": handler.NewC(c), "NoCreator": nil} {
+		t.Run(name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			Recover(h)(panicking).ServeHTTP(resp, newRequest(t))
+
+			assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			assert.Equal(t, jsonapi.MediaType, resp.Header().Get("Content-Type"))
+
+			payload, err := jsonapi.UnmarshalErrors(resp.Body)
+			require.NoError(t, err)
+			if assert.Len(t, payload.Errors, 1) {
+				assert.Equal(t, "500", payload.Errors[0].Status)
+			}
+		})
+	}
+
+	t.Run("Written", func(t *testing.T) {
+		next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.WriteHeader(http.StatusOK)
+			_, err := rw.Write([]byte(`{"data":`))
+			require.NoError(t, err)
+			panic("handler failed")
+		})
+
+		resp := httptest.NewRecorder()
+		Recover(handler.NewC(c))(next).ServeHTTP(resp, newRequest(t))
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, `{"data":`, resp.Body.String())
+	})
+
+	t.Run("ResetHeaders", func(t *testing.T) {
+		next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set("Content-Type", "text/csv")
+			rw.Header().Set("Content-Encoding", "gzip")
+			rw.Header().Set("Vary", "Accept")
+			panic("handler failed")
+		})
+
+		for name, h := range map[string]*handler.Creator{"Creator": handler.NewC(c), "NoCreator": nil} {
+			resp := httptest.NewRecorder()
+			Recover(h)(next).ServeHTTP(resp, newRequest(t))
+
+			assert.Equal(t, http.StatusInternalServerError, resp.Code, name)
+			assert.Equal(t, jsonapi.MediaType, resp.Header().Get("Content-Type"), name)
+			assert.Empty(t, resp.Header().Get("Content-Encoding"), name)
+			assert.NotContains(t, resp.Header()["Vary"], "Accept", name)
+
+			// the body is not compressed.
+			_, err := jsonapi.UnmarshalErrors(resp.Body)
+			assert.NoError(t, err, name)
+		}
+	})
+
+	t.Run("Interfaces", func(t *testing.T) {
+		var flushed, hijacked bool
+		next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			f, ok := rw.(http.Flusher)
+			require.True(t, ok)
+			f.Flush()
+			flushed = true
+
+			hj, ok := rw.(http.Hijacker)
+			require.True(t, ok)
+			_, _, err := hj.Hijack()
+			require.NoError(t, err)
+			hijacked = true
+
+			p, ok := rw.(http.Pusher)
+			require.True(t, ok)
+			require.NoError(t, p.Push("/style.css", nil))
+		})
+
+		w := &fullWriter{ResponseRecorder: httptest.NewRecorder()}
+		Recover(nil)(next).ServeHTTP(w, newRequest(t))
+		assert.True(t, flushed)
+		assert.True(t, hijacked)
+		assert.True(t, w.hijacked)
+		assert.Equal(t, "/style.css", w.pushed)
+
+		// the interfaces not implemented by the wrapped writer are not exposed.
+		var checked bool
+		next = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			_, ok := rw.(http.Flusher)
+			assert.True(t, ok)
+			_, ok = rw.(http.Hijacker)
+			assert.False(t, ok)
+			_, ok = rw.(http.Pusher)
+			assert.False(t, ok)
+			checked = true
+		})
+		Recover(nil)(next).ServeHTTP(httptest.NewRecorder(), newRequest(t))
+		assert.True(t, checked)
+	})
+
+	t.Run("Hijacked", func(t *testing.T) {
+		next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			_, _, err := rw.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			panic("handler failed")
+		})
+
+		// the response is not written into the hijacked connection.
+		w := &fullWriter{ResponseRecorder: httptest.NewRecorder()}
+		Recover(nil)(next).ServeHTTP(w, newRequest(t))
+		assert.False(t, w.Flushed)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			panic(http.ErrAbortHandler)
+		})
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			Recover(nil)(next).ServeHTTP(httptest.NewRecorder(), newRequest(t))
+		})
+	})
+}
+
+// fullWriter is the response recorder implementing the http.Hijacker and http.Pusher interfaces.
+type fullWriter struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+	pushed   string
+}
+
+// Hijack implements http.Hijacker interface.
+func (f *fullWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	f.hijacked = true
+	return nil, nil, nil
+}
+
+// Push implements http.Pusher interface.
+func (f *fullWriter) Push(target string, opts *http.PushOptions) error {
+	f.pushed = target
+	return nil
+}
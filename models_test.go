@@ -3,12 +3,52 @@ package handler
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/neuronlabs/neuron-core/mapping"
 	"github.com/neuronlabs/neuron-core/query"
+	"github.com/neuronlabs/neuron-core/repository"
+	mocks "github.com/neuronlabs/neuron-mocks"
 
 	"github.com/neuronlabs/jsonapi-handler/log"
 )
 
+// aggregatorDriverName is the driver name of the mocked repositories implementing the Aggregator.
+const aggregatorDriverName = "neuron-mockery-aggregator"
+
+func init() {
+	if err := repository.RegisterFactory(&aggregatorFactory{}); err != nil {
+		panic(err)
+	}
+}
+
+// aggregatorFactory is the factory of the mocked repositories implementing the Aggregator.
+type aggregatorFactory struct {
+	mocks.Factory
+}
+
+// New implements repository.Factory interface.
+func (f *aggregatorFactory) New(repository.Controller, *mapping.ModelStruct) (repository.Repository, error) {
+	return &aggregatorRepository{Repository: &mocks.Repository{}}, nil
+}
+
+// DriverName implements repository.Factory interface.
+func (f *aggregatorFactory) DriverName() string {
+	return aggregatorDriverName
+}
+
+// aggregatorRepository is the mocked repository implementing the Aggregator.
+type aggregatorRepository struct {
+	*mocks.Repository
+}
+
+// Aggregate implements Aggregator interface.
+func (r *aggregatorRepository) Aggregate(ctx context.Context, s *query.Scope, aggregation *Aggregation) ([]*AggregateResult, error) {
+	args := r.Called(ctx, s, aggregation)
+	results, _ := args.Get(0).([]*AggregateResult)
+	return results, args.Error(1)
+}
+
 // House is the model used by the jsonapi handler tests.
 type House struct {
 	ID      int `neuron:"type=primary;flags=client-id"`
@@ -33,6 +73,22 @@ type Car struct {
 	OwnerID int `neuron:"type=fk;foreign=Owner"`
 }
 
+// Parcel is the model with multiple relationships to the same model used by the jsonapi handler tests.
+type Parcel struct {
+	ID          int
+	Sender      *Human
+	SenderID    int `neuron:"type=fk;foreign=Sender"`
+	Recipient   *Human
+	RecipientID int `neuron:"type=fk;foreign=Recipient"`
+}
+
+// Pet is the soft deleted model used by the jsonapi handler tests.
+type Pet struct {
+	ID        int
+	Name      string
+	RemovedAt *time.Time
+}
+
 type HookChecker struct {
 	ID     int
 	Before bool
@@ -121,3 +177,16 @@ func hooksCheckerAfterList(ctx context.Context, s *query.Scope) error {
 	}
 	return nil
 }
+
+// Shelter is the model with the many to many relationship used by the jsonapi handler tests.
+type Shelter struct {
+	ID   int
+	Pets []*Pet `neuron:"type=relation;many2many=ShelterPet;foreign=ShelterID,PetID"`
+}
+
+// ShelterPet is the join model of the Shelter pets used by the jsonapi handler tests.
+type ShelterPet struct {
+	ID        int
+	ShelterID int `neuron:"type=foreign"`
+	PetID     int `neuron:"type=foreign"`
+}
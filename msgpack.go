@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// msgpackEncoder encodes the generic JSON values in the MessagePack format.
+// See: https://github.com/msgpack/msgpack/blob/master/spec.md
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (e *msgpackEncoder) encode(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		e.buf = append(e.buf, 0xc0)
+	case bool:
+		if v {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case string:
+		e.encodeString(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			e.encodeInt(i)
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		e.encodeFloat(f)
+	case int:
+		e.encodeInt(int64(v))
+	case int32:
+		e.encodeInt(int64(v))
+	case int64:
+		e.encodeInt(v)
+	case uint:
+		e.encodeUint(uint64(v))
+	case uint32:
+		e.encodeUint(uint64(v))
+	case uint64:
+		e.encodeUint(v)
+	case float32:
+		e.encodeFloat(float64(v))
+	case float64:
+		e.encodeFloat(v)
+	case []interface{}:
+		e.encodeHeader(len(v), 0x90, 15, 0xdc)
+		for _, elem := range v {
+			if err := e.encode(elem); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		return e.encodeMap(v)
+	case document:
+		return e.encodeMap(v)
+	default:
+		// the values of other types are encoded through their JSON form.
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var generic interface{}
+		if err = unmarshalGeneric(data, &generic); err != nil {
+			return err
+		}
+		return e.encode(generic)
+	}
+	return nil
+}
+
+func (e *msgpackEncoder) encodeMap(m map[string]interface{}) error {
+	e.encodeHeader(len(m), 0x80, 15, 0xde)
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		e.encodeString(key)
+		if err := e.encode(m[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeHeader encodes the array or map header with the 'fixed' format for lengths up to 'maxFixed',
+// otherwise the 16 bit format with the 'code' or the 32 bit format with the 'code'+1.
+func (e *msgpackEncoder) encodeHeader(length int, fixed byte, maxFixed int, code byte) {
+	switch {
+	case length <= maxFixed:
+		e.buf = append(e.buf, fixed|byte(length))
+	case length <= math.MaxUint16:
+		e.buf = append(e.buf, code)
+		e.buf = appendUint16(e.buf, uint16(length))
+	default:
+		e.buf = append(e.buf, code+1)
+		e.buf = appendUint32(e.buf, uint32(length))
+	}
+}
+
+func (e *msgpackEncoder) encodeString(s string) {
+	switch length := len(s); {
+	case length <= 31:
+		e.buf = append(e.buf, 0xa0|byte(length))
+	case length <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(length))
+	case length <= math.MaxUint16:
+		e.buf = append(e.buf, 0xda)
+		e.buf = appendUint16(e.buf, uint16(length))
+	default:
+		e.buf = append(e.buf, 0xdb)
+		e.buf = appendUint32(e.buf, uint32(length))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+func (e *msgpackEncoder) encodeInt(i int64) {
+	switch {
+	case i >= 0:
+		e.encodeUint(uint64(i))
+	case i >= -32:
+		e.buf = append(e.buf, byte(i))
+	case i >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		e.buf = append(e.buf, 0xd1)
+		e.buf = appendUint16(e.buf, uint16(i))
+	case i >= math.MinInt32:
+		e.buf = append(e.buf, 0xd2)
+		e.buf = appendUint32(e.buf, uint32(i))
+	default:
+		e.buf = append(e.buf, 0xd3)
+		e.buf = appendUint64(e.buf, uint64(i))
+	}
+}
+
+func (e *msgpackEncoder) encodeUint(u uint64) {
+	switch {
+	case u <= 127:
+		e.buf = append(e.buf, byte(u))
+	case u <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		e.buf = append(e.buf, 0xcd)
+		e.buf = appendUint16(e.buf, uint16(u))
+	case u <= math.MaxUint32:
+		e.buf = append(e.buf, 0xce)
+		e.buf = appendUint32(e.buf, uint32(u))
+	default:
+		e.buf = append(e.buf, 0xcf)
+		e.buf = appendUint64(e.buf, u)
+	}
+}
+
+func (e *msgpackEncoder) encodeFloat(f float64) {
+	e.buf = append(e.buf, 0xcb)
+	e.buf = appendUint64(e.buf, math.Float64bits(f))
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	var tmp [2]byte
+	binary.BigEndian.PutUint16(tmp[:], v)
+	return append(b, tmp[:]...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var tmp [4]byte
+	binary.BigEndian.PutUint32(tmp[:], v)
+	return append(b, tmp[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var tmp [8]byte
+	binary.BigEndian.PutUint64(tmp[:], v)
+	return append(b, tmp[:]...)
+}
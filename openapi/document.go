@@ -0,0 +1,107 @@
+package openapi
+
+import (
+	"github.com/neuronlabs/jsonapi-handler/jsonschema"
+)
+
+// Version is the version of the OpenAPI specification of the generated documents.
+const Version = "3.1.0"
+
+// JSONSchemaDialect is the default JSON Schema dialect used by the generated documents.
+const JSONSchemaDialect = "https://spec.openapis.org/oas/3.1/dialect/base"
+
+// Document is the root object of the OpenAPI document.
+type Document struct {
+	OpenAPI           string               `json:"openapi"`
+	Info              Info                 `json:"info"`
+	JSONSchemaDialect string               `json:"jsonSchemaDialect,omitempty"`
+	Servers           []Server             `json:"servers,omitempty"`
+	Paths             map[string]*PathItem `json:"paths"`
+	Components        *Components          `json:"components,omitempty"`
+	Tags              []Tag                `json:"tags,omitempty"`
+}
+
+// Info is the metadata about the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is the object representing the API server.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag is the metadata of the tag used by the operations.
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem describes the operations available on a single path.
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+}
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a single operation parameter.
+type Parameter struct {
+	Ref             string  `json:"$ref,omitempty"`
+	Name            string  `json:"name,omitempty"`
+	In              string  `json:"in,omitempty"`
+	Description     string  `json:"description,omitempty"`
+	Required        bool    `json:"required,omitempty"`
+	AllowEmptyValue bool    `json:"allowEmptyValue,omitempty"`
+	Style           string  `json:"style,omitempty"`
+	Explode         *bool   `json:"explode,omitempty"`
+	Schema          *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes a single request body.
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// Response describes a single response from an API operation.
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType provides schema for the media type.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Components holds the reusable objects of the document.
+type Components struct {
+	Schemas    map[string]*Schema    `json:"schemas,omitempty"`
+	Responses  map[string]*Response  `json:"responses,omitempty"`
+	Parameters map[string]*Parameter `json:"parameters,omitempty"`
+}
+
+// Schema is the JSON Schema object used by the OpenAPI document.
+type Schema = jsonschema.Schema
+
+// Ref creates the schema that references the component schema with given 'name'.
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
@@ -0,0 +1,460 @@
+// Package openapi generates the OpenAPI 3.1 documents for the JSONAPI endpoints created by the handler Creator.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+
+	handler "github.com/neuronlabs/jsonapi-handler"
+	"github.com/neuronlabs/jsonapi-handler/errors"
+	"github.com/neuronlabs/jsonapi-handler/jsonschema"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// DocumentMediaType is the media type of the OpenAPI documents served by the Generator.
+const DocumentMediaType = "application/vnd.oai.openapi+json;version=3.1"
+
+// Generator is the OpenAPI 3.1 document generator for the endpoints created by the handler Creator.
+// The document contains the paths of all the endpoints created by the Creator's handler functions
+// with the JSONAPI request and response schemas of the models registered within the Creator's controller.
+// The Generator implements http.Handler interface and serves the generated document in JSON format.
+type Generator struct {
+	// Info is the metadata of the API.
+	Info Info
+	// Servers are the servers of the API.
+	Servers []Server
+
+	h *handler.Creator
+}
+
+// New creates the OpenAPI document Generator for the endpoints of the handler Creator 'h'.
+func New(h *handler.Creator, info Info) *Generator {
+	return &Generator{Info: info, h: h}
+}
+
+// ServeHTTP implements http.Handler interface. The document is generated on each request so that
+// it contains all the endpoints created after the Generator.
+func (g *Generator) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	data, err := json.Marshal(g.Document())
+	if err != nil {
+		log.Errorf("Marshaling OpenAPI document failed: %v", err)
+		g.h.MarshalErrors(rw, req, http.StatusInternalServerError, errors.ErrInternalError())
+		return
+	}
+	rw.Header().Set("Content-Type", DocumentMediaType)
+	rw.WriteHeader(http.StatusOK)
+	if _, err = rw.Write(data); err != nil {
+		log.Debugf("Writing OpenAPI document failed: %v", err)
+	}
+}
+
+// Document generates the OpenAPI document.
+func (g *Generator) Document() *Document {
+	doc := &Document{
+		OpenAPI:           Version,
+		Info:              g.Info,
+		JSONSchemaDialect: JSONSchemaDialect,
+		Servers:           g.Servers,
+		Paths:             map[string]*PathItem{},
+		Components: &Components{
+			Schemas:    map[string]*Schema{},
+			Responses:  errorResponses(),
+			Parameters: sharedParameters(),
+		},
+	}
+	sharedSchemas(doc.Components.Schemas)
+	for _, model := range g.h.Controller().ListModels() {
+		modelSchemas(doc.Components.Schemas, model, g.h.RequiredAttributes(model))
+	}
+
+	tags := map[string]struct{}{}
+	for _, endpoint := range g.h.Endpoints() {
+		item, ok := doc.Paths[endpoint.Path()]
+		if !ok {
+			item = &PathItem{}
+			if strings.Contains(endpoint.Path(), "{id}") {
+				item.Parameters = []*Parameter{{Ref: "#/components/parameters/id"}}
+			}
+			doc.Paths[endpoint.Path()] = item
+		}
+		operation := g.operation(endpoint)
+		switch endpoint.Type.Method() {
+		case http.MethodGet:
+			item.Get = operation
+		case http.MethodPost:
+			item.Post = operation
+		case http.MethodPatch:
+			item.Patch = operation
+		case http.MethodDelete:
+			item.Delete = operation
+		}
+		tags[endpoint.Model.Collection()] = struct{}{}
+	}
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool {
+		return doc.Tags[i].Name < doc.Tags[j].Name
+	})
+	return doc
+}
+
+func (g *Generator) operation(e handler.Endpoint) *Operation {
+	model := e.Model
+	op := &Operation{
+		OperationID: operationID(e),
+		Tags:        []string{model.Collection()},
+		Responses:   map[string]*Response{},
+	}
+	var errorStatuses []int
+	switch e.Type {
+	case handler.EndpointCreate:
+		op.Summary = fmt.Sprintf("Creates new '%s' resource.", model.Collection())
+		op.RequestBody = documentBody(Ref(SchemaName(model, "CreateDocument")))
+		op.Responses["201"] = documentResponse("Created resource.", Ref(SchemaName(model, "Document")))
+		if model.AllowClientID() {
+			op.Responses["204"] = &Response{Description: "Resource with client generated id created."}
+		}
+		errorStatuses = []int{400, 403, 404, 409, 413, 415, 422}
+	case handler.EndpointGet:
+		op.Summary = fmt.Sprintf("Gets the '%s' resource.", model.Collection())
+		op.Parameters = g.queryParameters(model, false)
+		op.Responses["200"] = documentResponse("Requested resource.", Ref(SchemaName(model, "Document")))
+		errorStatuses = []int{400, 404}
+	case handler.EndpointList:
+		op.Summary = fmt.Sprintf("Lists the '%s' resources.", model.Collection())
+		op.Parameters = g.queryParameters(model, true)
+		op.Responses["200"] = documentResponse("Requested resources.", Ref(SchemaName(model, "CollectionDocument")))
+		errorStatuses = []int{400}
+	case handler.EndpointPatch:
+		op.Summary = fmt.Sprintf("Updates the '%s' resource.", model.Collection())
+		op.RequestBody = documentBody(Ref(SchemaName(model, "UpdateDocument")))
+		op.Responses["200"] = documentResponse("Updated resource.", Ref(SchemaName(model, "Document")))
+		op.Responses["204"] = &Response{Description: "Resource updated."}
+		errorStatuses = []int{400, 403, 404, 409, 413, 415, 422}
+	case handler.EndpointDelete:
+		op.Summary = fmt.Sprintf("Deletes the '%s' resource.", model.Collection())
+		op.Responses["204"] = &Response{Description: "Resource deleted."}
+		errorStatuses = []int{400, 404}
+	case handler.EndpointGetRelated:
+		related := e.Field.Relationship().Struct()
+		op.Summary = fmt.Sprintf("Gets the '%s' related resources of the '%s' resource.", e.Field.NeuronName(), model.Collection())
+		op.Parameters = g.queryParameters(related, false)
+		schema := Ref(SchemaName(related, "Document"))
+		if e.Field.Kind() == mapping.KindRelationshipMultiple {
+			schema = Ref(SchemaName(related, "CollectionDocument"))
+		}
+		op.Responses["200"] = documentResponse("Related resources.", schema)
+		errorStatuses = []int{400, 403, 404}
+	case handler.EndpointGetRelationship:
+		op.Summary = fmt.Sprintf("Gets the '%s' relationship of the '%s' resource.", e.Field.NeuronName(), model.Collection())
+		op.Responses["200"] = documentResponse("Relationship linkage.", linkageDocument(e.Field))
+		errorStatuses = []int{400, 403, 404}
+	case handler.EndpointPatchRelationship:
+		op.Summary = fmt.Sprintf("Updates the '%s' relationship of the '%s' resource.", e.Field.NeuronName(), model.Collection())
+		op.RequestBody = documentBody(linkageDocument(e.Field))
+		op.Responses["200"] = documentResponse("Updated relationship linkage.", linkageDocument(e.Field))
+		op.Responses["204"] = &Response{Description: "Relationship updated."}
+		errorStatuses = []int{400, 403, 404, 413, 415}
+	case handler.EndpointRestore:
+		op.Summary = fmt.Sprintf("Restores the soft deleted '%s' resource.", model.Collection())
+		op.Responses["204"] = &Response{Description: "Resource restored."}
+		errorStatuses = []int{400, 403, 404}
+	case handler.EndpointAggregate:
+		op.Summary = fmt.Sprintf("Aggregates the '%s' resources.", model.Collection())
+		op.Parameters = aggregateParameters(model)
+		op.Responses["200"] = documentResponse("Aggregation results.", &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"meta": Ref(SchemaMeta)},
+			Required:   []string{"meta"},
+		})
+		errorStatuses = []int{400, 403}
+	case handler.EndpointExport:
+		op.Summary = fmt.Sprintf("Exports the '%s' resources.", model.Collection())
+		// only the fieldset of the exported model is supported.
+		op.Parameters = append([]*Parameter{fieldsetParameter(model)}, &Parameter{
+			Name:        handler.QueryParamExportFormat,
+			In:          "query",
+			Description: "Export format. Overwrites the format negotiated with the 'Accept' header.",
+			Schema:      &Schema{Type: "string", Enum: []interface{}{"ndjson", "csv"}},
+		})
+		op.Responses["200"] = &Response{Description: "Exported resources.", Content: map[string]*MediaType{
+			handler.MediaTypeNDJSON: {Schema: &Schema{Type: "string"}},
+			handler.MediaTypeCSV:    {Schema: &Schema{Type: "string"}},
+		}}
+		errorStatuses = []int{400, 403}
+	}
+	errorStatuses = append(errorStatuses, 406, 500)
+	for _, status := range errorStatuses {
+		op.Responses[strconv.Itoa(status)] = &Response{Ref: "#/components/responses/" + errorResponseName(status)}
+	}
+	return op
+}
+
+func operationID(e handler.Endpoint) string {
+	id := e.Type.String() + "_" + e.Model.Collection()
+	if e.Field != nil {
+		id += "_" + e.Field.NeuronName()
+	}
+	if e.BasePath != "" && e.BasePath != "/" {
+		id = strings.Trim(strings.Replace(e.BasePath, "/", "_", -1), "_") + "_" + id
+	}
+	return id
+}
+
+func documentBody(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]*MediaType{jsonapi.MediaType: {Schema: schema}}}
+}
+
+func documentResponse(description string, schema *Schema) *Response {
+	return &Response{Description: description, Content: map[string]*MediaType{jsonapi.MediaType: {Schema: schema}}}
+}
+
+func linkageDocument(field *mapping.StructField) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"data":  jsonschema.LinkageSchema(field),
+			"links": Ref(SchemaLinks),
+			"meta":  Ref(SchemaMeta),
+		},
+		Required: []string{"data"},
+	}
+}
+
+// queryParameters gets the query parameters for the get and list endpoints of the 'model'.
+func (g *Generator) queryParameters(model *mapping.ModelStruct, list bool) []*Parameter {
+	parameters := []*Parameter{{Ref: "#/components/parameters/include"}}
+
+	// fieldsets are available for the model and all its relationships.
+	models := []*mapping.ModelStruct{model}
+	for _, relation := range model.RelationFields() {
+		related := relation.Relationship().Struct()
+		var found bool
+		for _, m := range models {
+			if m == related {
+				found = true
+				break
+			}
+		}
+		if !found {
+			models = append(models, related)
+		}
+	}
+	for _, m := range models {
+		parameters = append(parameters, fieldsetParameter(m))
+	}
+
+	var toMany []string
+	for _, relation := range model.RelationFields() {
+		if relation.Kind() == mapping.KindRelationshipMultiple && !relation.IsHidden() {
+			toMany = append(toMany, relation.NeuronName())
+		}
+	}
+	if len(toMany) > 0 {
+		parameters = append(parameters, &Parameter{
+			Name:        handler.QueryParamMetaCounts,
+			In:          "query",
+			Description: fmt.Sprintf("Comma separated to-many relationships which related resources are counted in the relationship 'meta.count': %s.", strings.Join(toMany, ", ")),
+			Schema:      &Schema{Type: "string"},
+		})
+	}
+	if !list {
+		return parameters
+	}
+
+	var sortable []string
+	for _, field := range model.Fields() {
+		if !field.IsRelationship() && !field.IsHidden() && field.CanBeSorted() {
+			sortable = append(sortable, field.NeuronName())
+		}
+	}
+	parameters = append(parameters, &Parameter{
+		Name:        query.ParamSort,
+		In:          "query",
+		Description: fmt.Sprintf("Comma separated sorting fields. Descending order is marked with the '-' prefix. Sortable fields: %s.", strings.Join(sortable, ", ")),
+		Schema:      &Schema{Type: "string"},
+	},
+		&Parameter{Ref: "#/components/parameters/pageSize"},
+		&Parameter{Ref: "#/components/parameters/pageNumber"},
+		&Parameter{Ref: "#/components/parameters/pageLimit"},
+		&Parameter{Ref: "#/components/parameters/pageOffset"},
+		&Parameter{Ref: "#/components/parameters/pageTotal"},
+	)
+
+	explode := true
+	for _, field := range model.Fields() {
+		if field.IsRelationship() || field.IsHidden() || field.IsNoFilter() {
+			continue
+		}
+		valueSchema := &Schema{Type: "string"}
+		if !field.IsPrimary() && field.Kind() != mapping.KindForeignKey {
+			valueSchema = jsonschema.FieldSchema(field)
+		}
+		operators := map[string]*Schema{}
+		for _, op := range filterOperators(field) {
+			operators[op.Raw] = valueSchema
+		}
+		parameters = append(parameters, &Parameter{
+			Name:        fmt.Sprintf("%s[%s][%s]", query.ParamFilter, model.Collection(), field.NeuronName()),
+			In:          "query",
+			Description: fmt.Sprintf("Filters the resources by the '%s' field value with provided operator.", field.NeuronName()),
+			Style:       "deepObject",
+			Explode:     &explode,
+			Schema:      &Schema{Type: "object", Properties: operators},
+		})
+	}
+	return parameters
+}
+
+// fieldsetParameter gets the fieldset query parameter of the 'model' resources.
+func fieldsetParameter(model *mapping.ModelStruct) *Parameter {
+	var fields []string
+	for _, field := range model.Fields() {
+		if !field.IsPrimary() && !field.IsHidden() && field.Kind() != mapping.KindForeignKey {
+			fields = append(fields, field.NeuronName())
+		}
+	}
+	return &Parameter{
+		Name:        fmt.Sprintf("%s[%s]", query.ParamFields, model.Collection()),
+		In:          "query",
+		Description: fmt.Sprintf("Comma separated fieldset of the '%s' resources: %s.", model.Collection(), strings.Join(fields, ", ")),
+		Schema:      &Schema{Type: "string"},
+	}
+}
+
+// aggregateParameters gets the query parameters of the aggregate endpoint of the 'model'.
+func aggregateParameters(model *mapping.ModelStruct) []*Parameter {
+	var groupable, numeric []string
+	for _, field := range model.Fields() {
+		if field.IsHidden() || (field.Kind() != mapping.KindAttribute && field.Kind() != mapping.KindForeignKey) {
+			continue
+		}
+		groupable = append(groupable, field.NeuronName())
+		t := field.ReflectField().Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			if field.Kind() == mapping.KindAttribute {
+				numeric = append(numeric, field.NeuronName())
+			}
+		}
+	}
+	return []*Parameter{
+		{
+			Name:        handler.QueryParamAggregateGroup,
+			In:          "query",
+			Description: fmt.Sprintf("Comma separated fields that groups the resources: %s.", strings.Join(groupable, ", ")),
+			Schema:      &Schema{Type: "string"},
+		},
+		{
+			Name:            handler.QueryParamAggregateCount,
+			In:              "query",
+			Description:     "Counts the resources of each group.",
+			AllowEmptyValue: true,
+			Schema:          &Schema{Type: "boolean"},
+		},
+		{
+			Name:        handler.QueryParamAggregateSum,
+			In:          "query",
+			Description: fmt.Sprintf("Comma separated numeric attributes summed within each group: %s.", strings.Join(numeric, ", ")),
+			Schema:      &Schema{Type: "string"},
+		},
+	}
+}
+
+func filterOperators(field *mapping.StructField) []*query.Operator {
+	operators := []*query.Operator{query.OpEqual, query.OpNotEqual, query.OpIn, query.OpNotIn}
+	t := field.ReflectField().Type
+	if field.IsTime() || field.IsTimePointer() {
+		return append(operators, query.OpGreaterThan, query.OpGreaterEqual, query.OpLessThan, query.OpLessEqual, query.OpIsNull, query.OpNotNull)
+	}
+	for t.Kind() == reflect.Ptr {
+		operators = append(operators, query.OpIsNull, query.OpNotNull)
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		operators = append(operators, query.OpContains, query.OpStartsWith, query.OpEndsWith)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		operators = append(operators, query.OpGreaterThan, query.OpGreaterEqual, query.OpLessThan, query.OpLessEqual)
+	}
+	return operators
+}
+
+func sharedParameters() map[string]*Parameter {
+	integer := func(name, description string) *Parameter {
+		var minimum float64
+		return &Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "integer", Minimum: &minimum}}
+	}
+	return map[string]*Parameter{
+		"id": {
+			Name:        "id",
+			In:          "path",
+			Description: "The resource identifier.",
+			Required:    true,
+			Schema:      &Schema{Type: "string"},
+		},
+		"include": {
+			Name:        query.ParamInclude,
+			In:          "query",
+			Description: "Comma separated relationship paths of the resources to include in the compound document.",
+			Schema:      &Schema{Type: "string"},
+		},
+		"pageSize":   integer(query.ParamPageSize, "The number of resources on the page."),
+		"pageNumber": integer(query.ParamPageNumber, "The number of the page."),
+		"pageLimit":  integer(query.ParamPageLimit, "The maximum number of resources."),
+		"pageOffset": integer(query.ParamPageOffset, "The number of resources to skip."),
+		"pageTotal": {
+			Name:        handler.QueryParamPageTotal,
+			In:          "query",
+			Description: "Marks to get the total number of the resources.",
+			Schema:      &Schema{Type: "boolean"},
+		},
+	}
+}
+
+// errorResponseFuncs are the API errors used for the error responses of given status.
+var errorResponseFuncs = map[int]func() *jsonapi.Error{
+	http.StatusBadRequest:            errors.ErrBadRequest,
+	http.StatusForbidden:             errors.ErrForbidden,
+	http.StatusNotFound:              errors.ErrResourceNotFound,
+	http.StatusMethodNotAllowed:      errors.ErrMethodNotAllowed,
+	http.StatusNotAcceptable:         errors.ErrNotAcceptable,
+	http.StatusConflict:              errors.ErrResourceAlreadyExists,
+	http.StatusRequestEntityTooLarge: errors.ErrRequestBodyTooLarge,
+	http.StatusUnsupportedMediaType:  errors.ErrUnsupportedContentEncoding,
+	http.StatusUnprocessableEntity:   errors.ErrValidationFailed,
+	http.StatusInternalServerError:   errors.ErrInternalError,
+	http.StatusServiceUnavailable:    errors.ErrServiceUnavailable,
+}
+
+func errorResponseName(status int) string {
+	return strings.Replace(http.StatusText(status), " ", "", -1)
+}
+
+func errorResponses() map[string]*Response {
+	responses := map[string]*Response{}
+	for status, errFunc := range errorResponseFuncs {
+		responses[errorResponseName(status)] = &Response{
+			Description: errFunc().Title,
+			Content:     map[string]*MediaType{jsonapi.MediaType: {Schema: Ref(SchemaErrors)}},
+		}
+	}
+	return responses
+}
@@ -0,0 +1,119 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/controller"
+	mocks "github.com/neuronlabs/neuron-mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	handler "github.com/neuronlabs/jsonapi-handler"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// Blog is the model used by the openapi tests.
+type Blog struct {
+	ID    int
+	Title string
+	Posts []*Post `neuron:"foreign=BlogID"`
+}
+
+// Post is the model used by the openapi tests.
+type Post struct {
+	ID        int `neuron:"type=primary;flags=client-id"`
+	Body      string
+	Likes     *uint
+	Blog      *Blog
+	BlogID    int `neuron:"type=fk"`
+	Published *time.Time
+}
+
+func testController(t *testing.T) *controller.Controller {
+	t.Helper()
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	require.NoError(t, c.RegisterModels(Blog{}, Post{}))
+	return c
+}
+
+// TestGenerator tests the generated OpenAPI document against the golden file.
+func TestGenerator(t *testing.T) {
+	h := handler.NewC(testController(t))
+	h.Validation(Post{}).Field("body").Required()
+	h.Create(Post{})
+	h.Get(Post{})
+	h.List(Post{})
+	h.Patch(Post{})
+	h.Delete(Post{})
+	h.List(Blog{})
+	h.GetRelated(Blog{}, "posts")
+	h.GetRelationship(Post{}, "blog")
+	h.PatchRelationship(Post{}, "blog")
+	h.Aggregate(Post{})
+	h.Export(Post{})
+
+	g := New(h, Info{Title: "Blog API", Version: "1.0.0"})
+
+	t.Run("Golden", func(t *testing.T) {
+		data, err := json.MarshalIndent(g.Document(), "", "  ")
+		require.NoError(t, err)
+		data = append(data, '\n')
+
+		golden := filepath.Join("testdata", "openapi.golden.json")
+		if *update {
+			require.NoError(t, ioutil.WriteFile(golden, data, 0644))
+		}
+		expected, err := ioutil.ReadFile(golden)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(data))
+	})
+
+	t.Run("ExportParameters", func(t *testing.T) {
+		item, ok := g.Document().Paths["/posts/export"]
+		require.True(t, ok)
+		require.NotNil(t, item.Get)
+
+		var names []string
+		for _, parameter := range item.Get.Parameters {
+			names = append(names, parameter.Name)
+		}
+		assert.Equal(t, []string{"fields[posts]", handler.QueryParamExportFormat}, names)
+	})
+
+	t.Run("RequiredAttributes", func(t *testing.T) {
+		create := g.Document().Components.Schemas["posts.CreateDocument"].Properties["data"]
+		assert.Equal(t, []string{"type", "attributes"}, create.Required)
+		assert.Equal(t, []string{"body"}, create.Properties["attributes"].Required)
+	})
+
+	t.Run("ServeHTTP", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/openapi.json", nil)
+		require.NoError(t, err)
+
+		resp := httptest.NewRecorder()
+		g.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, DocumentMediaType, resp.Header().Get("Content-Type"))
+
+		doc := &Document{}
+		require.NoError(t, json.NewDecoder(bytes.NewReader(resp.Body.Bytes())).Decode(doc))
+		assert.Equal(t, Version, doc.OpenAPI)
+	})
+}
@@ -0,0 +1,155 @@
+package openapi
+
+import (
+	"github.com/neuronlabs/neuron-core/mapping"
+
+	"github.com/neuronlabs/jsonapi-handler/jsonschema"
+)
+
+// Names of the shared component schemas.
+const (
+	SchemaResource        = "jsonapi.Resource"
+	SchemaLinks           = "jsonapi.Links"
+	SchemaMeta            = "jsonapi.Meta"
+	SchemaErrors          = "jsonapi.Errors"
+	SchemaError           = "jsonapi.Error"
+	SchemaPaginationLinks = "jsonapi.PaginationLinks"
+)
+
+// SchemaName gets the name of the component schema with the 'suffix' for the 'model'.
+// The suffixes used by the generator are: 'Attributes', 'Relationships', 'Resource', 'Identifier',
+// 'CreateDocument', 'UpdateDocument', 'Document' and 'CollectionDocument'.
+func SchemaName(model *mapping.ModelStruct, suffix string) string {
+	return model.Collection() + "." + suffix
+}
+
+func sharedSchemas(schemas map[string]*Schema) {
+	schemas[SchemaLinks] = jsonschema.LinksSchema()
+	schemas[SchemaPaginationLinks] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"self":  {Type: []string{"string", "null"}},
+			"first": {Type: []string{"string", "null"}},
+			"prev":  {Type: []string{"string", "null"}},
+			"next":  {Type: []string{"string", "null"}},
+			"last":  {Type: []string{"string", "null"}},
+		},
+	}
+	schemas[SchemaMeta] = jsonschema.MetaSchema()
+	schemas[SchemaResource] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"type":          {Type: "string"},
+			"id":            {Type: "string"},
+			"attributes":    {Type: "object"},
+			"relationships": {Type: "object"},
+			"links":         Ref(SchemaLinks),
+			"meta":          Ref(SchemaMeta),
+		},
+		Required: []string{"type", "id"},
+	}
+	schemas[SchemaError] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"id":     {Type: "string"},
+			"status": {Type: "string"},
+			"code":   {Type: "string"},
+			"title":  {Type: "string"},
+			"detail": {Type: "string"},
+			"source": {Type: "object", Properties: map[string]*Schema{
+				"pointer":   {Type: "string"},
+				"parameter": {Type: "string"},
+			}},
+			"meta": Ref(SchemaMeta),
+		},
+	}
+	schemas[SchemaErrors] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"errors": {Type: "array", Items: Ref(SchemaError)},
+			"meta":   Ref(SchemaMeta),
+		},
+		Required: []string{"errors"},
+	}
+}
+
+// modelSchemas adds the component schemas of the 'model' into 'schemas'. The 'required' attributes
+// must be present within the create document.
+func modelSchemas(schemas map[string]*Schema, model *mapping.ModelStruct, required []string) {
+	schemas[SchemaName(model, "Identifier")] = jsonschema.IdentifierSchema(model)
+	schemas[SchemaName(model, "Attributes")] = jsonschema.AttributesSchema(model)
+	schemas[SchemaName(model, "Relationships")] = jsonschema.RelationshipsSchema(model, true)
+
+	schemas[SchemaName(model, "Resource")] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"type":          {Type: "string", Const: model.Collection()},
+			"id":            {Type: "string"},
+			"attributes":    Ref(SchemaName(model, "Attributes")),
+			"relationships": Ref(SchemaName(model, "Relationships")),
+			"links":         Ref(SchemaLinks),
+			"meta":          Ref(SchemaMeta),
+		},
+		Required: []string{"type", "id"},
+	}
+
+	createData := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"type":          {Type: "string", Const: model.Collection()},
+			"attributes":    Ref(SchemaName(model, "Attributes")),
+			"relationships": Ref(SchemaName(model, "Relationships")),
+		},
+		Required: []string{"type"},
+	}
+	if len(required) > 0 {
+		attributes := jsonschema.AttributesSchema(model)
+		attributes.Required = required
+		createData.Properties["attributes"] = attributes
+		createData.Required = append(createData.Required, "attributes")
+	}
+	if model.AllowClientID() {
+		createData.Properties["id"] = &Schema{Type: "string"}
+	}
+	schemas[SchemaName(model, "CreateDocument")] = &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"data": createData},
+		Required:   []string{"data"},
+	}
+
+	schemas[SchemaName(model, "UpdateDocument")] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{"data": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"type":          {Type: "string", Const: model.Collection()},
+				"id":            {Type: "string"},
+				"attributes":    Ref(SchemaName(model, "Attributes")),
+				"relationships": Ref(SchemaName(model, "Relationships")),
+			},
+			Required: []string{"type", "id"},
+		}},
+		Required: []string{"data"},
+	}
+
+	schemas[SchemaName(model, "Document")] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"data":     {OneOf: []*Schema{Ref(SchemaName(model, "Resource")), {Type: "null"}}},
+			"included": {Type: "array", Items: Ref(SchemaResource)},
+			"links":    Ref(SchemaLinks),
+			"meta":     Ref(SchemaMeta),
+		},
+		Required: []string{"data"},
+	}
+	schemas[SchemaName(model, "CollectionDocument")] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"data":     {Type: "array", Items: Ref(SchemaName(model, "Resource"))},
+			"included": {Type: "array", Items: Ref(SchemaResource)},
+			"links":    {OneOf: []*Schema{Ref(SchemaPaginationLinks), Ref(SchemaLinks)}},
+			"meta":     Ref(SchemaMeta),
+		},
+		Required: []string{"data"},
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/neuron-core/query"
+
+	handlerClass "github.com/neuronlabs/jsonapi-handler/errors/class"
+)
+
+// PageLimit sets the default limit-offset pagination with the 'limit' for given endpoint.
+// It overwrites the page number pagination set by the PageSize.
+func (l *ListHandlerCreator) PageLimit(limit int) *ListHandlerCreator {
+	l.pageSize = limit
+	l.options.limitOffsetPagination = true
+	return l
+}
+
+// MaxPageSize sets the maximum value of the 'page[size]' and 'page[limit]' query parameters for given endpoint.
+// The default page size greater than the maximum is clamped and the requests without the pagination are
+// paginated with the maximum page size. It overwrites the Creator's MaxPageSize.
+func (l *ListHandlerCreator) MaxPageSize(size int) *ListHandlerCreator {
+	l.options.maxPageSize = size
+	return l
+}
+
+// ClampPageSize sets the page sizes greater than the maximum page size to be clamped to the maximum,
+// instead of rejecting the request.
+func (l *ListHandlerCreator) ClampPageSize() *ListHandlerCreator {
+	l.options.clampPageSize = true
+	return l
+}
+
+// AllowNoPagination allows the clients to disable the pagination of given endpoint by the explicit
+// 'page[size]=0' or 'page[limit]=0' query parameter. The default pagination and the maximum page size
+// are not applied for such requests.
+func (l *ListHandlerCreator) AllowNoPagination() *ListHandlerCreator {
+	l.options.allowNoPagination = true
+	return l
+}
+
+// defaultPagination creates the default pagination for the 'pageSize' and endpoint options 'o'.
+// The default page size is limited to the maximum page size. If the maximum page size is set, the endpoints
+// without the default page size are paginated with the maximum page size.
+func (h *Creator) defaultPagination(o *endpointOptions, pageSize int) *query.Pagination {
+	if pageSize <= 0 && h.DefaultPageSize > 0 {
+		pageSize = h.DefaultPageSize
+	}
+	if maxPageSize := h.maxPageSize(o); maxPageSize > 0 && (pageSize <= 0 || int64(pageSize) > maxPageSize) {
+		pageSize = int(maxPageSize)
+	}
+	if pageSize <= 0 {
+		return nil
+	}
+	if o.limitOffsetPagination {
+		return &query.Pagination{Size: int64(pageSize), Type: query.LimitOffsetPagination}
+	}
+	return &query.Pagination{Size: int64(pageSize), Offset: 1, Type: query.PageNumberPagination}
+}
+
+// checkPageSize checks if the scope 's' pagination size doesn't exceed the maximum page size.
+// The size is clamped to the maximum if the Creator or the endpoint options 'o' allows it.
+func (h *Creator) checkPageSize(s *query.Scope, o *endpointOptions) error {
+	maxPageSize := h.maxPageSize(o)
+	if maxPageSize <= 0 || s.Pagination == nil || s.Pagination.Size <= maxPageSize {
+		if maxPageSize > 0 && s.Pagination != nil && s.Pagination.Size == 0 {
+			// the pagination without the size (i.e. offset only) is limited to the maximum page size.
+			s.Pagination.Size = maxPageSize
+		}
+		return nil
+	}
+	if o.clampPageSize || h.ClampPageSize {
+		s.Pagination.Size = maxPageSize
+		return nil
+	}
+	err := errors.NewDetf(handlerClass.QueryPageSizeOutOfRange, "page size: '%d' exceeds the maximum: '%d'", s.Pagination.Size, maxPageSize)
+	err.SetDetailsf("The page size must not be greater than: %d.", maxPageSize)
+	return err
+}
+
+// maxPageSize gets the maximum page size of the endpoint with options 'o'.
+func (h *Creator) maxPageSize(o *endpointOptions) int64 {
+	if o.maxPageSize > 0 {
+		return int64(o.maxPageSize)
+	}
+	return int64(h.MaxPageSize)
+}
+
+var scopeNoPaginationK scopeNoPagination
+
+type scopeNoPagination struct{}
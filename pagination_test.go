@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+
+	handlerClass "github.com/neuronlabs/jsonapi-handler/errors/class"
+)
+
+// TestPagination tests the maximum page size and default pagination of the list endpoint.
+func TestPagination(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, target string) *http.Request {
+		req, err := http.NewRequest("GET", target, nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req
+	}
+
+	repo, err := c.GetRepository(House{})
+	require.NoError(t, err)
+
+	housesRepo, ok := repo.(*mocks.Repository)
+	require.True(t, ok)
+
+	expectPagination := func(t *testing.T, expected *query.Pagination) {
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if expected == nil {
+				assert.Nil(t, s.Pagination)
+				return
+			}
+			if assert.NotNil(t, s.Pagination) {
+				assert.Equal(t, *expected, *s.Pagination)
+			}
+		}).Return(nil)
+		if expected != nil {
+			housesRepo.On("Count", mock.Anything, mock.Anything).Once().Return(int64(0), nil)
+		}
+	}
+
+	t.Run("MaxPageSize", func(t *testing.T) {
+		h := NewC(c)
+		h.MaxPageSize = 10
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "/houses?page[size]=11&page[number]=1&fields[houses]=address"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		payload, err := jsonapi.UnmarshalErrors(resp.Body)
+		require.NoError(t, err)
+		if assert.Len(t, payload.Errors, 1) {
+			code, err := strconv.ParseInt(payload.Errors[0].Code, 16, 32)
+			require.NoError(t, err)
+			assert.Equal(t, handlerClass.QueryPageSizeOutOfRange, errors.Class(code))
+		}
+
+		expectPagination(t, &query.Pagination{Size: 5, Offset: 10, Type: query.LimitOffsetPagination})
+		resp = httptest.NewRecorder()
+		h.ListWith(House{}).MaxPageSize(5).ClampPageSize().Handler().ServeHTTP(resp, newRequest(t, "/houses?page[limit]=8&page[offset]=10&fields[houses]=address"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		// offset only pagination is limited to the maximum page size.
+		expectPagination(t, &query.Pagination{Size: 10, Offset: 4, Type: query.LimitOffsetPagination})
+		resp = httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "/houses?page[offset]=4&fields[houses]=address"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("DefaultMaxPageSize", func(t *testing.T) {
+		h := NewC(c)
+		h.MaxPageSize = 10
+
+		// the requests without pagination are paginated with the maximum page size.
+		expectPagination(t, &query.Pagination{Size: 10, Offset: 1, Type: query.PageNumberPagination})
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "/houses?fields[houses]=address"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		// the default page size is clamped to the maximum page size.
+		expectPagination(t, &query.Pagination{Size: 10, Offset: 1, Type: query.PageNumberPagination})
+		resp = httptest.NewRecorder()
+		h.ListWith(House{}).PageSize(50).Handler().ServeHTTP(resp, newRequest(t, "/houses?fields[houses]=address"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		expectPagination(t, &query.Pagination{Size: 5, Type: query.LimitOffsetPagination})
+		resp = httptest.NewRecorder()
+		h.ListWith(House{}).PageLimit(50).MaxPageSize(5).Handler().ServeHTTP(resp, newRequest(t, "/houses?fields[houses]=address"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		// the endpoint allowing no pagination is paginated unless the client explicitly disables it.
+		expectPagination(t, &query.Pagination{Size: 10, Offset: 1, Type: query.PageNumberPagination})
+		resp = httptest.NewRecorder()
+		h.ListWith(House{}).AllowNoPagination().Handler().ServeHTTP(resp, newRequest(t, "/houses?fields[houses]=address"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("DefaultLimit", func(t *testing.T) {
+		h := NewC(c)
+
+		expectPagination(t, &query.Pagination{Size: 20, Type: query.LimitOffsetPagination})
+		resp := httptest.NewRecorder()
+		h.ListWith(House{}).PageLimit(20).Handler().ServeHTTP(resp, newRequest(t, "/houses?fields[houses]=address"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("NoPagination", func(t *testing.T) {
+		h := NewC(c)
+		h.MaxPageSize = 10
+
+		expectPagination(t, nil)
+		resp := httptest.NewRecorder()
+		h.ListWith(House{}).PageSize(5).AllowNoPagination().Handler().ServeHTTP(resp, newRequest(t, "/houses?page[size]=0&fields[houses]=address"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"net/http"
+	"net/url"
 	"reflect"
 
 	neuronErrors "github.com/neuronlabs/errors"
@@ -14,17 +15,28 @@ import (
 	"github.com/neuronlabs/jsonapi-handler/log"
 )
 
-// PatchRelationship returns JSONAPI patch relationship http.HandlerFunc for given 'model' and it's 'field' relationship.
-func (h *Creator) PatchRelationship(model interface{}, field string) http.HandlerFunc {
+// PatchRelationshipWith returns JSONAPI patch relationship EndpointHandler for given 'model' and it's 'field' relationship.
+func (h *Creator) PatchRelationshipWith(model interface{}, field string) *EndpointHandler {
 	mappedModel := h.c.MustGetModelStruct(model)
 	sField, ok := mappedModel.RelationField(field)
 	if !ok {
 		log.Panicf("Model: '%s' doesn't have field: '%s'", mappedModel.String(), field)
 	}
-	return h.handlePatchRelationship(mappedModel, sField, "")
+	return &EndpointHandler{
+		model: mappedModel,
+		handler: func(model *mapping.ModelStruct, o *endpointOptions) http.HandlerFunc {
+			return h.handlePatchRelationship(model, sField, o)
+		},
+	}
+}
+
+// PatchRelationship returns JSONAPI patch relationship http.HandlerFunc for given 'model' and it's 'field' relationship.
+func (h *Creator) PatchRelationship(model interface{}, field string) http.HandlerFunc {
+	return h.PatchRelationshipWith(model, field).Handler()
 }
 
 // PatchRelationshipHandlers returns mapping for the 'model' relation fields to related JSONAPI patch relationship http.HandlerFunc.
+// The relationship fields disallowed by the model's EndpointPolicy are skipped.
 func (h *Creator) PatchRelationshipHandlers(model interface{}, basePath ...string) map[*mapping.StructField]http.HandlerFunc {
 	mappedModel := h.c.MustGetModelStruct(model)
 	relationFields := mappedModel.RelationFields()
@@ -40,16 +52,40 @@ func (h *Creator) PatchRelationshipHandlers(model interface{}, basePath ...strin
 	}
 	// set the http.HandlerFunc for each relation field.
 	for _, relation := range relationFields {
-		handlers[relation] = h.handlePatchRelationship(mappedModel, relation, bp)
+		if !h.isEndpointAllowed(EndpointPatchRelationship, mappedModel, relation) {
+			continue
+		}
+		handlers[relation] = h.handlePatchRelationship(mappedModel, relation, &endpointOptions{basePath: bp})
 	}
 	return handlers
 }
 
-func (h *Creator) handlePatchRelationship(model *mapping.ModelStruct, field *mapping.StructField, basePath string) http.HandlerFunc {
+func (h *Creator) handlePatchRelationship(model *mapping.ModelStruct, field *mapping.StructField, o *endpointOptions) http.HandlerFunc {
+	h.registerEndpoint(EndpointPatchRelationship, model, field, o.basePath)
+
 	return func(rw http.ResponseWriter, req *http.Request) {
+		if h.endpointNotAllowed(rw, req, EndpointPatchRelationship, model, field) {
+			return
+		}
+		if err := h.parseQuery(req.Context(), req, EndpointPatchRelationship, nil, o); err != nil {
+			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
+			return
+		}
 		ctx := req.Context()
-		sID := CtxMustGetID(ctx)
-		id, err := model.Primary().ValueFromString(sID)
+		if !h.canWrite(ctx, model, field) {
+			log.Debug2f("[PATCH-RELATIONSHIP][%s] Writing field: '%s' is forbidden", model.Collection(), field.NeuronName())
+			h.marshalSourceErrors(rw, req, 0, errors.PointerError(fieldForbiddenError(field, "Writing"), "/data"))
+			return
+		}
+		sID := h.getID(req, model)
+		if sID == "" {
+			log.Debugf("[PATCH-RELATIONSHIP][%s] Empty id params", model.Collection())
+			err := errors.ErrBadRequest()
+			err.Detail = "Provided empty 'id' in url"
+			h.marshalErrors(rw, req, 0, err)
+			return
+		}
+		idValues, err := h.decodeID(model, sID)
 		if err != nil {
 			log.Debugf("Invalid 'id': '%v' in url: %v", sID, err)
 			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
@@ -58,12 +94,19 @@ func (h *Creator) handlePatchRelationship(model *mapping.ModelStruct, field *map
 
 		s := query.NewModelC(h.c, model, false)
 
+		body, err := h.requestBody(rw, req, o)
+		if err != nil {
+			log.Debugf("Reading request body for: '%s' failed: %v", model.Collection(), err)
+			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
+			return
+		}
 		var nilData bool
 		switch field.Kind() {
 		case mapping.KindRelationshipSingle:
 			v := reflect.New(field.ReflectField().Type.Elem()).Interface()
-			selected, err := jsonapi.UnmarshalWithSelectedC(h.c, req.Body, v, h.jsonapiUnmarshalOptions())
+			selected, err := jsonapi.UnmarshalWithSelectedC(h.c, body, v, h.jsonapiUnmarshalOptions())
 			if err != nil {
+				err = body.unmarshalError(err)
 				cl, ok := err.(neuronErrors.ClassError)
 				if !ok {
 					log.Errorf("Unmarshal patch-relationship content failed: %v", err)
@@ -105,8 +148,9 @@ func (h *Creator) handlePatchRelationship(model *mapping.ModelStruct, field *map
 
 			value := reflect.New(reflect.SliceOf(reflect.PtrTo(fieldType))).Interface()
 			log.Debugf("Value: %T", value)
-			err = jsonapi.UnmarshalC(h.c, req.Body, value)
+			err = jsonapi.UnmarshalC(h.c, body, value)
 			if err != nil {
+				err = body.unmarshalError(err)
 				ec, ok := err.(neuronErrors.ClassError)
 				if ok && ec.Class() == class.EncodingUnmarshalNoData {
 					nilData = true
@@ -133,8 +177,8 @@ func (h *Creator) handlePatchRelationship(model *mapping.ModelStruct, field *map
 			h.marshalErrors(rw, req, 500, errors.ErrInternalError())
 			return
 		}
-		if err = s.FilterField(query.NewFilter(model.Primary(), query.OpEqual, id)); err != nil {
-			log.Errorf("[PATCH-RELATIONSHIP][SCOPE][%s] Adding param primary filter with value: '%s' failed: %v", s.ID(), id, err)
+		if err = filterID(s, idValues); err != nil {
+			log.Errorf("[PATCH-RELATIONSHIP][SCOPE][%s] Adding param primary filter with value: '%s' failed: %v", s.ID(), sID, err)
 			h.marshalErrors(rw, req, 0, errors.ErrInternalError())
 			return
 		}
@@ -144,6 +188,15 @@ func (h *Creator) handlePatchRelationship(model *mapping.ModelStruct, field *map
 			h.marshalErrors(rw, req, 0, errors.ErrInternalError())
 			return
 		}
+		if err = h.excludeSoftDeleted(s); err != nil {
+			log.Errorf("[PATCH-RELATIONSHIP][SCOPE][%s] Adding soft deleted filter failed: %v", s.ID(), err)
+			h.marshalErrors(rw, req, 0, errors.ErrInternalError())
+			return
+		}
+		if errs := h.authorize(req, EndpointPatchRelationship, model, field, s); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
 		if log.Level() == log.LDEBUG3 {
 			log.Debug3f("Patching Relationship Scope: %s", s)
 		}
@@ -166,7 +219,7 @@ func (h *Creator) handlePatchRelationship(model *mapping.ModelStruct, field *map
 			}
 		}
 
-		if req.Header.Get("Accept") != jsonapi.MediaType {
+		if !h.acceptsContent(req) {
 			log.Debug3("No Accept Header - response with '204' - http.StatusNoContent")
 			rw.WriteHeader(http.StatusNoContent)
 			return
@@ -180,8 +233,8 @@ func (h *Creator) handlePatchRelationship(model *mapping.ModelStruct, field *map
 
 		marshalOptions := &jsonapi.MarshalOptions{Link: jsonapi.LinkOptions{
 			Type:         linkType,
-			BaseURL:      h.getBasePath(basePath),
-			RootID:       sID,
+			BaseURL:      h.getBasePath(o.basePath),
+			RootID:       url.PathEscape(sID),
 			Collection:   model.Collection(),
 			RelatedField: field.NeuronName(),
 		}}
@@ -196,7 +249,7 @@ func (h *Creator) handlePatchRelationship(model *mapping.ModelStruct, field *map
 		}
 
 		resultScope := query.NewModelC(h.c, model, false)
-		if err = resultScope.FilterField(query.NewFilter(model.Primary(), query.OpEqual, id)); err != nil {
+		if err = filterID(resultScope, idValues); err != nil {
 			log.Errorf("[PATCH-RELATIONSHIP][SCOPE][%s] Adding param primary filter to return content scope failed: %v", err)
 			h.marshalErrors(rw, req, 0, errors.ErrInternalError())
 			return
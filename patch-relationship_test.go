@@ -35,6 +35,24 @@ func TestHandlePatchRelationship(t *testing.T) {
 	err = c.RegisterModels(Human{}, House{}, Car{})
 	require.NoError(t, err)
 
+	t.Run("EndpointBodySize", func(t *testing.T) {
+		h := NewC(c)
+		h.MaxBodySize = 1024
+
+		req, err := http.NewRequest("PATCH", "/houses/1/relationships/owner", strings.NewReader(`{"data":{"type":"humen","id":"1"}}`))
+		require.NoError(t, err)
+
+		req.Header.Add("Content-Type", jsonapi.MediaType)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		req = req.WithContext(context.WithValue(context.Background(), IDKey, "1"))
+
+		resp := httptest.NewRecorder()
+		h.PatchRelationshipWith(House{}, "owner").MaxBodySize(10).Handler().ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	})
+
 	t.Run("RootNotFound", func(t *testing.T) {
 		h := NewC(c)
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/neuronlabs/jsonapi"
 	"github.com/neuronlabs/neuron-core/mapping"
@@ -24,20 +25,34 @@ func (h *Creator) PatchWith(model interface{}) *EndpointHandler {
 
 // Patch returns JSONAPI http.HandlerFunc for the 'model'.
 func (h *Creator) Patch(model interface{}) http.HandlerFunc {
-	return h.handlePatch(h.c.MustGetModelStruct(model), "")
+	return h.handlePatch(h.c.MustGetModelStruct(model), &endpointOptions{})
 }
 
-func (h *Creator) handlePatch(model *mapping.ModelStruct, basePath string) http.HandlerFunc {
+func (h *Creator) handlePatch(model *mapping.ModelStruct, o *endpointOptions) http.HandlerFunc {
+	h.registerEndpoint(EndpointPatch, model, nil, o.basePath)
 	return func(rw http.ResponseWriter, req *http.Request) {
+		if h.endpointNotAllowed(rw, req, EndpointPatch, model, nil) {
+			return
+		}
+		if err := h.parseQuery(req.Context(), req, EndpointPatch, nil, o); err != nil {
+			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
+			return
+		}
 		var buf *bytes.Buffer
-		reader := io.Reader(req.Body)
+		body, err := h.requestBody(rw, req, o)
+		if err != nil {
+			log.Debugf("Reading request body for: '%s' failed: %v", model.Collection(), err)
+			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
+			return
+		}
+		reader := io.Reader(body)
 		// for debug purpose prepare the tee reader
 		if log.Level().IsAllowed(log.LDEBUG3) {
 			buf = &bytes.Buffer{}
-			reader = io.TeeReader(req.Body, buf)
+			reader = io.TeeReader(body, buf)
 		}
 
-		id := CtxMustGetID(req.Context())
+		id := h.getID(req, model)
 		if id == "" {
 			log.Debugf("[PATCH][%s] Empty id params", model.Collection())
 			err := errors.ErrBadRequest()
@@ -46,7 +61,7 @@ func (h *Creator) handlePatch(model *mapping.ModelStruct, basePath string) http.
 			return
 		}
 
-		idURLValue, err := model.Primary().ValueFromString(id)
+		idValues, err := h.decodeID(model, id)
 		if err != nil {
 			err := errors.ErrInvalidURI()
 			err.Detail = "Provided invalid 'id' value in url"
@@ -57,6 +72,7 @@ func (h *Creator) handlePatch(model *mapping.ModelStruct, basePath string) http.
 
 		s, err := jsonapi.UnmarshalSingleScopeC(h.c, reader, model, h.jsonapiUnmarshalOptions())
 		if err != nil {
+			err = body.unmarshalError(err)
 			if log.Level().IsAllowed(log.LDEBUG3) {
 				log.Debug3f("Unmarshal value: '%s' failed: %v", buf.String(), err)
 			}
@@ -64,22 +80,60 @@ func (h *Creator) handlePatch(model *mapping.ModelStruct, basePath string) http.
 			return
 		}
 
-		idDataValue, err := h.getFieldValue(s.Value, s.Struct().Primary())
-		if err != nil {
-			h.marshalErrors(rw, req, 0, errors.MapError(err)...)
-			return
-		}
-
-		if idDataValue != idURLValue {
+		if idValue, dataValue, ok := conflictingIDValue(s, idValues); ok {
 			err := errors.ErrIDConflict()
-			err.Detail = fmt.Sprintf("URL id value: '%s' doesn't match input data id value: '%v'", id, idDataValue)
+			if idValue.Field.IsPrimary() {
+				err.Detail = fmt.Sprintf("URL id value: '%s' doesn't match input data id value: '%v'", id, dataValue)
+			} else {
+				err.Detail = fmt.Sprintf("URL id: '%s' field: '%s' value doesn't match input data value: '%v'", id, idValue.Field.NeuronName(), dataValue)
+			}
 			log.Debug2f("[PATCH][%s] %s", model.Collection(), err.Detail)
 			h.marshalErrors(rw, req, 0, err)
 			return
 		}
 
+		// the resources identified by compound id needs to be filtered by the non primary id fields.
+		for _, idValue := range idValues {
+			if idValue.Field.IsPrimary() {
+				continue
+			}
+			if err = s.FilterField(query.NewFilter(idValue.Field, query.OpEqual, idValue.Value)); err != nil {
+				log.Errorf("[PATCH][SCOPE][%s] Adding compound id filter failed: %v", s.ID(), err)
+				h.marshalErrors(rw, req, 0, errors.ErrInternalError())
+				return
+			}
+		}
+
+		// the soft deleted resources are not patched.
+		if field, ok := h.softDeletes[model]; ok {
+			if err = s.FilterField(query.NewFilter(field, query.OpIsNull)); err != nil {
+				log.Errorf("[PATCH][SCOPE][%s] Adding soft delete filter failed: %v", s.ID(), err)
+				h.marshalErrors(rw, req, 0, errors.ErrInternalError())
+				return
+			}
+		}
+
 		ctx := req.Context()
 
+		if errs := h.authorize(req, EndpointPatch, model, nil, s); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
+
+		// check if all the unmarshaled fields are writable.
+		if errs := h.unwritableFields(ctx, s); len(errs) > 0 {
+			log.Debug2f("[PATCH][%s][%s] forbidden fields", model.Collection(), s.ID())
+			h.marshalSourceErrors(rw, req, http.StatusForbidden, errs...)
+			return
+		}
+
+		// validate the unmarshaled attributes.
+		if errs := h.validate(ctx, s, false); len(errs) > 0 {
+			log.Debug2f("[PATCH][%s][%s] validation failed", model.Collection(), s.ID())
+			h.marshalSourceErrors(rw, req, 0, errs...)
+			return
+		}
+
 		// execute the before patcher API hook if given model defines it.
 		if beforePatchHook, ok := Hooks.getHook(model, BeforePatch); ok {
 			if err = beforePatchHook(ctx, s); err != nil {
@@ -102,14 +156,14 @@ func (h *Creator) handlePatch(model *mapping.ModelStruct, basePath string) http.
 			}
 		}
 
-		if req.Header.Get("Accept") != jsonapi.MediaType {
+		if !h.acceptsContent(req) {
 			log.Debug3f("[PATCH][%s][%s] No 'Accept' Header - returning HTTP Status: No Content - 204", model.Collection(), s.ID())
 			rw.WriteHeader(http.StatusNoContent)
 			return
 		}
 
 		getScope := query.NewModelC(h.c, model, false)
-		if err = getScope.FilterField(query.NewFilter(model.Primary(), query.OpEqual, idDataValue)); err != nil {
+		if err = filterID(getScope, idValues); err != nil {
 			log.Errorf("[PATCH][SCOPE][%s] Adding param primary filter to return content scope failed: %v", err)
 			h.marshalErrors(rw, req, 0, errors.ErrInternalError())
 			return
@@ -120,16 +174,13 @@ func (h *Creator) handlePatch(model *mapping.ModelStruct, basePath string) http.
 			rw.WriteHeader(http.StatusNoContent)
 			return
 		}
-		if basePath == "" {
-			basePath = h.basePath()
-		}
-
 		options := &jsonapi.MarshalOptions{Link: jsonapi.LinkOptions{
 			Type:       jsonapi.ResourceLink,
-			BaseURL:    basePath,
-			RootID:     id,
+			BaseURL:    h.getBasePath(o.basePath),
+			RootID:     url.PathEscape(id),
 			Collection: model.Collection(),
 		}}
+		setDocumentMeta(getScope, o)
 		h.marshalScope(getScope, rw, req, http.StatusOK, options)
 	}
 }
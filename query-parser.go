@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/neuron-core/annotation"
+	"github.com/neuronlabs/neuron-core/query"
+
+	handlerClass "github.com/neuronlabs/jsonapi-handler/errors/class"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// queryCapability is the set of query parameters supported by the endpoint.
+type queryCapability int
+
+// Enumerated query capabilities.
+const (
+	capInclude queryCapability = 1 << iota
+	capFields
+	capFilter
+	capSort
+	capPagination
+	capLinks
+	capLanguage
+	capDeleted
+	capSearch
+	capAggregate
+	capExport
+	capCounts
+)
+
+// queryCapabilities gets the query parameter capabilities of the 'endpoint'. The endpoints without
+// any capabilities treats all the query parameters as unsupported.
+func queryCapabilities(endpoint EndpointType) queryCapability {
+	switch endpoint {
+	case EndpointList:
+		return capInclude | capFields | capFilter | capSort | capPagination | capLinks | capLanguage | capDeleted | capSearch | capCounts
+	case EndpointGet:
+		return capInclude | capFields | capLinks | capLanguage | capDeleted | capCounts
+	case EndpointGetRelated:
+		return capFields | capLinks
+	case EndpointAggregate:
+		return capFilter | capDeleted | capSearch | capAggregate
+	case EndpointExport:
+		return capFields | capFilter | capSort | capLanguage | capDeleted | capSearch | capExport
+	}
+	return 0
+}
+
+// parseQuery parses the 'req' query parameters into the scope 's' with the capabilities of the 'endpoint'.
+// The query errors are aggregated up to the QueryErrorsLimit. The unsupported query parameters are rejected
+// only in the StrictQueriesMode. The scope 's' might be nil for the endpoints without capabilities.
+func (h *Creator) parseQuery(ctx context.Context, req *http.Request, endpoint EndpointType, s *query.Scope, o *endpointOptions) error {
+	capabilities := queryCapabilities(endpoint)
+	if !o.allowDeletedFilter {
+		capabilities &^= capDeleted
+	}
+	if capabilities&capSearch != 0 && h.endpointSearcher(s, o) == nil {
+		capabilities &^= capSearch
+	}
+	q := req.URL.Query()
+
+	var multiErrors errors.MultiError
+	// the included scopes must be set before the fieldsets and filters of the included collections.
+	// The values of the repeated include parameters are merged.
+	if included, ok := q[query.ParamInclude]; ok && capabilities&capInclude != 0 {
+		var includes []string
+		for _, value := range included {
+			includes = append(includes, strings.Split(value, annotation.Separator)...)
+		}
+		includes = h.readableIncludes(ctx, s.Struct(), includes)
+		log.Debug2f("Including fields: %v", includes)
+		if err := s.IncludeFields(includes...); err != nil {
+			if multiErrors, err = appendQueryError(multiErrors, err); err != nil {
+				return err
+			}
+		} else {
+			setIncludedPaths(s, includes)
+		}
+	}
+	if languages, ok := q[query.ParamLanguage]; ok && capabilities&capLanguage != 0 && len(languages) == 1 {
+		if err := h.queryParameterLanguage(s, languages[0]); err != nil {
+			if multiErrors, err = appendQueryError(multiErrors, err); err != nil {
+				return err
+			}
+		}
+	}
+
+	for key, values := range q {
+		if len(multiErrors) >= h.QueryErrorsLimit && h.QueryErrorsLimit > 0 {
+			log.Debug2f("Reached single query error limit: %v", multiErrors)
+			return multiErrors
+		}
+
+		select {
+		case <-ctx.Done():
+			ctxErr := ctx.Err()
+			if ctxErr == context.DeadlineExceeded {
+				err := errors.NewDet(handlerClass.QueryTimeout, context.DeadlineExceeded.Error())
+				err.SetDetails("The query connection had timed out")
+				return err
+			}
+			return ctxErr
+		default:
+		}
+
+		capability := parameterCapability(key)
+		if capability == capDeleted && capabilities&capDeleted == 0 {
+			// the deleted filter not allowed by the endpoint is parsed as a common filter.
+			capability = capFilter
+		}
+		if capability == 0 || capabilities&capability == 0 {
+			if err := h.defaultQueryParameter(key); err != nil {
+				multiErrors = append(multiErrors, err.(errors.ClassError))
+			}
+			continue
+		}
+
+		if key == query.ParamInclude {
+			continue
+		}
+		if len(values) > 1 {
+			err := errors.NewDetf(handlerClass.QueryInvalidParameter, "provided invalid query parameters")
+			err.SetDetailsf("The query parameter: '%s' used more than once.", key)
+			multiErrors = append(multiErrors, err)
+			continue
+		}
+
+		value := values[0]
+		var err error
+		switch key {
+		case query.ParamLanguage:
+			continue
+		case query.ParamPageLimit:
+			err = preparePagination(s, key, value, ppLimit)
+		case query.ParamPageOffset:
+			err = preparePagination(s, key, value, ppOffset)
+		case query.ParamPageNumber:
+			err = preparePagination(s, key, value, ppPageNumber)
+		case query.ParamPageSize:
+			err = preparePagination(s, key, value, ppPageSize)
+		case QueryParamPageTotal:
+			err = h.queryParameterPageTotal(s, key, value)
+		case query.ParamSort:
+			err = h.queryParameterSort(ctx, s, value, o)
+		case QueryParamLinks:
+			err = h.queryParameterLinks(s, key, value)
+		case QueryParamFilterExpression:
+			err = h.queryParameterFilterExpression(ctx, s, value, o)
+		case QueryParamSearch:
+			err = h.queryParameterSearch(ctx, s, value, o)
+		case QueryParamAggregateGroup, QueryParamAggregateCount, QueryParamAggregateSum:
+			err = h.queryParameterAggregate(ctx, s, key, value)
+		case QueryParamExportFormat:
+			err = h.queryParameterExportFormat(s, value)
+		case QueryParamMetaCounts:
+			err = h.queryParameterMetaCounts(ctx, s, value)
+		default:
+			switch capability {
+			case capFilter:
+				err = h.queryParameterFilters(ctx, s, key, value, o)
+			case capFields:
+				err = h.queryParameterFields(s, key, value)
+			case capDeleted:
+				err = h.queryParameterDeleted(s, value)
+			}
+		}
+
+		if err != nil {
+			if multiErrors, err = appendQueryError(multiErrors, err); err != nil {
+				return err
+			}
+		}
+	}
+	if len(multiErrors) > 0 {
+		log.Debug2f("Multiple errors: %v", multiErrors)
+		return multiErrors
+	}
+	return nil
+}
+
+// appendQueryError appends the query error 'err' to the 'multiErrors'. The errors that are neither
+// class nor multi errors are returned back.
+func appendQueryError(multiErrors errors.MultiError, err error) (errors.MultiError, error) {
+	switch et := err.(type) {
+	case errors.ClassError:
+		return append(multiErrors, et), nil
+	case errors.MultiError:
+		return append(multiErrors, et...), nil
+	}
+	return multiErrors, err
+}
+
+// parameterCapability gets the query capability required by the query parameter 'key'.
+func parameterCapability(key string) queryCapability {
+	switch {
+	case key == query.ParamInclude:
+		return capInclude
+	case key == query.ParamLanguage:
+		return capLanguage
+	case key == query.ParamPageLimit, key == query.ParamPageOffset, key == query.ParamPageNumber,
+		key == query.ParamPageSize, key == QueryParamPageTotal:
+		return capPagination
+	case key == query.ParamSort:
+		return capSort
+	case key == QueryParamLinks:
+		return capLinks
+	case key == QueryParamDeleted:
+		return capDeleted
+	case key == QueryParamSearch:
+		return capSearch
+	case key == QueryParamAggregateGroup, key == QueryParamAggregateCount, key == QueryParamAggregateSum:
+		return capAggregate
+	case key == QueryParamExportFormat:
+		return capExport
+	case key == QueryParamMetaCounts:
+		return capCounts
+	case strings.HasPrefix(key, query.ParamFilter):
+		return capFilter
+	case strings.HasPrefix(key, query.ParamFields):
+		return capFields
+	}
+	return 0
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+)
+
+// TestParseQuery tests the query parameters parsing shared by the endpoints.
+func TestParseQuery(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, method, target string) *http.Request {
+		req, err := http.NewRequest(method, target, nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req.WithContext(context.WithValue(context.Background(), IDKey, "1"))
+	}
+
+	t.Run("Strict", func(t *testing.T) {
+		h := NewC(c)
+		h.StrictQueriesMode = true
+
+		resp := httptest.NewRecorder()
+		h.GetRelationship(House{}, "owner").ServeHTTP(resp, newRequest(t, "GET", "/houses/1/relationships/owner?unknown=1"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		resp = httptest.NewRecorder()
+		h.Delete(House{}).ServeHTTP(resp, newRequest(t, "DELETE", "/houses/1?include=owner"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		// sorting is not supported by the get endpoint.
+		resp = httptest.NewRecorder()
+		h.Get(House{}).ServeHTTP(resp, newRequest(t, "GET", "/houses/1?sort=address"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Duplicated", func(t *testing.T) {
+		h := NewC(c)
+		for _, handler := range []http.HandlerFunc{h.Get(House{}), h.List(House{})} {
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, newRequest(t, "GET", "/houses?fields[houses]=address&fields[houses]=owner"))
+			assert.Equal(t, http.StatusBadRequest, resp.Code)
+		}
+	})
+
+	t.Run("RepeatedInclude", func(t *testing.T) {
+		h := NewC(c)
+
+		// the values of the repeated include parameters are merged.
+		s := query.NewModelC(c, c.MustGetModelStruct(House{}), false)
+		req := newRequest(t, "GET", "/houses/1?include=owner&include=owner.houses")
+		require.NoError(t, h.parseQuery(req.Context(), req, EndpointGet, s, &endpointOptions{}))
+		assert.Len(t, s.IncludedScopes(), 2)
+	})
+
+	t.Run("ErrorsLimit", func(t *testing.T) {
+		h := NewC(c)
+		h.QueryErrorsLimit = 0
+
+		req := newRequest(t, "GET", "/houses/1?fields[houses]=unknown&fields[humen]=unknown")
+		err := h.parseQuery(req.Context(), req, EndpointGet, query.NewModelC(c, c.MustGetModelStruct(House{}), false), &endpointOptions{})
+		if assert.Error(t, err) {
+			multiErr, ok := err.(errors.MultiError)
+			require.True(t, ok)
+			assert.Len(t, multiErr, 2)
+		}
+
+		// the include errors are aggregated with the other query errors.
+		req = newRequest(t, "GET", "/houses/1?include=unknown&fields[houses]=unknown")
+		err = h.parseQuery(req.Context(), req, EndpointGet, query.NewModelC(c, c.MustGetModelStruct(House{}), false), &endpointOptions{})
+		if assert.Error(t, err) {
+			multiErr, ok := err.(errors.MultiError)
+			require.True(t, ok)
+			assert.Len(t, multiErr, 2)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		h := NewC(c)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		req := newRequest(t, "GET", "/houses/1?fields[houses]=address")
+		err := h.parseQuery(ctx, req, EndpointGet, query.NewModelC(c, c.MustGetModelStruct(House{}), false), &endpointOptions{})
+		assert.Equal(t, context.Canceled, err)
+	})
+}
@@ -0,0 +1,379 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/annotation"
+	"github.com/neuronlabs/neuron-core/class"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+
+	handlerErrors "github.com/neuronlabs/jsonapi-handler/errors"
+	handlerClass "github.com/neuronlabs/jsonapi-handler/errors/class"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// QueryParamMetaCounts is the query parameter of the List and Get endpoints with the comma separated to-many
+// relationships, which related resources are counted i.e.: 'meta[counts]=comments,likes'. The number of the related
+// resources is written in the 'count' meta of each resource relationship object. The related resources are not
+// loaded - they are counted by the repository implementing the Aggregator. Otherwise the related resources are
+// counted by the handler only if the Creator's MaxAggregateResources is set, and the requests are rejected with
+// the '501' status if not. The many to many relationships of the soft deleted or authorized related models
+// are always counted by the handler.
+const QueryParamMetaCounts = "meta[counts]"
+
+// Counts sets the to-many relationships counted by default by given endpoint handler, when the request doesn't
+// contain the 'meta[counts]' query parameter. Panics if any of the fields is not a to-many relationship.
+func (e *EndpointHandler) Counts(relationships ...string) *EndpointHandler {
+	e.options.counts = countedRelationships(e.model, relationships)
+	return e
+}
+
+// Counts sets the to-many relationships counted by default by given list endpoint, when the request doesn't
+// contain the 'meta[counts]' query parameter. Panics if any of the fields is not a to-many relationship.
+func (l *ListHandlerCreator) Counts(relationships ...string) *ListHandlerCreator {
+	l.options.counts = countedRelationships(l.model, relationships)
+	return l
+}
+
+func countedRelationships(model *mapping.ModelStruct, relationships []string) []*mapping.StructField {
+	fields := make([]*mapping.StructField, len(relationships))
+	for i, name := range relationships {
+		field, ok := model.RelationField(name)
+		if !ok || field.Kind() != mapping.KindRelationshipMultiple {
+			log.Panicf("Counted relationship: '%s' is not a to-many relationship of the model: '%s'", name, model.Collection())
+		}
+		fields[i] = field
+	}
+	return fields
+}
+
+// relationshipCounts are the numbers of the related resources of the counted relationship fields,
+// mapped by the string value of the root resources primary field.
+type relationshipCounts map[*mapping.StructField]map[string]int64
+
+// queryParameterMetaCounts sets the relationship fields counted for the scope 's' resources.
+func (h *Creator) queryParameterMetaCounts(ctx context.Context, s *query.Scope, value string) error {
+	counts := relationshipCounts{}
+	for _, name := range strings.Split(value, annotation.Separator) {
+		field, ok := s.Struct().RelationField(name)
+		if !ok || field.Kind() != mapping.KindRelationshipMultiple {
+			err := errors.NewDetf(handlerClass.QueryInvalidParameter, "invalid counted relationship: '%s'", name)
+			err.SetDetailsf("The query parameter: '%s' field: '%s' is not a to-many relationship of the collection: '%s'.", QueryParamMetaCounts, name, s.Struct().Collection())
+			return err
+		}
+		if !h.canRead(ctx, s.Struct(), field) {
+			return readForbiddenError(name)
+		}
+		counts[field] = nil
+	}
+	s.StoreSet(scopeRelationshipCountsK, counts)
+	return nil
+}
+
+// defaultRelationshipCounts sets the readable relationships counted by default by the endpoint with options 'o',
+// if the scope 's' query didn't define them.
+func (h *Creator) defaultRelationshipCounts(ctx context.Context, s *query.Scope, o *endpointOptions) {
+	if len(o.counts) == 0 {
+		return
+	}
+	if _, ok := s.StoreGet(scopeRelationshipCountsK); ok {
+		return
+	}
+	counts := relationshipCounts{}
+	for _, field := range o.counts {
+		if h.canRead(ctx, s.Struct(), field) {
+			counts[field] = nil
+		}
+	}
+	if len(counts) > 0 {
+		s.StoreSet(scopeRelationshipCountsK, counts)
+	}
+}
+
+// countRelationships counts the related resources of the relationships set by the 'meta[counts]' query parameter
+// or the endpoint defaults for the scope 's' resources. The related resources scopes are authorized
+// for the 'endpoint' with the relationship field.
+func (h *Creator) countRelationships(req *http.Request, endpoint EndpointType, s *query.Scope, o *endpointOptions) []*jsonapi.Error {
+	ctx := req.Context()
+	h.defaultRelationshipCounts(ctx, s, o)
+	v, ok := s.StoreGet(scopeRelationshipCountsK)
+	if !ok {
+		return nil
+	}
+	counts := v.(relationshipCounts)
+	primaries := scopePrimaries(s)
+	for field := range counts {
+		fieldCounts := map[string]int64{}
+		for _, primary := range primaries {
+			fieldCounts[mapping.StringValues(primary, nil)[0]] = 0
+		}
+		counts[field] = fieldCounts
+		if len(primaries) == 0 {
+			continue
+		}
+		if errs := h.countRelated(req, endpoint, field, primaries, fieldCounts); errs != nil {
+			return errs
+		}
+		log.Debug3f("[COUNTS][%s] Relationship: '%s' counts: %v", field.Struct().Collection(), field.NeuronName(), fieldCounts)
+	}
+	return nil
+}
+
+// countRelated counts the related resources of the relationship 'field' for the root resource 'primaries'.
+// The related resources, or the join model rows for the many to many relationships, are counted with a single
+// query grouped by the foreign key filtered with the root 'primaries'. The query is aggregated by the repository
+// if it implements the Aggregator. Otherwise the foreign key values of the related resources are listed and
+// counted by the handler, the same way as the in-memory aggregation - only if the Creator's MaxAggregateResources
+// is set and the related resources don't exceed it. The soft deleted and unauthorized related resources of the many
+// to many relationships are always counted by the handler, where an additional query lists the accessible related
+// resources primaries.
+func (h *Creator) countRelated(req *http.Request, endpoint EndpointType, field *mapping.StructField, primaries []interface{}, counts map[string]int64) []*jsonapi.Error {
+	relationship := field.Relationship()
+	if relationship.IsManyToMany() {
+		if _, ok := h.softDeletes[relationship.Struct()]; ok || h.Authorizer != nil {
+			// the join model rows doesn't contain the deletion timestamp nor the authorized values of the related resources.
+			return h.countJoinedAccessible(req, endpoint, field, primaries, counts)
+		}
+	}
+
+	s, err := h.relatedCountScope(relationship, primaries)
+	if err != nil {
+		return handlerErrors.MapError(err)
+	}
+	if !relationship.IsManyToMany() {
+		if errs := h.authorize(req, endpoint, field.Struct(), field, s); errs != nil {
+			return errs
+		}
+	}
+	aggregated, err := h.aggregateRelated(req.Context(), s, relationship.ForeignKey(), counts)
+	if err == nil && !aggregated {
+		err = h.listCountRelated(req.Context(), s, relationship.ForeignKey(), counts)
+	}
+	if err != nil {
+		return handlerErrors.MapError(err)
+	}
+	return nil
+}
+
+// relatedCountScope creates the scope of the counted related resources of the 'relationship', or the join model
+// rows for the many to many relationships, filtered by the foreign key within the root resources 'primaries'.
+func (h *Creator) relatedCountScope(relationship *mapping.Relationship, primaries []interface{}) (*query.Scope, error) {
+	model := relationship.Struct()
+	if relationship.IsManyToMany() {
+		model = relationship.JoinModel()
+	}
+	s := query.NewModelC(h.c, model, true)
+	if err := s.FilterField(query.NewFilter(relationship.ForeignKey(), query.OpIn, primaries...)); err != nil {
+		return nil, err
+	}
+	if !relationship.IsManyToMany() {
+		if err := h.excludeSoftDeleted(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// aggregateRelated counts the scope 's' resources grouped by the 'foreignKey' within the repository implementing
+// the Aggregator. Returns false if the repository doesn't implement the aggregation.
+func (h *Creator) aggregateRelated(ctx context.Context, s *query.Scope, foreignKey *mapping.StructField, counts map[string]int64) (bool, error) {
+	repo, err := h.c.GetRepository(s.Struct())
+	if err != nil {
+		return false, err
+	}
+	aggregator, ok := repo.(Aggregator)
+	if !ok {
+		return false, nil
+	}
+	results, err := aggregator.Aggregate(ctx, s, &Aggregation{Groups: []*mapping.StructField{foreignKey}, Count: true})
+	if err != nil {
+		if e, ok := err.(errors.ClassError); ok && e.Class() == handlerClass.RepositoryNotImplementsAggregator {
+			log.Debug2f("[COUNTS][SCOPE][%s] Repository doesn't implement the aggregation: %v", s.ID(), err)
+			return false, nil
+		}
+		return false, err
+	}
+	for _, result := range results {
+		if result.Count == nil {
+			continue
+		}
+		key := mapping.StringValues(result.Group[foreignKey.NeuronName()], nil)[0]
+		if _, ok := counts[key]; ok {
+			counts[key] = int64(*result.Count)
+		}
+	}
+	return true, nil
+}
+
+// listCountRelated lists the 'foreignKey' values of the scope 's' resources and counts them for each root resource.
+func (h *Creator) listCountRelated(ctx context.Context, s *query.Scope, foreignKey *mapping.StructField, counts map[string]int64) error {
+	values, err := h.listFieldValues(ctx, s, foreignKey)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < values.Len(); i++ {
+		single := values.Index(i)
+		if single.Kind() == reflect.Ptr {
+			if single.IsNil() {
+				continue
+			}
+			single = single.Elem()
+		}
+		key := mapping.StringValues(single.FieldByIndex(foreignKey.ReflectField().Index).Interface(), nil)[0]
+		if _, ok := counts[key]; ok {
+			counts[key]++
+		}
+	}
+	return nil
+}
+
+// countJoinedAccessible counts the related resources of the many to many relationship 'field' for the root
+// resource 'primaries', that are not soft deleted and are authorized. The join model rows of the root resources
+// are listed, and the accessible related resources identified by them are listed with the second query.
+func (h *Creator) countJoinedAccessible(req *http.Request, endpoint EndpointType, field *mapping.StructField, primaries []interface{}, counts map[string]int64) []*jsonapi.Error {
+	ctx := req.Context()
+	relationship := field.Relationship()
+	joined, err := h.relatedCountScope(relationship, primaries)
+	if err != nil {
+		return handlerErrors.MapError(err)
+	}
+	relatedKey := relationship.ManyToManyForeignKey()
+	rows, err := h.listFieldValues(ctx, joined, relationship.ForeignKey(), relatedKey)
+	if err != nil {
+		return handlerErrors.MapError(err)
+	}
+	var related []interface{}
+	for i := 0; i < rows.Len(); i++ {
+		single := reflect.Indirect(rows.Index(i))
+		if single.IsValid() {
+			related = append(related, single.FieldByIndex(relatedKey.ReflectField().Index).Interface())
+		}
+	}
+	if len(related) == 0 {
+		return nil
+	}
+
+	relatedModel := relationship.Struct()
+	relatedScope := query.NewModelC(h.c, relatedModel, true)
+	if err = relatedScope.FilterField(query.NewFilter(relatedModel.Primary(), query.OpIn, related...)); err == nil {
+		err = h.excludeSoftDeleted(relatedScope)
+	}
+	if err != nil {
+		return handlerErrors.MapError(err)
+	}
+	if errs := h.authorize(req, endpoint, field.Struct(), field, relatedScope); errs != nil {
+		return errs
+	}
+	if _, err = h.listFieldValues(ctx, relatedScope, relatedModel.Primary()); err != nil {
+		return handlerErrors.MapError(err)
+	}
+	accessible := map[interface{}]struct{}{}
+	for _, primary := range scopePrimaries(relatedScope) {
+		accessible[primary] = struct{}{}
+	}
+
+	for i := 0; i < rows.Len(); i++ {
+		single := reflect.Indirect(rows.Index(i))
+		if !single.IsValid() {
+			continue
+		}
+		if _, ok := accessible[single.FieldByIndex(relatedKey.ReflectField().Index).Interface()]; !ok {
+			continue
+		}
+		key := mapping.StringValues(single.FieldByIndex(relationship.ForeignKey().ReflectField().Index).Interface(), nil)[0]
+		if _, ok := counts[key]; ok {
+			counts[key]++
+		}
+	}
+	return nil
+}
+
+// listFieldValues lists the 'fields' of the scope 's' resources counted in memory and returns the reflect value
+// of the listed slice. The number of the listed resources is limited by the Creator's MaxAggregateResources.
+// The scope without results returns an empty slice.
+func (h *Creator) listFieldValues(ctx context.Context, s *query.Scope, fields ...*mapping.StructField) (reflect.Value, error) {
+	fieldset := make([]interface{}, len(fields))
+	for i, field := range fields {
+		fieldset[i] = field
+	}
+	if err := s.SetFields(fieldset...); err != nil {
+		return reflect.Value{}, err
+	}
+	if err := h.listAggregated(ctx, s); err != nil {
+		if e, ok := err.(errors.ClassError); !ok || e.Class() != class.QueryValueNoResult {
+			return reflect.Value{}, err
+		}
+	}
+	return reflect.ValueOf(s.Value).Elem(), nil
+}
+
+// scopePrimaries gets the primary field values of the scope 's' resources.
+func scopePrimaries(s *query.Scope) []interface{} {
+	v := reflect.ValueOf(s.Value)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil
+	}
+	v = v.Elem()
+	index := s.Struct().Primary().ReflectField().Index
+	if v.Kind() != reflect.Slice {
+		return []interface{}{v.FieldByIndex(index).Interface()}
+	}
+	primaries := make([]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		single := v.Index(i)
+		if single.Kind() == reflect.Ptr {
+			if single.IsNil() {
+				continue
+			}
+			single = single.Elem()
+		}
+		primaries = append(primaries, single.FieldByIndex(index).Interface())
+	}
+	return primaries
+}
+
+// hasRelationshipCounts checks if the scope 's' resources have the counted relationships.
+func hasRelationshipCounts(s *query.Scope) bool {
+	_, ok := s.StoreGet(scopeRelationshipCountsK)
+	return ok
+}
+
+// relationshipCountsProcessor returns the document processor that sets the 'count' meta of the counted relationships
+// of the scope 's' resources. The relationship objects not marshaled within the fieldset are created with the meta only.
+func relationshipCountsProcessor(s *query.Scope) func(resource resourceObject) {
+	v, _ := s.StoreGet(scopeRelationshipCountsK)
+	counted, _ := v.(relationshipCounts)
+	return func(resource resourceObject) {
+		if resource.collection() != s.Struct().Collection() {
+			return
+		}
+		id, _ := resource["id"].(string)
+		for field, counts := range counted {
+			count, ok := counts[id]
+			if !ok {
+				continue
+			}
+			relationships, _ := resource["relationships"].(map[string]interface{})
+			if relationships == nil {
+				relationships = map[string]interface{}{}
+				resource["relationships"] = relationships
+			}
+			relationship, _ := relationships[field.NeuronName()].(map[string]interface{})
+			if relationship == nil {
+				relationship = map[string]interface{}{}
+				relationships[field.NeuronName()] = relationship
+			}
+			setMeta(relationship, map[string]interface{}{"count": count})
+		}
+	}
+}
+
+var scopeRelationshipCountsK scopeRelationshipCounts
+
+type scopeRelationshipCounts struct{}
@@ -0,0 +1,403 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+
+	handlerClass "github.com/neuronlabs/jsonapi-handler/errors/class"
+)
+
+// TestRelationshipCounts tests the 'meta[counts]' query parameter of the list and get endpoints.
+func TestRelationshipCounts(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, target string) *http.Request {
+		req, err := http.NewRequest("GET", target, nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req.WithContext(context.WithValue(context.Background(), IDKey, "1"))
+	}
+
+	mockRepositories := func(t *testing.T) (humansRepo, housesRepo *mocks.Repository) {
+		repo, err := c.GetRepository(Human{})
+		require.NoError(t, err)
+		humansRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		repo, err = c.GetRepository(House{})
+		require.NoError(t, err)
+		housesRepo, ok = repo.(*mocks.Repository)
+		require.True(t, ok)
+		return humansRepo, housesRepo
+	}
+
+	listHouses := func(t *testing.T, housesRepo *mocks.Repository, owners []interface{}, houses ...*House) {
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			// the related resources are counted with a single query for all the root resources.
+			if assert.Len(t, s.ForeignFilters, 1) && assert.Len(t, s.ForeignFilters[0].Values, 1) {
+				assert.Equal(t, query.OpIn, s.ForeignFilters[0].Values[0].Operator)
+				assert.ElementsMatch(t, owners, s.ForeignFilters[0].Values[0].Values)
+			}
+			assert.Len(t, s.Fieldset, 1)
+			assert.Contains(t, s.Fieldset, "owner_id")
+			// the related resources counted in memory are limited.
+			if assert.NotNil(t, s.Pagination) {
+				assert.Equal(t, int64(11), s.Pagination.Size)
+			}
+
+			v, ok := s.Value.(*[]*House)
+			require.True(t, ok)
+			*v = append(*v, houses...)
+		}).Return(nil)
+	}
+
+	type resource struct {
+		ID            string                            `json:"id"`
+		Relationships map[string]map[string]interface{} `json:"relationships"`
+	}
+
+	t.Run("List", func(t *testing.T) {
+		humansRepo, housesRepo := mockRepositories(t)
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*[]*Human)
+			require.True(t, ok)
+			*v = append(*v, &Human{ID: 1, Name: "Alice"}, &Human{ID: 2, Name: "Bob"})
+		}).Return(nil)
+		listHouses(t, housesRepo, []interface{}{1, 2}, &House{ID: 1, OwnerID: 1}, &House{ID: 2, OwnerID: 1}, &House{ID: 3, OwnerID: 1})
+
+		h := NewC(c)
+		h.MaxAggregateResources = 10
+		resp := httptest.NewRecorder()
+		h.List(Human{}).ServeHTTP(resp, newRequest(t, "/humen?fields[humen]=name&meta[counts]=houses"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var payload struct {
+			Data []resource `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &payload))
+		require.Len(t, payload.Data, 2)
+
+		counts := map[string]interface{}{}
+		for _, human := range payload.Data {
+			if assert.Contains(t, human.Relationships, "houses") {
+				counts[human.ID] = human.Relationships["houses"]["meta"].(map[string]interface{})["count"]
+				// the related resources are not marshaled.
+				assert.NotContains(t, human.Relationships["houses"], "data")
+			}
+		}
+		assert.Equal(t, map[string]interface{}{"1": float64(3), "2": float64(0)}, counts)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		humansRepo, housesRepo := mockRepositories(t)
+		humansRepo.On("Get", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*Human)
+			require.True(t, ok)
+			v.ID = 1
+			v.Name = "Alice"
+		}).Return(nil)
+		listHouses(t, housesRepo, []interface{}{1}, &House{ID: 1, OwnerID: 1}, &House{ID: 2, OwnerID: 1})
+
+		h := NewC(c)
+		h.MaxAggregateResources = 10
+		resp := httptest.NewRecorder()
+		h.GetWith(Human{}).Counts("houses").Handler().ServeHTTP(resp, newRequest(t, "/humen/1?fields[humen]=name"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var payload struct {
+			Data resource `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &payload))
+		if assert.Contains(t, payload.Data.Relationships, "houses") {
+			assert.Equal(t, map[string]interface{}{"count": float64(2)}, payload.Data.Relationships["houses"]["meta"])
+		}
+	})
+
+	t.Run("ResourceMeta", func(t *testing.T) {
+		humansRepo, housesRepo := mockRepositories(t)
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*[]*Human)
+			require.True(t, ok)
+			*v = append(*v, &Human{ID: 1, Name: "Alice"})
+		}).Return(nil)
+		listHouses(t, housesRepo, []interface{}{1}, &House{ID: 1, OwnerID: 1})
+
+		h := NewC(c)
+		h.MaxAggregateResources = 10
+		h.RegisterResourceMeta(Human{}, ResourceMetaProviderFunc(func(ctx context.Context, model *mapping.ModelStruct, value interface{}) map[string]interface{} {
+			return map[string]interface{}{"editable": true}
+		}))
+		resp := httptest.NewRecorder()
+		h.List(Human{}).ServeHTTP(resp, newRequest(t, "/humen?fields[humen]=name&meta[counts]=houses"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		// both the resource meta and the relationship counts are set on the same resource objects.
+		var payload struct {
+			Data []struct {
+				resource
+				Meta map[string]interface{} `json:"meta"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &payload))
+		if assert.Len(t, payload.Data, 1) {
+			assert.Equal(t, map[string]interface{}{"editable": true}, payload.Data[0].Meta)
+			if assert.Contains(t, payload.Data[0].Relationships, "houses") {
+				assert.Equal(t, map[string]interface{}{"count": float64(1)}, payload.Data[0].Relationships["houses"]["meta"])
+			}
+		}
+	})
+
+	t.Run("Aggregator", func(t *testing.T) {
+		c, err := neuron.NewController(config.Default())
+		require.NoError(t, err)
+
+		err = c.RegisterRepository("aggregator", &config.Repository{DriverName: aggregatorDriverName})
+		require.NoError(t, err)
+
+		err = c.RegisterModels(Human{}, House{})
+		require.NoError(t, err)
+
+		repo, err := c.GetRepository(Human{})
+		require.NoError(t, err)
+		humansRepo, ok := repo.(*aggregatorRepository)
+		require.True(t, ok)
+
+		repo, err = c.GetRepository(House{})
+		require.NoError(t, err)
+		housesRepo, ok := repo.(*aggregatorRepository)
+		require.True(t, ok)
+
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*[]*Human)
+			require.True(t, ok)
+			*v = append(*v, &Human{ID: 1}, &Human{ID: 2})
+		}).Return(nil)
+
+		count := 3
+		housesRepo.On("Aggregate", mock.Anything, mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if assert.Len(t, s.ForeignFilters, 1) && assert.Len(t, s.ForeignFilters[0].Values, 1) {
+				assert.Equal(t, query.OpIn, s.ForeignFilters[0].Values[0].Operator)
+				assert.ElementsMatch(t, []interface{}{1, 2}, s.ForeignFilters[0].Values[0].Values)
+			}
+			a, ok := args[2].(*Aggregation)
+			require.True(t, ok)
+
+			assert.True(t, a.Count)
+			if assert.Len(t, a.Groups, 1) {
+				assert.Equal(t, "owner_id", a.Groups[0].NeuronName())
+			}
+		}).Return([]*AggregateResult{{Group: map[string]interface{}{"owner_id": 1}, Count: &count}}, nil)
+
+		h := NewC(c)
+		resp := httptest.NewRecorder()
+		h.List(Human{}).ServeHTTP(resp, newRequest(t, "/humen?fields[humen]=name&meta[counts]=houses"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var payload struct {
+			Data []resource `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &payload))
+
+		counts := map[string]interface{}{}
+		for _, human := range payload.Data {
+			if assert.Contains(t, human.Relationships, "houses") {
+				counts[human.ID] = human.Relationships["houses"]["meta"].(map[string]interface{})["count"]
+			}
+		}
+		assert.Equal(t, map[string]interface{}{"1": float64(3), "2": float64(0)}, counts)
+	})
+
+	t.Run("ManyToManySoftDeleted", func(t *testing.T) {
+		c, err := neuron.NewController(config.Default())
+		require.NoError(t, err)
+
+		err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+		require.NoError(t, err)
+
+		err = c.RegisterModels(Shelter{}, ShelterPet{}, Pet{})
+		require.NoError(t, err)
+
+		getRepository := func(model interface{}) *mocks.Repository {
+			repo, err := c.GetRepository(model)
+			require.NoError(t, err)
+			mockRepo, ok := repo.(*mocks.Repository)
+			require.True(t, ok)
+			return mockRepo
+		}
+
+		getRepository(Shelter{}).On("Get", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*Shelter)
+			require.True(t, ok)
+			v.ID = 1
+		}).Return(nil)
+
+		getRepository(ShelterPet{}).On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if assert.Len(t, s.ForeignFilters, 1) && assert.Len(t, s.ForeignFilters[0].Values, 1) {
+				assert.Equal(t, "shelter_id", s.ForeignFilters[0].StructField.NeuronName())
+				assert.Equal(t, []interface{}{1}, s.ForeignFilters[0].Values[0].Values)
+			}
+			v, ok := s.Value.(*[]*ShelterPet)
+			require.True(t, ok)
+			*v = append(*v, &ShelterPet{ID: 1, ShelterID: 1, PetID: 1}, &ShelterPet{ID: 2, ShelterID: 1, PetID: 2})
+		}).Return(nil)
+
+		// the soft deleted pets are not counted.
+		getRepository(Pet{}).On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if assert.Len(t, s.PrimaryFilters, 1) && assert.Len(t, s.PrimaryFilters[0].Values, 1) {
+				assert.ElementsMatch(t, []interface{}{1, 2}, s.PrimaryFilters[0].Values[0].Values)
+			}
+			if assert.Len(t, s.AttributeFilters, 1) && assert.Len(t, s.AttributeFilters[0].Values, 1) {
+				assert.Equal(t, "removed_at", s.AttributeFilters[0].StructField.NeuronName())
+				assert.Equal(t, query.OpIsNull, s.AttributeFilters[0].Values[0].Operator)
+			}
+			v, ok := s.Value.(*[]*Pet)
+			require.True(t, ok)
+			*v = append(*v, &Pet{ID: 2})
+		}).Return(nil)
+
+		h := NewC(c)
+		h.MaxAggregateResources = 10
+		h.RegisterSoftDelete(Pet{}, "removed_at")
+
+		resp := httptest.NewRecorder()
+		h.GetWith(Shelter{}).Counts("pets").Handler().ServeHTTP(resp, newRequest(t, "/shelters/1?fields[shelters]=id"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var payload struct {
+			Data resource `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &payload))
+		if assert.Contains(t, payload.Data.Relationships, "pets") {
+			assert.Equal(t, float64(1), payload.Data.Relationships["pets"]["meta"].(map[string]interface{})["count"])
+		}
+	})
+
+	t.Run("Authorized", func(t *testing.T) {
+		authorizer := AuthorizerFunc(func(req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField, s *query.Scope) error {
+			if s.Struct() != c.MustGetModelStruct(House{}) {
+				return nil
+			}
+			assert.Equal(t, EndpointList, endpoint)
+			if assert.NotNil(t, field) {
+				assert.Equal(t, "houses", field.NeuronName())
+			}
+			address, ok := s.Struct().Attribute("address")
+			require.True(t, ok)
+			return s.FilterField(query.NewFilter(address, query.OpNotEqual, "Hidden"))
+		})
+
+		humansRepo, housesRepo := mockRepositories(t)
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*[]*Human)
+			require.True(t, ok)
+			*v = append(*v, &Human{ID: 1})
+		}).Return(nil)
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			// the counted related resources are restricted by the authorizer.
+			if assert.Len(t, s.AttributeFilters, 1) {
+				assert.Equal(t, "address", s.AttributeFilters[0].StructField.NeuronName())
+			}
+		}).Return(nil)
+
+		h := NewC(c)
+		h.MaxAggregateResources = 10
+		h.Authorizer = authorizer
+		resp := httptest.NewRecorder()
+		h.List(Human{}).ServeHTTP(resp, newRequest(t, "/humen?fields[humen]=name&meta[counts]=houses"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		housesRepo.AssertExpectations(t)
+
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*[]*Human)
+			require.True(t, ok)
+			*v = append(*v, &Human{ID: 1})
+		}).Return(nil)
+
+		h.Authorizer = AuthorizerFunc(func(req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField, s *query.Scope) error {
+			if field != nil {
+				return errors.NewDet(handlerClass.AccessForbidden, "houses forbidden")
+			}
+			return nil
+		})
+		resp = httptest.NewRecorder()
+		h.List(Human{}).ServeHTTP(resp, newRequest(t, "/humen?fields[humen]=name&meta[counts]=houses"))
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("InMemoryLimit", func(t *testing.T) {
+		humansRepo, housesRepo := mockRepositories(t)
+		getHuman := func() {
+			humansRepo.On("Get", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+				v, ok := args[1].(*query.Scope).Value.(*Human)
+				require.True(t, ok)
+				v.ID = 1
+			}).Return(nil)
+		}
+
+		// the related resources are not counted in memory by default.
+		getHuman()
+		h := NewC(c)
+		resp := httptest.NewRecorder()
+		h.Get(Human{}).ServeHTTP(resp, newRequest(t, "/humen/1?fields[humen]=name&meta[counts]=houses"))
+		assert.Equal(t, http.StatusNotImplemented, resp.Code)
+
+		getHuman()
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*[]*House)
+			require.True(t, ok)
+			*v = append(*v, &House{ID: 1, OwnerID: 1}, &House{ID: 2, OwnerID: 1})
+		}).Return(nil)
+
+		h.MaxAggregateResources = 1
+		resp = httptest.NewRecorder()
+		h.Get(Human{}).ServeHTTP(resp, newRequest(t, "/humen/1?fields[humen]=name&meta[counts]=houses"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		humansRepo.AssertExpectations(t)
+		housesRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		h := NewC(c)
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "/houses?meta[counts]=owner"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
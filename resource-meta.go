@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+)
+
+// ResourceMetaProvider is the interface used to provide the meta of the resource objects.
+type ResourceMetaProvider interface {
+	// ResourceMeta gets the meta of the 'model' resource 'value' i.e. the permissions of the user within the 'ctx'.
+	ResourceMeta(ctx context.Context, model *mapping.ModelStruct, value interface{}) map[string]interface{}
+}
+
+// ResourceMetaProviderFunc is the function that implements ResourceMetaProvider interface.
+type ResourceMetaProviderFunc func(ctx context.Context, model *mapping.ModelStruct, value interface{}) map[string]interface{}
+
+// ResourceMeta implements ResourceMetaProvider interface.
+func (f ResourceMetaProviderFunc) ResourceMeta(ctx context.Context, model *mapping.ModelStruct, value interface{}) map[string]interface{} {
+	return f(ctx, model, value)
+}
+
+// RelationshipMetaProvider is the interface used to provide the meta of the resource relationship objects.
+type RelationshipMetaProvider interface {
+	// RelationshipMeta gets the meta of the relationship 'field' of the 'model' resource 'value'.
+	RelationshipMeta(ctx context.Context, model *mapping.ModelStruct, value interface{}, field *mapping.StructField) map[string]interface{}
+}
+
+// RelationshipMetaProviderFunc is the function that implements RelationshipMetaProvider interface.
+type RelationshipMetaProviderFunc func(ctx context.Context, model *mapping.ModelStruct, value interface{}, field *mapping.StructField) map[string]interface{}
+
+// RelationshipMeta implements RelationshipMetaProvider interface.
+func (f RelationshipMetaProviderFunc) RelationshipMeta(ctx context.Context, model *mapping.ModelStruct, value interface{}, field *mapping.StructField) map[string]interface{} {
+	return f(ctx, model, value, field)
+}
+
+// ResourceMetaer is the interface implemented by the models that provide the meta of their resource objects.
+type ResourceMetaer interface {
+	ResourceMeta(ctx context.Context) map[string]interface{}
+}
+
+// RelationshipMetaer is the interface implemented by the models that provide the meta of their relationship objects.
+// The 'relationship' is the neuron name of the relationship field.
+type RelationshipMetaer interface {
+	RelationshipMeta(ctx context.Context, relationship string) map[string]interface{}
+}
+
+var (
+	resourceMetaerType     = reflect.TypeOf((*ResourceMetaer)(nil)).Elem()
+	relationshipMetaerType = reflect.TypeOf((*RelationshipMetaer)(nil)).Elem()
+)
+
+// RegisterResourceMeta registers the 'provider' of the 'model' resource objects meta. The meta of the provider
+// overwrites the values of the ResourceMetaer model method.
+func (h *Creator) RegisterResourceMeta(model interface{}, provider ResourceMetaProvider) {
+	h.resourceMetas[h.c.MustGetModelStruct(model)] = provider
+}
+
+// RegisterRelationshipMeta registers the 'provider' of the 'model' relationship objects meta. The meta of the
+// provider overwrites the values of the RelationshipMetaer model method.
+func (h *Creator) RegisterRelationshipMeta(model interface{}, provider RelationshipMetaProvider) {
+	h.relationMetas[h.c.MustGetModelStruct(model)] = provider
+}
+
+// hasResourceMeta checks if any of the scope 's' or its included scopes models have the resource meta.
+func (h *Creator) hasResourceMeta(s *query.Scope) bool {
+	for _, scope := range append([]*query.Scope{s}, s.IncludedScopes()...) {
+		model := scope.Struct()
+		if _, ok := h.resourceMetas[model]; ok {
+			return true
+		}
+		if _, ok := h.relationMetas[model]; ok {
+			return true
+		}
+		if t := reflect.PtrTo(model.Type()); t.Implements(resourceMetaerType) || t.Implements(relationshipMetaerType) {
+			return true
+		}
+	}
+	return false
+}
+
+// metaResource is the model value of the marshaled resource object.
+type metaResource struct {
+	model *mapping.ModelStruct
+	value interface{}
+}
+
+// resourceMetaProcessor returns the document processor that sets the meta of the resource and relationship objects
+// of the scope 's' and its included scopes.
+func (h *Creator) resourceMetaProcessor(ctx context.Context, s *query.Scope) func(resource resourceObject) {
+	resources := map[string]metaResource{}
+	indexMetaResources(resources, s.Struct(), s.Value)
+	for _, included := range s.IncludedScopes() {
+		for _, value := range included.IncludedValues() {
+			indexMetaResources(resources, included.Struct(), value)
+		}
+	}
+	return func(resource resourceObject) {
+		id, _ := resource["id"].(string)
+		r, ok := resources[resource.collection()+"/"+id]
+		if !ok {
+			return
+		}
+		setMeta(resource, h.resourceMeta(ctx, r))
+		for name, relationship := range resource.relationships() {
+			if field, ok := r.model.RelationField(name); ok {
+				setMeta(relationship, h.relationshipMeta(ctx, r, field))
+			}
+		}
+	}
+}
+
+func (h *Creator) resourceMeta(ctx context.Context, r metaResource) map[string]interface{} {
+	var meta map[string]interface{}
+	if metaer, ok := r.value.(ResourceMetaer); ok {
+		meta = mergeMeta(meta, metaer.ResourceMeta(ctx))
+	}
+	if provider, ok := h.resourceMetas[r.model]; ok {
+		meta = mergeMeta(meta, provider.ResourceMeta(ctx, r.model, r.value))
+	}
+	return meta
+}
+
+func (h *Creator) relationshipMeta(ctx context.Context, r metaResource, field *mapping.StructField) map[string]interface{} {
+	var meta map[string]interface{}
+	if metaer, ok := r.value.(RelationshipMetaer); ok {
+		meta = mergeMeta(meta, metaer.RelationshipMeta(ctx, field.NeuronName()))
+	}
+	if provider, ok := h.relationMetas[r.model]; ok {
+		meta = mergeMeta(meta, provider.RelationshipMeta(ctx, r.model, r.value, field))
+	}
+	return meta
+}
+
+// indexMetaResources adds the 'model' resources of the 'value' into 'resources' mapped by their collection and id.
+// The 'value' might be a single model instance or a pointer to the slice of the instances.
+func indexMetaResources(resources map[string]metaResource, model *mapping.ModelStruct, value interface{}) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return
+	}
+	if v.Elem().Kind() == reflect.Slice {
+		for i := 0; i < v.Elem().Len(); i++ {
+			single := v.Elem().Index(i)
+			if single.Kind() != reflect.Ptr {
+				single = single.Addr()
+			}
+			indexMetaResources(resources, model, single.Interface())
+		}
+		return
+	}
+	primary := v.Elem().FieldByIndex(model.Primary().ReflectField().Index).Interface()
+	resources[model.Collection()+"/"+mapping.StringValues(primary, nil)[0]] = metaResource{model: model, value: value}
+}
+
+// setMeta merges the 'meta' into the 'meta' member of the JSON:API 'object'.
+func setMeta(object map[string]interface{}, meta map[string]interface{}) {
+	if len(meta) == 0 {
+		return
+	}
+	current, _ := object["meta"].(map[string]interface{})
+	object["meta"] = mergeMeta(current, meta)
+}
+
+// mergeMeta sets the 'values' into the 'meta' map. The 'meta' is created if nil.
+func mergeMeta(meta, values map[string]interface{}) map[string]interface{} {
+	if len(values) == 0 {
+		return meta
+	}
+	if meta == nil {
+		meta = make(map[string]interface{}, len(values))
+	}
+	for key, value := range values {
+		meta[key] = value
+	}
+	return meta
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+)
+
+// Post is the model that provides its resource meta.
+type Post struct {
+	ID    int
+	Title string
+}
+
+// ResourceMeta implements ResourceMetaer interface.
+func (p *Post) ResourceMeta(ctx context.Context) map[string]interface{} {
+	return map[string]interface{}{"editable": ctx.Value(IDKey) == "1"}
+}
+
+// TestResourceMeta tests the meta of the resource and relationship objects.
+func TestResourceMeta(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{}, Post{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, target string) *http.Request {
+		req, err := http.NewRequest("GET", target, nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req.WithContext(context.WithValue(context.Background(), IDKey, "1"))
+	}
+
+	type payload struct {
+		Data []struct {
+			ID            string                            `json:"id"`
+			Meta          map[string]interface{}            `json:"meta"`
+			Relationships map[string]map[string]interface{} `json:"relationships"`
+		} `json:"data"`
+	}
+
+	t.Run("Providers", func(t *testing.T) {
+		repo, err := c.GetRepository(House{})
+		require.NoError(t, err)
+
+		housesRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		h := NewC(c)
+		h.RegisterResourceMeta(House{}, ResourceMetaProviderFunc(func(ctx context.Context, model *mapping.ModelStruct, value interface{}) map[string]interface{} {
+			house, ok := value.(*House)
+			require.True(t, ok)
+			return map[string]interface{}{"permissions": map[string]interface{}{"canEdit": house.ID == 1}}
+		}))
+		h.RegisterRelationshipMeta(House{}, RelationshipMetaProviderFunc(func(ctx context.Context, model *mapping.ModelStruct, value interface{}, field *mapping.StructField) map[string]interface{} {
+			return map[string]interface{}{"field": field.NeuronName()}
+		}))
+		housesRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*[]*House)
+			require.True(t, ok)
+			*v = append(*v, &House{ID: 1, Owner: &Human{ID: 3}}, &House{ID: 2})
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "/houses?fields[houses]=address,owner"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		p := payload{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+		if assert.Len(t, p.Data, 2) {
+			assert.Equal(t, map[string]interface{}{"permissions": map[string]interface{}{"canEdit": true}}, p.Data[0].Meta)
+			assert.Equal(t, map[string]interface{}{"permissions": map[string]interface{}{"canEdit": false}}, p.Data[1].Meta)
+			if assert.Contains(t, p.Data[0].Relationships, "owner") {
+				assert.Equal(t, map[string]interface{}{"field": "owner"}, p.Data[0].Relationships["owner"]["meta"])
+			}
+		}
+		housesRepo.AssertExpectations(t)
+	})
+
+	t.Run("ModelMethod", func(t *testing.T) {
+		repo, err := c.GetRepository(Post{})
+		require.NoError(t, err)
+
+		postsRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		h := NewC(c)
+		postsRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			v, ok := args[1].(*query.Scope).Value.(*[]*Post)
+			require.True(t, ok)
+			*v = append(*v, &Post{ID: 1, Title: "First"})
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.List(Post{}).ServeHTTP(resp, newRequest(t, "/posts"))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		p := payload{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+		if assert.Len(t, p.Data, 1) {
+			assert.Equal(t, map[string]interface{}{"editable": true}, p.Data[0].Meta)
+		}
+		postsRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/neuron-core/annotation"
+	"github.com/neuronlabs/neuron-core/class"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// QueryParamSearch is the query parameter of the list endpoints that contains the search text.
+// The parameter is supported only by the endpoints with the Searcher.
+const QueryParamSearch = "search"
+
+// Searcher is the interface used by the list endpoints to search the resources matching the 'search' query parameter.
+// The implementation should narrow the scope 's' by adding the filters i.e. repository specific full-text filter.
+type Searcher interface {
+	Search(ctx context.Context, s *query.Scope, text string) error
+}
+
+// SearcherFunc is the function that implements Searcher interface.
+type SearcherFunc func(ctx context.Context, s *query.Scope, text string) error
+
+// Search implements Searcher interface.
+func (f SearcherFunc) Search(ctx context.Context, s *query.Scope, text string) error {
+	return f(ctx, s, text)
+}
+
+// FieldSearcher is the Searcher that matches the search text with any of the model attributes.
+// When used by the list endpoint, the attributes unreadable by the model's FieldPolicy are not searched.
+// If none of the attributes is readable the search is forbidden.
+type FieldSearcher struct {
+	// Fields are the neuron names of the searched attributes.
+	Fields []string
+	// Operator is the filter operator used to match the attributes. By default the OpContains is used.
+	Operator *query.Operator
+}
+
+// Search implements Searcher interface. The attribute filters are combined with the logical 'or' operator.
+// As the repositories doesn't support the 'or' filters, the search of multiple attributes is resolved the same
+// way as the filter expression 'or' groups - each attribute costs an additional repository query listing
+// the primary values of the matching resources. The attribute queries contain all the filters of the searched
+// scope, thus only the resources accessible by the request are matched. The primary values of all the resources
+// matching any of the attributes are loaded into memory, regardless of the list pagination. The number of the
+// matching resources is not limited unless the Creator's MaxFilterExpressionResources is set - in that case the
+// searches matching more resources are rejected with the '400' status. Use the repository specific Searcher
+// for the large collections.
+func (f *FieldSearcher) Search(ctx context.Context, s *query.Scope, text string) error {
+	operator := f.Operator
+	if operator == nil {
+		operator = query.OpContains
+	}
+	group := &filterGroup{or: true}
+	for _, name := range f.Fields {
+		attr, ok := s.Struct().Attribute(name)
+		if !ok {
+			return errors.NewDetf(class.QueryFilterUnknownField, "search attribute: '%s' not found within model: '%s'", name, s.Struct().Collection())
+		}
+		group.groups = append(group.groups, &filterGroup{filters: []*query.FilterField{query.NewFilter(attr, operator, text)}})
+	}
+	switch len(group.groups) {
+	case 0:
+		return nil
+	case 1:
+		return s.FilterField(group.groups[0].filters[0])
+	}
+	addFilterGroups(s, group)
+	return nil
+}
+
+// RegisterSearcher registers the 'searcher' used by the list endpoints of the 'model'.
+func (h *Creator) RegisterSearcher(model interface{}, searcher Searcher) {
+	h.searchers[h.c.MustGetModelStruct(model)] = searcher
+}
+
+// SearchFields sets the attributes searched with the 'search' query parameter of given endpoint. The search text
+// is matched with the 'contains' operator. The search of multiple attributes lists the primary values of all the
+// matching resources - see FieldSearcher. If the Creator's MaxFilterExpressionResources is set, the searches matching
+// more resources are rejected with the '400' status. Panics if any of the fields is not the model's attribute.
+func (l *ListHandlerCreator) SearchFields(fields ...string) *ListHandlerCreator {
+	for _, field := range fields {
+		if _, ok := l.model.Attribute(field); !ok {
+			log.Panicf("Search attribute: '%s' not found within model: '%s'", field, l.model.Collection())
+		}
+	}
+	l.options.searcher = &FieldSearcher{Fields: fields}
+	return l
+}
+
+// Searcher sets the 'searcher' of given endpoint. It overwrites the searcher registered for the model.
+// Use the repository specific searcher to search the large collections by multiple attributes.
+func (l *ListHandlerCreator) Searcher(searcher Searcher) *ListHandlerCreator {
+	l.options.searcher = searcher
+	return l
+}
+
+// endpointSearcher gets the searcher of the 'model' list endpoint with the options 'o'.
+func (h *Creator) endpointSearcher(s *query.Scope, o *endpointOptions) Searcher {
+	if o.searcher != nil {
+		return o.searcher
+	}
+	return h.searchers[s.Struct()]
+}
+
+// queryParameterSearch searches the scope 's' resources with the endpoint searcher.
+func (h *Creator) queryParameterSearch(ctx context.Context, s *query.Scope, value string, o *endpointOptions) error {
+	if value == "" {
+		return nil
+	}
+	searcher := h.endpointSearcher(s, o)
+	if fieldSearcher, ok := searcher.(*FieldSearcher); ok {
+		readable, err := h.readableFieldSearcher(ctx, s.Struct(), fieldSearcher)
+		if err != nil {
+			return err
+		}
+		searcher = readable
+	}
+	return searcher.Search(ctx, s, value)
+}
+
+// readableFieldSearcher gets the copy of the 'searcher' that searches only the attributes of the 'model' readable
+// within the 'ctx'.
+func (h *Creator) readableFieldSearcher(ctx context.Context, model *mapping.ModelStruct, searcher *FieldSearcher) (*FieldSearcher, error) {
+	if _, ok := h.fieldPolicies[model]; !ok {
+		return searcher, nil
+	}
+	readable := &FieldSearcher{Operator: searcher.Operator}
+	for _, name := range searcher.Fields {
+		if attr, ok := model.Attribute(name); ok && !h.canRead(ctx, model, attr) {
+			log.Debug2f("[%s] Forbidden search attribute: '%s' skipped", model.Collection(), name)
+			continue
+		}
+		readable.Fields = append(readable.Fields, name)
+	}
+	if len(readable.Fields) == 0 && len(searcher.Fields) > 0 {
+		return nil, readForbiddenError(strings.Join(searcher.Fields, annotation.Separator))
+	}
+	return readable, nil
+}
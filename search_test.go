@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+)
+
+// TestSearch tests the list search query parameter.
+func TestSearch(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, target string) *http.Request {
+		req, err := http.NewRequest("GET", target, nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req
+	}
+
+	repo, err := c.GetRepository(House{})
+	require.NoError(t, err)
+
+	mockRepo, ok := repo.(*mocks.Repository)
+	require.True(t, ok)
+
+	t.Run("SingleField", func(t *testing.T) {
+		h := NewC(c)
+		mockRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if assert.Len(t, s.AttributeFilters, 1) && assert.Len(t, s.AttributeFilters[0].Values, 1) {
+				assert.Equal(t, query.OpContains, s.AttributeFilters[0].Values[0].Operator)
+				assert.Equal(t, []interface{}{"Main"}, s.AttributeFilters[0].Values[0].Values)
+			}
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.ListWith(House{}).SearchFields("address").Handler().ServeHTTP(resp, newRequest(t, "/houses?fields[houses]=address&search=Main"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("MultipleFields", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterSearcher(Human{}, &FieldSearcher{Fields: []string{"name", "age"}, Operator: query.OpStartsWith})
+
+		repo, err := c.GetRepository(Human{})
+		require.NoError(t, err)
+
+		humansRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		listHumans := func(check func(s *query.Scope), humans ...*Human) {
+			humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+				s, ok := args[1].(*query.Scope)
+				require.True(t, ok)
+
+				check(s)
+				v, ok := s.Value.(*[]*Human)
+				require.True(t, ok)
+				*v = append(*v, humans...)
+			}).Return(nil)
+		}
+		listHumans(func(s *query.Scope) {
+			if assert.Len(t, s.AttributeFilters, 1) {
+				assert.Equal(t, "name", s.AttributeFilters[0].StructField.NeuronName())
+			}
+			// the number of the matching resources is not limited by default.
+			assert.Nil(t, s.Pagination)
+		}, &Human{ID: 1})
+		listHumans(func(s *query.Scope) {
+			if assert.Len(t, s.AttributeFilters, 1) {
+				assert.Equal(t, "age", s.AttributeFilters[0].StructField.NeuronName())
+			}
+			// the number of the matching resources is not limited by default.
+			assert.Nil(t, s.Pagination)
+		}, &Human{ID: 4})
+		listHumans(func(s *query.Scope) {
+			assert.Len(t, s.AttributeFilters, 0)
+			if assert.Len(t, s.PrimaryFilters, 1) && assert.Len(t, s.PrimaryFilters[0].Values, 1) {
+				assert.Equal(t, query.OpIn, s.PrimaryFilters[0].Values[0].Operator)
+				assert.Equal(t, []interface{}{1, 4}, s.PrimaryFilters[0].Values[0].Values)
+			}
+		}, &Human{ID: 1, Name: "Jon"})
+
+		resp := httptest.NewRecorder()
+		h.List(Human{}).ServeHTTP(resp, newRequest(t, "/humen?fields[humen]=name&search=4"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		humansRepo.AssertExpectations(t)
+	})
+
+	t.Run("TooManyResources", func(t *testing.T) {
+		h := NewC(c)
+		h.MaxFilterExpressionResources = 1
+
+		repo, err := c.GetRepository(Human{})
+		require.NoError(t, err)
+
+		humansRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		// the multiple fields search is limited by the opt-in filter expression resources limit.
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			v, ok := s.Value.(*[]*Human)
+			require.True(t, ok)
+			*v = append(*v, &Human{ID: 1}, &Human{ID: 2})
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.ListWith(Human{}).SearchFields("name", "age").Handler().ServeHTTP(resp, newRequest(t, "/humen?fields[humen]=name&search=4"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		humansRepo.AssertExpectations(t)
+	})
+
+	t.Run("ScopeFilters", func(t *testing.T) {
+		h := NewC(c)
+		h.Authorizer = AuthorizerFunc(func(req *http.Request, endpoint EndpointType, model *mapping.ModelStruct, field *mapping.StructField, s *query.Scope) error {
+			return s.FilterField(query.NewFilter(model.Primary(), query.OpIn, 1, 4))
+		})
+
+		repo, err := c.GetRepository(Human{})
+		require.NoError(t, err)
+
+		humansRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+
+		// the attribute queries are restricted by the root scope and authorizer filters.
+		for _, name := range []string{"name", "age"} {
+			name := name
+			humansRepo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+				s, ok := args[1].(*query.Scope)
+				require.True(t, ok)
+
+				if assert.Len(t, s.AttributeFilters, 2) {
+					assert.Equal(t, "name", s.AttributeFilters[0].StructField.NeuronName())
+					assert.Equal(t, query.OpNotEqual, s.AttributeFilters[0].Values[0].Operator)
+					assert.Equal(t, name, s.AttributeFilters[1].StructField.NeuronName())
+				}
+				if assert.Len(t, s.PrimaryFilters, 1) {
+					assert.Equal(t, []interface{}{1, 4}, s.PrimaryFilters[0].Values[0].Values)
+				}
+				v, ok := s.Value.(*[]*Human)
+				require.True(t, ok)
+				*v = append(*v, &Human{ID: 1})
+			}).Return(nil)
+		}
+		humansRepo.On("List", mock.Anything, mock.Anything).Once().Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.ListWith(Human{}).SearchFields("name", "age").Handler().ServeHTTP(resp, newRequest(t, "/humen?fields[humen]=name&filter[humen][name][$ne]=Jon&search=4"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		humansRepo.AssertExpectations(t)
+	})
+
+	t.Run("Searcher", func(t *testing.T) {
+		h := NewC(c)
+		var searched string
+		searcher := SearcherFunc(func(ctx context.Context, s *query.Scope, text string) error {
+			searched = text
+			return nil
+		})
+		mockRepo.On("List", mock.Anything, mock.Anything).Once().Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.ListWith(House{}).Searcher(searcher).Handler().ServeHTTP(resp, newRequest(t, "/houses?fields[houses]=address&search=main+road"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "main road", searched)
+	})
+
+	t.Run("NotSupported", func(t *testing.T) {
+		h := NewC(c)
+		h.StrictQueriesMode = true
+
+		resp := httptest.NewRecorder()
+		h.List(House{}).ServeHTTP(resp, newRequest(t, "/houses?fields[houses]=address&search=Main"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+
+	handlerErrors "github.com/neuronlabs/jsonapi-handler/errors"
+	"github.com/neuronlabs/jsonapi-handler/errors/class"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// QueryParamDeleted is the query parameter that selects the soft deleted resources. It is available
+// only for the endpoints with the AllowDeletedFilter option. The value 'true' selects only the soft deleted
+// resources, the value 'false' selects only the resources that are not deleted.
+const QueryParamDeleted = "filter[deleted]"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// RegisterSoftDelete enables the soft delete of the 'model' resources. The 'field' is the neuron name
+// of the deletion timestamp attribute of type *time.Time. The Delete endpoint sets the attribute to current time
+// instead of deleting the resource. The soft deleted resources are excluded from the List, Get, related, relationship
+// endpoints and included resources, and are not patched by the Patch endpoint. The attribute is not filterable
+// by the client filters - use the QueryParamDeleted instead - and is not writable by the Create and Patch endpoints. Panics if the attribute is not found or is not
+// of *time.Time type.
+func (h *Creator) RegisterSoftDelete(model interface{}, field string) {
+	mappedModel := h.c.MustGetModelStruct(model)
+	attr, ok := mappedModel.Attribute(field)
+	if !ok {
+		log.Panicf("Soft delete attribute: '%s' not found within model: '%s'", field, mappedModel.Collection())
+	}
+	if t := attr.ReflectField().Type; t.Kind() != reflect.Ptr || t.Elem() != timeType {
+		log.Panicf("Soft delete attribute: '%s' of model: '%s' must be of *time.Time type", field, mappedModel.Collection())
+	}
+	h.softDeletes[mappedModel] = attr
+}
+
+// AllowDeletedFilter enables the 'filter[deleted]' query parameter for given endpoint handler.
+// It is used by the Get endpoint.
+func (e *EndpointHandler) AllowDeletedFilter() *EndpointHandler {
+	e.options.allowDeletedFilter = true
+	return e
+}
+
+// AllowDeletedFilter enables the 'filter[deleted]' query parameter for given list endpoint.
+func (l *ListHandlerCreator) AllowDeletedFilter() *ListHandlerCreator {
+	l.options.allowDeletedFilter = true
+	return l
+}
+
+// RestoreWith returns the EndpointHandler that restores the soft deleted resource of the 'model'.
+func (h *Creator) RestoreWith(model interface{}) *EndpointHandler {
+	return &EndpointHandler{
+		model:   h.c.MustGetModelStruct(model),
+		handler: h.handleRestore,
+	}
+}
+
+// Restore returns the http.HandlerFunc that restores the soft deleted resource of the 'model'.
+// The 'model' must have the soft delete registered. On success it responds with the '204' status.
+func (h *Creator) Restore(model interface{}) http.HandlerFunc {
+	return h.handleRestore(h.c.MustGetModelStruct(model), &endpointOptions{})
+}
+
+func (h *Creator) handleRestore(model *mapping.ModelStruct, o *endpointOptions) http.HandlerFunc {
+	field, ok := h.softDeletes[model]
+	if !ok {
+		log.Panicf("Restore endpoint of the model: '%s' without soft delete registered", model.Collection())
+	}
+	h.registerEndpoint(EndpointRestore, model, nil, o.basePath)
+	return func(rw http.ResponseWriter, req *http.Request) {
+		if h.endpointNotAllowed(rw, req, EndpointRestore, model, nil) {
+			return
+		}
+		if err := h.parseQuery(req.Context(), req, EndpointRestore, nil, o); err != nil {
+			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
+			return
+		}
+		id := h.getID(req, model)
+		if id == "" {
+			log.Debugf("[RESTORE][%s] Empty id params", model.Collection())
+			err := handlerErrors.ErrBadRequest()
+			err.Detail = "Provided empty 'id' in url"
+			h.marshalErrors(rw, req, 0, err)
+			return
+		}
+		idValues, err := h.decodeID(model, id)
+		if err != nil {
+			log.Debugf("[RESTORE][%s] Invalid URL id value: '%s': '%v'", model.Collection(), id, err)
+			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
+			return
+		}
+
+		s := query.NewModelC(h.c, model, false)
+		if err = filterID(s, idValues); err != nil {
+			log.Errorf("[RESTORE][%s] Adding param primary filter with value: '%s' failed: %v", model.Collection(), id, err)
+			h.marshalErrors(rw, req, 0, handlerErrors.ErrInternalError())
+			return
+		}
+		if errs := h.authorize(req, EndpointRestore, model, nil, s); errs != nil {
+			h.marshalErrors(rw, req, 0, errs...)
+			return
+		}
+
+		// only the soft deleted resource might be restored.
+		if err = s.FilterField(query.NewFilter(field, query.OpNotNull)); err != nil {
+			log.Errorf("[RESTORE][%s] Adding deleted filter failed: %v", model.Collection(), err)
+			h.marshalErrors(rw, req, 0, handlerErrors.ErrInternalError())
+			return
+		}
+		if err = s.SetFields(field); err != nil {
+			log.Errorf("[RESTORE][%s] Setting fieldset failed: %v", model.Collection(), err)
+			h.marshalErrors(rw, req, 0, handlerErrors.ErrInternalError())
+			return
+		}
+		if err = s.PatchContext(req.Context()); err != nil {
+			log.Debugf("[RESTORE][%s][%s] failed: %v", model.Collection(), id, err)
+			h.marshalErrors(rw, req, 0, handlerErrors.MapError(err)...)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// softDelete marks the resources of the scope 's' as deleted by patching the deletion timestamp 'field'.
+// Already deleted resources are not patched.
+func (h *Creator) softDelete(ctx context.Context, s *query.Scope, field *mapping.StructField) error {
+	now := time.Now()
+	reflect.ValueOf(s.Value).Elem().FieldByIndex(field.ReflectField().Index).Set(reflect.ValueOf(&now))
+	if err := s.SetFields(field); err != nil {
+		return err
+	}
+	if err := s.FilterField(query.NewFilter(field, query.OpIsNull)); err != nil {
+		return err
+	}
+	return s.PatchContext(ctx)
+}
+
+// excludeSoftDeleted adds the filters that excludes the soft deleted resources into the scope 's' and
+// its included scopes. The scope with the accepted 'filter[deleted]' query parameter is not changed.
+func (h *Creator) excludeSoftDeleted(s *query.Scope) error {
+	if len(h.softDeletes) == 0 {
+		return nil
+	}
+	for _, scope := range append([]*query.Scope{s}, s.IncludedScopes()...) {
+		field, ok := h.softDeletes[scope.Struct()]
+		if !ok {
+			continue
+		}
+		if _, deletedFilter := scope.StoreGet(scopeDeletedFilterK); deletedFilter {
+			continue
+		}
+		if err := scope.FilterField(query.NewFilter(field, query.OpIsNull)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSoftDeleteFilter checks if the filter 'f' and its nested filters don't filter the deletion timestamp fields.
+// The soft deleted resources might be selected only with the 'filter[deleted]' query parameter.
+func (h *Creator) checkSoftDeleteFilter(f *query.FilterField) error {
+	if len(h.softDeletes) == 0 {
+		return nil
+	}
+	if field, ok := h.softDeletes[f.StructField.ModelStruct()]; ok && field == f.StructField {
+		err := errors.NewDetf(class.QueryInvalidParameter, "filtering soft delete field: '%s'", field.NeuronName())
+		err.SetDetailsf("The field: '%s' is not filterable. Use the '%s' query parameter instead.", field.NeuronName(), QueryParamDeleted)
+		return err
+	}
+	for _, nested := range f.Nested {
+		if err := h.checkSoftDeleteFilter(nested); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queryParameterDeleted sets the soft deleted filter for the scope 's' with the 'filter[deleted]' query 'value'.
+func (h *Creator) queryParameterDeleted(s *query.Scope, value string) error {
+	field, ok := h.softDeletes[s.Struct()]
+	if !ok {
+		err := errors.NewDetf(class.QueryInvalidParameter, "model doesn't support soft delete")
+		err.SetDetailsf("The query parameter: '%s' is not supported for the collection: '%s'.", QueryParamDeleted, s.Struct().Collection())
+		return err
+	}
+	var operator *query.Operator
+	switch value {
+	case "true":
+		operator = query.OpNotNull
+	case "false":
+		operator = query.OpIsNull
+	default:
+		err := errors.NewDetf(class.QueryInvalidParameter, "invalid deleted filter value: '%s'", value)
+		err.SetDetailsf("The query parameter: '%s' value must be 'true' or 'false'.", QueryParamDeleted)
+		return err
+	}
+	s.StoreSet(scopeDeletedFilterK, struct{}{})
+	return s.FilterField(query.NewFilter(field, operator))
+}
+
+var scopeDeletedFilterK scopeDeletedFilter
+
+type scopeDeletedFilter struct{}
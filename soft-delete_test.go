@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+
+	handlerErrors "github.com/neuronlabs/jsonapi-handler/errors"
+)
+
+// TestSoftDelete tests the soft delete of the models.
+func TestSoftDelete(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{}, Pet{})
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, method, target string) *http.Request {
+		req, err := http.NewRequest(method, target, nil)
+		require.NoError(t, err)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req.WithContext(context.WithValue(context.Background(), IDKey, "1"))
+	}
+
+	petsRepo := func(t *testing.T) *mocks.Repository {
+		repo, err := c.GetRepository(Pet{})
+		require.NoError(t, err)
+
+		petsRepo, ok := repo.(*mocks.Repository)
+		require.True(t, ok)
+		return petsRepo
+	}
+
+	removedFilter := func(t *testing.T, s *query.Scope) *query.FilterField {
+		for _, filter := range s.AttributeFilters {
+			if filter.StructField.NeuronName() == "removed_at" {
+				return filter
+			}
+		}
+		t.Errorf("removed_at filter not found")
+		return nil
+	}
+
+	t.Run("Register", func(t *testing.T) {
+		h := NewC(c)
+		assert.Panics(t, func() { h.RegisterSoftDelete(Pet{}, "name") })
+		assert.Panics(t, func() { h.RegisterSoftDelete(Pet{}, "unknown") })
+		assert.Panics(t, func() { h.Restore(House{}) })
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterSoftDelete(Pet{}, "removed_at")
+
+		repo := petsRepo(t)
+		repo.On("Begin", mock.Anything, mock.Anything).Once().Return(nil)
+		repo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			v, ok := s.Value.(*[]*Pet)
+			require.True(t, ok)
+			*v = append(*v, &Pet{ID: 1})
+		}).Return(nil)
+		repo.On("Patch", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			v, ok := s.Value.(*Pet)
+			require.True(t, ok)
+			assert.NotNil(t, v.RemovedAt)
+		}).Return(nil)
+		repo.On("Commit", mock.Anything, mock.Anything).Once().Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.Delete(Pet{}).ServeHTTP(resp, newRequest(t, "DELETE", "/pets/1"))
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+		repo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterSoftDelete(Pet{}, "removed_at")
+
+		petsRepo(t).On("Get", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if filter := removedFilter(t, s); filter != nil && assert.Len(t, filter.Values, 1) {
+				assert.Equal(t, query.OpIsNull, filter.Values[0].Operator)
+			}
+			v, ok := s.Value.(*Pet)
+			require.True(t, ok)
+			v.ID = 1
+			v.Name = "Rex"
+		}).Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.Get(Pet{}).ServeHTTP(resp, newRequest(t, "GET", "/pets/1"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("DeletedFilter", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterSoftDelete(Pet{}, "removed_at")
+
+		// the deleted filter is not allowed by default.
+		resp := httptest.NewRecorder()
+		h.List(Pet{}).ServeHTTP(resp, newRequest(t, "GET", "/pets?filter[deleted]=true"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		resp = httptest.NewRecorder()
+		h.ListWith(Pet{}).AllowDeletedFilter().Handler().ServeHTTP(resp, newRequest(t, "GET", "/pets?filter[deleted]=maybe"))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+		petsRepo(t).On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if assert.Len(t, s.AttributeFilters, 1) {
+				filter := removedFilter(t, s)
+				if filter != nil && assert.Len(t, filter.Values, 1) {
+					assert.Equal(t, query.OpNotNull, filter.Values[0].Operator)
+				}
+			}
+			now := time.Now()
+			v, ok := s.Value.(*[]*Pet)
+			require.True(t, ok)
+			*v = append(*v, &Pet{ID: 1, Name: "Rex", RemovedAt: &now})
+		}).Return(nil)
+
+		resp = httptest.NewRecorder()
+		h.ListWith(Pet{}).AllowDeletedFilter().Handler().ServeHTTP(resp, newRequest(t, "GET", "/pets?filter[deleted]=true"))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterSoftDelete(Pet{}, "removed_at")
+
+		repo := petsRepo(t)
+		repo.On("Begin", mock.Anything, mock.Anything).Once().Return(nil)
+		repo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			if filter := removedFilter(t, s); filter != nil && assert.Len(t, filter.Values, 1) {
+				assert.Equal(t, query.OpNotNull, filter.Values[0].Operator)
+			}
+			v, ok := s.Value.(*[]*Pet)
+			require.True(t, ok)
+			*v = append(*v, &Pet{ID: 1})
+		}).Return(nil)
+		repo.On("Patch", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			v, ok := s.Value.(*Pet)
+			require.True(t, ok)
+			assert.Nil(t, v.RemovedAt)
+		}).Return(nil)
+		repo.On("Commit", mock.Anything, mock.Anything).Once().Return(nil)
+
+		resp := httptest.NewRecorder()
+		h.Restore(Pet{}).ServeHTTP(resp, newRequest(t, "POST", "/pets/1/restore"))
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+
+		endpoints := h.Endpoints()
+		if assert.Len(t, endpoints, 1) {
+			assert.Equal(t, "/pets/{id}/restore", endpoints[0].Path())
+			assert.Equal(t, http.MethodPost, endpoints[0].Type.Method())
+		}
+	})
+
+	t.Run("RawFilter", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterSoftDelete(Pet{}, "removed_at")
+
+		// the deletion field filters doesn't select the soft deleted resources.
+		for _, target := range []string{
+			"/pets?filter[pets][removed_at][$notnull]",
+			"/pets?filter[expression]=removed_at+notnull",
+		} {
+			resp := httptest.NewRecorder()
+			h.ListWith(Pet{}).Handler().ServeHTTP(resp, newRequest(t, "GET", target))
+			assert.Equal(t, http.StatusBadRequest, resp.Code, target)
+		}
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterSoftDelete(Pet{}, "removed_at")
+
+		repo := petsRepo(t)
+		repo.On("Begin", mock.Anything, mock.Anything).Once().Return(nil)
+		repo.On("List", mock.Anything, mock.Anything).Once().Run(func(args mock.Arguments) {
+			s, ok := args[1].(*query.Scope)
+			require.True(t, ok)
+
+			// the soft deleted resources are not patched.
+			if filter := removedFilter(t, s); filter != nil && assert.Len(t, filter.Values, 1) {
+				assert.Equal(t, query.OpIsNull, filter.Values[0].Operator)
+			}
+			v, ok := s.Value.(*[]*Pet)
+			require.True(t, ok)
+			*v = append(*v, &Pet{ID: 1})
+		}).Return(nil)
+		repo.On("Patch", mock.Anything, mock.Anything).Once().Return(nil)
+		repo.On("Commit", mock.Anything, mock.Anything).Once().Return(nil)
+
+		req := newRequest(t, "PATCH", "/pets/1")
+		req.Body = ioutil.NopCloser(strings.NewReader(`{"data":{"type":"pets","id":"1","attributes":{"name":"Rex"}}}`))
+		req.Header.Set("Content-Type", jsonapi.MediaType)
+		req.Header.Del("Accept")
+
+		resp := httptest.NewRecorder()
+		h.Patch(Pet{}).ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("WriteDeletedAt", func(t *testing.T) {
+		h := NewC(c)
+		h.RegisterSoftDelete(Pet{}, "removed_at")
+
+		for _, method := range []string{"PATCH", "POST"} {
+			target, body := "/pets/1", `{"data":{"type":"pets","id":"1","attributes":{"removed_at":1577836800}}}`
+			handler := h.Patch(Pet{})
+			if method == "POST" {
+				target, body = "/pets", `{"data":{"type":"pets","attributes":{"name":"Rex","removed_at":1577836800}}}`
+				handler = h.Create(Pet{})
+			}
+			req := newRequest(t, method, target)
+			req.Body = ioutil.NopCloser(strings.NewReader(body))
+			req.Header.Set("Content-Type", jsonapi.MediaType)
+
+			// the resources are soft deleted only by the Delete endpoint.
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			assert.Equal(t, http.StatusForbidden, resp.Code, method)
+
+			payload := struct {
+				Errors []*handlerErrors.SourceError `json:"errors"`
+			}{}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+			if assert.Len(t, payload.Errors, 1, method) && assert.NotNil(t, payload.Errors[0].Source, method) {
+				assert.Equal(t, "/data/attributes/removed_at", payload.Errors[0].Source.Pointer, method)
+			}
+		}
+	})
+}
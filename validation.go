@@ -0,0 +1,302 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/neuronlabs/errors"
+	"github.com/neuronlabs/neuron-core/mapping"
+	"github.com/neuronlabs/neuron-core/query"
+
+	handlerErrors "github.com/neuronlabs/jsonapi-handler/errors"
+	"github.com/neuronlabs/jsonapi-handler/errors/class"
+	"github.com/neuronlabs/jsonapi-handler/log"
+)
+
+// ValidateFunc is the custom validation function of the attribute 'value'. The 'value' is of the same type
+// as the model's field. A non nil error marks the value as invalid. If the error is not an errors.ClassError
+// its message is used as the error details.
+type ValidateFunc func(ctx context.Context, value interface{}) error
+
+// ModelValidation is the declarative validation of the model's attributes. The validation is done
+// by the create and patch endpoints after the request document is unmarshaled and before
+// the BeforeCreate and BeforePatch hooks are executed. All the validation failures are
+// returned at once as the '422' errors with the source pointers of the invalid attributes.
+type ModelValidation struct {
+	model  *mapping.ModelStruct
+	fields []*FieldValidation
+}
+
+// Validation gets the attributes validation for the 'model'.
+func (h *Creator) Validation(model interface{}) *ModelValidation {
+	mappedModel := h.c.MustGetModelStruct(model)
+	v, ok := h.validations[mappedModel]
+	if !ok {
+		v = &ModelValidation{model: mappedModel}
+		h.validations[mappedModel] = v
+	}
+	return v
+}
+
+// Field gets the validation of the model's attribute with the neuron name 'field'.
+// Panics if the model doesn't have such attribute.
+func (m *ModelValidation) Field(field string) *FieldValidation {
+	attr, ok := m.model.Attribute(field)
+	if !ok {
+		log.Panicf("Validation attribute: '%s' not found within model: '%s'", field, m.model.Collection())
+	}
+	for _, f := range m.fields {
+		if f.field == attr {
+			return f
+		}
+	}
+	f := &FieldValidation{m: m, field: attr}
+	m.fields = append(m.fields, f)
+	return f
+}
+
+// FieldValidation is the set of the validation rules of the single attribute.
+type FieldValidation struct {
+	m     *ModelValidation
+	field *mapping.StructField
+
+	required             bool
+	min, max             *float64
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	enum                 []interface{}
+	funcs                []ValidateFunc
+}
+
+// Field gets the validation of another model's attribute with the neuron name 'field'.
+func (f *FieldValidation) Field(field string) *FieldValidation {
+	return f.m.Field(field)
+}
+
+// Required marks the attribute as required. The create document must contain the attribute and the value
+// of the pointer attribute can't be null in both create and patch documents. The zero values i.e. 'false' or '0'
+// sent by the client are valid.
+func (f *FieldValidation) Required() *FieldValidation {
+	f.required = true
+	return f
+}
+
+// Min sets the minimum value of the numeric attribute.
+func (f *FieldValidation) Min(min float64) *FieldValidation {
+	f.mustBeKind("Min", isNumericKind)
+	f.min = &min
+	return f
+}
+
+// Max sets the maximum value of the numeric attribute.
+func (f *FieldValidation) Max(max float64) *FieldValidation {
+	f.mustBeKind("Max", isNumericKind)
+	f.max = &max
+	return f
+}
+
+// MinLength sets the minimum length of the string, slice or map attribute. The length of the string
+// is the number of its characters.
+func (f *FieldValidation) MinLength(length int) *FieldValidation {
+	f.mustBeKind("MinLength", isLengthKind)
+	f.minLength = &length
+	return f
+}
+
+// MaxLength sets the maximum length of the string, slice or map attribute. The length of the string
+// is the number of its characters.
+func (f *FieldValidation) MaxLength(length int) *FieldValidation {
+	f.mustBeKind("MaxLength", isLengthKind)
+	f.maxLength = &length
+	return f
+}
+
+// Length sets the minimum and maximum length of the string, slice or map attribute.
+func (f *FieldValidation) Length(min, max int) *FieldValidation {
+	return f.MinLength(min).MaxLength(max)
+}
+
+// Pattern sets the regular expression that must match the string attribute value.
+// Panics if the 'expr' is not a valid regular expression.
+func (f *FieldValidation) Pattern(expr string) *FieldValidation {
+	f.mustBeKind("Pattern", func(k reflect.Kind) bool { return k == reflect.String })
+	f.pattern = regexp.MustCompile(expr)
+	return f
+}
+
+// Enum sets the allowed values of the attribute. The values are compared by their string representation.
+func (f *FieldValidation) Enum(values ...interface{}) *FieldValidation {
+	f.enum = values
+	return f
+}
+
+// Func adds the custom validation function of the attribute.
+func (f *FieldValidation) Func(validateFunc ValidateFunc) *FieldValidation {
+	f.funcs = append(f.funcs, validateFunc)
+	return f
+}
+
+func (f *FieldValidation) mustBeKind(rule string, isKind func(reflect.Kind) bool) {
+	t := f.field.ReflectField().Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !isKind(t.Kind()) {
+		log.Panicf("Validation rule: '%s' is not applicable for the field: '%s' of type: '%s'", rule, f.field.NeuronName(), t)
+	}
+}
+
+func isNumericKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func isLengthKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// RequiredAttributes gets the neuron names of the 'model' attributes marked as required by the registered
+// validation. The result might be used as the jsonschema.Options Required function.
+func (h *Creator) RequiredAttributes(model *mapping.ModelStruct) []string {
+	m, ok := h.validations[model]
+	if !ok {
+		return nil
+	}
+	var required []string
+	for _, f := range m.fields {
+		if f.required {
+			required = append(required, f.field.NeuronName())
+		}
+	}
+	return required
+}
+
+// validate validates the attributes of the scope 's' value. If the 'create' is true the required attributes
+// must be present in the scope's fieldset. Otherwise only the attributes within the fieldset are validated.
+func (h *Creator) validate(ctx context.Context, s *query.Scope, create bool) []*handlerErrors.SourceError {
+	m, ok := h.validations[s.Struct()]
+	if !ok {
+		return nil
+	}
+	modelValue := reflect.ValueOf(s.Value).Elem()
+	var errs []*handlerErrors.SourceError
+	for _, f := range m.fields {
+		var fieldErrors []error
+		if _, inFieldset := s.Fieldset[f.field.NeuronName()]; inFieldset {
+			fieldErrors = f.validate(ctx, modelValue.FieldByIndex(f.field.ReflectField().Index))
+		} else if create && f.required {
+			fieldErrors = append(fieldErrors, f.requiredError())
+		}
+		for _, err := range fieldErrors {
+			for _, apiErr := range handlerErrors.MapError(err) {
+				errs = append(errs, handlerErrors.PointerError(apiErr, "/data/attributes/"+f.field.NeuronName()))
+			}
+		}
+	}
+	return errs
+}
+
+func (f *FieldValidation) validate(ctx context.Context, fieldValue reflect.Value) (errs []error) {
+	v := fieldValue
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			if f.required {
+				return []error{f.requiredError()}
+			}
+			return nil
+		}
+		v = v.Elem()
+	}
+	name := f.field.NeuronName()
+
+	if f.min != nil || f.max != nil {
+		var number float64
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			number = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			number = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			number = v.Float()
+		}
+		if f.min != nil && number < *f.min {
+			err := errors.NewDetf(class.InputValidationRange, "attribute: '%s' value is lower than: %v", name, *f.min)
+			err.SetDetailsf("The attribute: '%s' value must be greater than or equal to: %v.", name, *f.min)
+			errs = append(errs, err)
+		}
+		if f.max != nil && number > *f.max {
+			err := errors.NewDetf(class.InputValidationRange, "attribute: '%s' value is greater than: %v", name, *f.max)
+			err.SetDetailsf("The attribute: '%s' value must be lower than or equal to: %v.", name, *f.max)
+			errs = append(errs, err)
+		}
+	}
+
+	if f.minLength != nil || f.maxLength != nil {
+		length := v.Len()
+		if v.Kind() == reflect.String {
+			length = utf8.RuneCountInString(v.String())
+		}
+		if f.minLength != nil && length < *f.minLength {
+			err := errors.NewDetf(class.InputValidationLength, "attribute: '%s' is too short", name)
+			err.SetDetailsf("The attribute: '%s' length must be at least: %d.", name, *f.minLength)
+			errs = append(errs, err)
+		}
+		if f.maxLength != nil && length > *f.maxLength {
+			err := errors.NewDetf(class.InputValidationLength, "attribute: '%s' is too long", name)
+			err.SetDetailsf("The attribute: '%s' length must be at most: %d.", name, *f.maxLength)
+			errs = append(errs, err)
+		}
+	}
+
+	if f.pattern != nil && !f.pattern.MatchString(v.String()) {
+		err := errors.NewDetf(class.InputValidationPattern, "attribute: '%s' doesn't match the pattern", name)
+		err.SetDetailsf("The attribute: '%s' value must match the pattern: '%s'.", name, f.pattern.String())
+		errs = append(errs, err)
+	}
+
+	if len(f.enum) > 0 {
+		value := fmt.Sprint(v.Interface())
+		var found bool
+		for _, allowed := range f.enum {
+			if fmt.Sprint(allowed) == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			err := errors.NewDetf(class.InputValidationEnum, "attribute: '%s' value is not allowed", name)
+			err.SetDetailsf("The attribute: '%s' value must be one of: %v.", name, f.enum)
+			errs = append(errs, err)
+		}
+	}
+
+	for _, validateFunc := range f.funcs {
+		if err := validateFunc(ctx, fieldValue.Interface()); err != nil {
+			if _, ok := err.(errors.ClassError); !ok {
+				e := errors.NewDetf(class.InputValidationFunc, "attribute: '%s' is not valid: %v", name, err)
+				e.SetDetailsf("%s", err.Error())
+				err = e
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (f *FieldValidation) requiredError() error {
+	err := errors.NewDetf(class.InputValidationRequired, "attribute: '%s' is required", f.field.NeuronName())
+	err.SetDetailsf("The attribute: '%s' is required.", f.field.NeuronName())
+	return err
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/neuronlabs/neuron-core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neuronlabs/jsonapi"
+	"github.com/neuronlabs/neuron-core/config"
+	"github.com/neuronlabs/neuron-core/query"
+	mocks "github.com/neuronlabs/neuron-mocks"
+
+	handlerErrors "github.com/neuronlabs/jsonapi-handler/errors"
+)
+
+// TestValidation tests the attribute validation of the create and patch endpoints.
+func TestValidation(t *testing.T) {
+	c, err := neuron.NewController(config.Default())
+	require.NoError(t, err)
+
+	err = c.RegisterRepository("mock", &config.Repository{DriverName: mocks.DriverName})
+	require.NoError(t, err)
+
+	err = c.RegisterModels(Human{}, House{}, Car{}, HookChecker{}, Pet{})
+	require.NoError(t, err)
+
+	newHandler := func() *Creator {
+		h := NewC(c)
+		h.Validation(Human{}).
+			Field("name").Required().Length(2, 10).Pattern("^[A-Z]").
+			Field("age").Min(0).Max(150)
+		h.Validation(Car{}).
+			Field("brand").Enum("audi", "bmw").Func(func(ctx context.Context, value interface{}) error {
+			if value.(string) == "bmw" {
+				return fmt.Errorf("The brand: 'bmw' is sold out.")
+			}
+			return nil
+		})
+		return h
+	}
+
+	readErrors := func(t *testing.T, resp *httptest.ResponseRecorder) []*handlerErrors.SourceError {
+		t.Helper()
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.Code)
+		payload := struct {
+			Errors []*handlerErrors.SourceError `json:"errors"`
+		}{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+		return payload.Errors
+	}
+
+	newRequest := func(t *testing.T, method, target, body string) *http.Request {
+		req, err := http.NewRequest(method, target, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Add("Content-Type", jsonapi.MediaType)
+		req.Header.Add("Accept", jsonapi.MediaType)
+		req.Header.Add("Accept-Encoding", "identity")
+		return req
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		t.Run("Required", func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			req := newRequest(t, "POST", "/humen", `{"data":{"type":"humen","attributes":{"age":20}}}`)
+			newHandler().Create(Human{}).ServeHTTP(resp, req)
+
+			errs := readErrors(t, resp)
+			if assert.Len(t, errs, 1) {
+				assert.Equal(t, "422", errs[0].Status)
+				if assert.NotNil(t, errs[0].Source) {
+					assert.Equal(t, "/data/attributes/name", errs[0].Source.Pointer)
+				}
+			}
+		})
+
+		t.Run("Multiple", func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			req := newRequest(t, "POST", "/humen", `{"data":{"type":"humen","attributes":{"name":"averyveryverylongname","age":-1}}}`)
+			newHandler().Create(Human{}).ServeHTTP(resp, req)
+
+			errs := readErrors(t, resp)
+			pointers := map[string]int{}
+			for _, err := range errs {
+				if assert.NotNil(t, err.Source) {
+					pointers[err.Source.Pointer]++
+				}
+			}
+			// the name is too long and doesn't start with the capital letter, the age is negative.
+			assert.Equal(t, 2, pointers["/data/attributes/name"])
+			assert.Equal(t, 1, pointers["/data/attributes/age"])
+		})
+
+		t.Run("EnumAndFunc", func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			req := newRequest(t, "POST", "/cars", `{"data":{"type":"cars","attributes":{"brand":"fiat"}}}`)
+			newHandler().Create(Car{}).ServeHTTP(resp, req)
+
+			errs := readErrors(t, resp)
+			if assert.Len(t, errs, 1) {
+				assert.Equal(t, "/data/attributes/brand", errs[0].Source.Pointer)
+			}
+
+			resp = httptest.NewRecorder()
+			req = newRequest(t, "POST", "/cars", `{"data":{"type":"cars","attributes":{"brand":"bmw"}}}`)
+			newHandler().Create(Car{}).ServeHTTP(resp, req)
+
+			errs = readErrors(t, resp)
+			if assert.Len(t, errs, 1) {
+				assert.Equal(t, "The brand: 'bmw' is sold out.", errs[0].Detail)
+			}
+		})
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		// only the attributes within the document are validated - missing required 'name' is not an error.
+		resp := httptest.NewRecorder()
+		req := newRequest(t, "PATCH", "/humen/1", `{"data":{"type":"humen","id":"1","attributes":{"age":200}}}`)
+		req = req.WithContext(context.WithValue(context.Background(), IDKey, "1"))
+		newHandler().Patch(Human{}).ServeHTTP(resp, req)
+
+		errs := readErrors(t, resp)
+		if assert.Len(t, errs, 1) {
+			assert.Equal(t, "/data/attributes/age", errs[0].Source.Pointer)
+		}
+	})
+
+	t.Run("RequiredZero", func(t *testing.T) {
+		h := NewC(c)
+		h.Validation(HookChecker{}).Field("before").Required().Field("number").Required()
+		h.Validation(Pet{}).Field("removed_at").Required()
+
+		// the zero values sent by the client are valid.
+		s := query.NewModelC(c, c.MustGetModelStruct(HookChecker{}), false)
+		s.Value = &HookChecker{Before: false, Number: 0}
+		require.NoError(t, s.SetFields("before", "number"))
+		assert.Empty(t, h.validate(context.Background(), s, true))
+
+		// the attributes missing in the create document are not valid.
+		s = query.NewModelC(c, c.MustGetModelStruct(HookChecker{}), false)
+		s.Value = &HookChecker{}
+		require.NoError(t, s.SetFields("before"))
+		errs := h.validate(context.Background(), s, true)
+		if assert.Len(t, errs, 1) && assert.NotNil(t, errs[0].Source) {
+			assert.Equal(t, "/data/attributes/number", errs[0].Source.Pointer)
+		}
+
+		// the null pointer attributes are not valid.
+		s = query.NewModelC(c, c.MustGetModelStruct(Pet{}), false)
+		s.Value = &Pet{}
+		require.NoError(t, s.SetFields("removed_at"))
+		errs = h.validate(context.Background(), s, false)
+		if assert.Len(t, errs, 1) && assert.NotNil(t, errs[0].Source) {
+			assert.Equal(t, "/data/attributes/removed_at", errs[0].Source.Pointer)
+		}
+	})
+
+	t.Run("InvalidRule", func(t *testing.T) {
+		h := NewC(c)
+		assert.Panics(t, func() { h.Validation(Human{}).Field("age").Pattern("^[0-9]+$") })
+		assert.Panics(t, func() { h.Validation(Human{}).Field("unknown") })
+	})
+}